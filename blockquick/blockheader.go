@@ -4,9 +4,11 @@
 package blockquick
 
 import (
+	"bytes"
 	"fmt"
 	"log"
 	"math/big"
+	"time"
 
 	"github.com/diodechain/diode_client/crypto"
 	"github.com/diodechain/diode_client/crypto/secp256k1"
@@ -14,6 +16,10 @@ import (
 	bert "github.com/diodechain/gobert"
 )
 
+var (
+	ErrInvalidHeaderField = fmt.Errorf("invalid block header field")
+)
+
 // BlockHeader is the modified Ethereum Block header
 // It additionally contains a miner signature (minerSig)
 type BlockHeader struct {
@@ -25,10 +31,30 @@ type BlockHeader struct {
 	timestamp   uint64
 	number      uint64
 	nonce       big.Int
+	// UncleHash is the ommer hash sent by some forks, it's not part of the block hash
+	UncleHash [32]byte
+	// Difficulty is nil if the server didn't send it, it's not part of the block hash
+	Difficulty *big.Int
 }
 
-// NewHeader creates a new block header from existing data
-func NewHeader(txHash []byte, stateHash []byte, prevBlock []byte, minerSig []byte, minerPubkey []byte, timestamp uint64, number uint64, nonce big.Int) (bh BlockHeader, err error) {
+// NewHeader creates a new block header from existing data, difficulty may be nil
+func NewHeader(txHash []byte, stateHash []byte, prevBlock []byte, minerSig []byte, minerPubkey []byte, timestamp uint64, number uint64, nonce big.Int, difficulty *big.Int) (bh BlockHeader, err error) {
+	for _, field := range []struct {
+		name   string
+		value  []byte
+		length int
+	}{
+		{"txHash", txHash, 32},
+		{"stateHash", stateHash, 32},
+		{"prevBlock", prevBlock, 32},
+		{"minerSig", minerSig, 65},
+		{"minerPubkey", minerPubkey, 65},
+	} {
+		if len(field.value) != field.length {
+			err = fmt.Errorf("%w: %s must be %d bytes but is %d", ErrInvalidHeaderField, field.name, field.length, len(field.value))
+			return
+		}
+	}
 	header := BlockHeader{
 		txHash:      txHash,
 		stateHash:   stateHash,
@@ -38,6 +64,7 @@ func NewHeader(txHash []byte, stateHash []byte, prevBlock []byte, minerSig []byt
 		timestamp:   timestamp,
 		number:      number,
 		nonce:       nonce,
+		Difficulty:  difficulty,
 	}
 	if !header.ValidateSig() {
 		err = fmt.Errorf("invalid block %v %v", header, header.Hash())
@@ -76,7 +103,9 @@ func (bh *BlockHeader) Hash() (hash Sha3) {
 
 // Miner returns the block miners hash
 func (bh *BlockHeader) Miner() Address {
-	return util.PubkeyToAddress(bh.minerPubkey)
+	// the length of the miner pubkey is validated when the header is created
+	addr, _ := util.PubkeyToAddress(bh.minerPubkey)
+	return addr
 }
 
 // Timestamp returns the block timestamp
@@ -84,12 +113,22 @@ func (bh *BlockHeader) Timestamp() uint64 {
 	return bh.timestamp
 }
 
+// Age returns how long before now the block was minted, the timestamp is in unix seconds
+func (bh *BlockHeader) Age(now time.Time) time.Duration {
+	return now.Sub(time.Unix(int64(bh.timestamp), 0))
+}
+
 // Parent returns the block parents hash (the previous block hash)
 func (bh *BlockHeader) Parent() (hash Sha3) {
 	copy(hash[:], bh.prevBlock)
 	return
 }
 
+// IsGenesis returns true if the previous block hash is all zero
+func (bh *BlockHeader) IsGenesis() bool {
+	return bytes.Equal(bh.prevBlock, make([]byte, 32))
+}
+
 // Number returns the block number
 func (bh *BlockHeader) Number() uint64 {
 	return bh.number
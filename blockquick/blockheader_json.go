@@ -0,0 +1,88 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package blockquick
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/diodechain/diode_client/util"
+)
+
+// blockHeaderJSON is the json representation of block header, binaries are 0x prefixed hex
+type blockHeaderJSON struct {
+	TxHash      string   `json:"txHash"`
+	StateHash   string   `json:"stateHash"`
+	PrevBlock   string   `json:"prevBlock"`
+	MinerSig    string   `json:"minerSig"`
+	MinerPubkey string   `json:"minerPubkey"`
+	Timestamp   uint64   `json:"timestamp"`
+	Number      uint64   `json:"number"`
+	Nonce       *big.Int `json:"nonce"`
+	Difficulty  *big.Int `json:"difficulty,omitempty"`
+	UncleHash   string   `json:"uncleHash,omitempty"`
+}
+
+// MarshalJSON returns json of block header with hex encoded binaries
+func (bh BlockHeader) MarshalJSON() ([]byte, error) {
+	var uncleHash string
+	if bh.UncleHash != [32]byte{} {
+		uncleHash = util.EncodeToStringOmitEmpty(bh.UncleHash[:])
+	}
+	return json.Marshal(blockHeaderJSON{
+		TxHash:      util.EncodeToStringOmitEmpty(bh.txHash),
+		StateHash:   util.EncodeToStringOmitEmpty(bh.stateHash),
+		PrevBlock:   util.EncodeToStringOmitEmpty(bh.prevBlock),
+		MinerSig:    util.EncodeToStringOmitEmpty(bh.minerSig),
+		MinerPubkey: util.EncodeToStringOmitEmpty(bh.minerPubkey),
+		Timestamp:   bh.timestamp,
+		Number:      bh.number,
+		Nonce:       &bh.nonce,
+		Difficulty:  bh.Difficulty,
+		UncleHash:   uncleHash,
+	})
+}
+
+// UnmarshalJSON decodes block header from json with hex encoded binaries,
+// the miner signature is not validated
+func (bh *BlockHeader) UnmarshalJSON(data []byte) (err error) {
+	var bj blockHeaderJSON
+	if err = json.Unmarshal(data, &bj); err != nil {
+		return
+	}
+	header := BlockHeader{
+		timestamp:  bj.Timestamp,
+		number:     bj.Number,
+		Difficulty: bj.Difficulty,
+	}
+	if bj.Nonce != nil {
+		header.nonce.Set(bj.Nonce)
+	}
+	if header.txHash, err = util.DecodeStringOmitEmpty(bj.TxHash); err != nil {
+		return
+	}
+	if header.stateHash, err = util.DecodeStringOmitEmpty(bj.StateHash); err != nil {
+		return
+	}
+	if header.prevBlock, err = util.DecodeStringOmitEmpty(bj.PrevBlock); err != nil {
+		return
+	}
+	if header.minerSig, err = util.DecodeStringOmitEmpty(bj.MinerSig); err != nil {
+		return
+	}
+	if header.minerPubkey, err = util.DecodeStringOmitEmpty(bj.MinerPubkey); err != nil {
+		return
+	}
+	uncleHash, err := util.DecodeStringOmitEmpty(bj.UncleHash)
+	if err != nil {
+		return
+	}
+	if len(uncleHash) > 0 && len(uncleHash) != len(header.UncleHash) {
+		return fmt.Errorf("%w: uncleHash must be %d bytes but is %d", ErrInvalidHeaderField, len(header.UncleHash), len(uncleHash))
+	}
+	copy(header.UncleHash[:], uncleHash)
+	*bh = header
+	return
+}
@@ -0,0 +1,177 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package blockquick
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of blockquick.proto
+const (
+	protoStateHeaders protowire.Number = 1
+
+	protoHeaderTxHash      protowire.Number = 1
+	protoHeaderStateHash   protowire.Number = 2
+	protoHeaderPrevBlock   protowire.Number = 3
+	protoHeaderMinerSig    protowire.Number = 4
+	protoHeaderMinerPubkey protowire.Number = 5
+	protoHeaderTimestamp   protowire.Number = 6
+	protoHeaderNumber      protowire.Number = 7
+	protoHeaderNonce       protowire.Number = 8
+	protoHeaderDifficulty  protowire.Number = 9
+	protoHeaderUncleHash   protowire.Number = 10
+)
+
+var (
+	ErrInvalidProto = fmt.Errorf("invalid blockquick protobuf state")
+)
+
+// SaveBlockquickStateProto writes the headers to path as a BlockquickState
+// message of blockquick.proto
+func SaveBlockquickStateProto(path string, headers []*BlockHeader) error {
+	var state []byte
+	for _, bh := range headers {
+		state = protowire.AppendTag(state, protoStateHeaders, protowire.BytesType)
+		state = protowire.AppendBytes(state, bh.appendProto(nil))
+	}
+	return ioutil.WriteFile(path, state, 0600)
+}
+
+// LoadBlockquickStateProto reads the headers saved by SaveBlockquickStateProto,
+// the miner signatures are not validated
+func LoadBlockquickStateProto(path string) ([]*BlockHeader, error) {
+	state, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var headers []*BlockHeader
+	err = consumeProtoFields(state, func(num protowire.Number, typ protowire.Type, value []byte) (int, error) {
+		if num != protoStateHeaders || typ != protowire.BytesType {
+			return protowire.ConsumeFieldValue(num, typ, value), nil
+		}
+		msg, n := protowire.ConsumeBytes(value)
+		if n < 0 {
+			return n, nil
+		}
+		bh := &BlockHeader{}
+		if err := bh.consumeProto(msg); err != nil {
+			return n, err
+		}
+		headers = append(headers, bh)
+		return n, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+func (bh *BlockHeader) appendProto(msg []byte) []byte {
+	for _, field := range []struct {
+		num   protowire.Number
+		value []byte
+	}{
+		{protoHeaderTxHash, bh.txHash},
+		{protoHeaderStateHash, bh.stateHash},
+		{protoHeaderPrevBlock, bh.prevBlock},
+		{protoHeaderMinerSig, bh.minerSig},
+		{protoHeaderMinerPubkey, bh.minerPubkey},
+	} {
+		msg = protowire.AppendTag(msg, field.num, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, field.value)
+	}
+	msg = protowire.AppendTag(msg, protoHeaderTimestamp, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, bh.timestamp)
+	msg = protowire.AppendTag(msg, protoHeaderNumber, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, bh.number)
+	msg = protowire.AppendTag(msg, protoHeaderNonce, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, bh.nonce.Bytes())
+	if bh.Difficulty != nil {
+		msg = protowire.AppendTag(msg, protoHeaderDifficulty, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, bh.Difficulty.Bytes())
+	}
+	if bh.UncleHash != [32]byte{} {
+		msg = protowire.AppendTag(msg, protoHeaderUncleHash, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, bh.UncleHash[:])
+	}
+	return msg
+}
+
+func (bh *BlockHeader) consumeProto(msg []byte) error {
+	return consumeProtoFields(msg, func(num protowire.Number, typ protowire.Type, value []byte) (int, error) {
+		if num == protoHeaderTimestamp || num == protoHeaderNumber {
+			if typ != protowire.VarintType {
+				return 0, fmt.Errorf("%w: field %d is not a varint", ErrInvalidProto, num)
+			}
+			v, n := protowire.ConsumeVarint(value)
+			if num == protoHeaderTimestamp {
+				bh.timestamp = v
+			} else {
+				bh.number = v
+			}
+			return n, nil
+		}
+		var dst *[]byte
+		switch num {
+		case protoHeaderTxHash:
+			dst = &bh.txHash
+		case protoHeaderStateHash:
+			dst = &bh.stateHash
+		case protoHeaderPrevBlock:
+			dst = &bh.prevBlock
+		case protoHeaderMinerSig:
+			dst = &bh.minerSig
+		case protoHeaderMinerPubkey:
+			dst = &bh.minerPubkey
+		case protoHeaderNonce, protoHeaderDifficulty, protoHeaderUncleHash:
+		default:
+			return protowire.ConsumeFieldValue(num, typ, value), nil
+		}
+		if typ != protowire.BytesType {
+			return 0, fmt.Errorf("%w: field %d is not bytes", ErrInvalidProto, num)
+		}
+		v, n := protowire.ConsumeBytes(value)
+		if n < 0 {
+			return n, nil
+		}
+		if num == protoHeaderUncleHash {
+			if len(v) != len(bh.UncleHash) {
+				return 0, fmt.Errorf("%w: uncle_hash must be %d bytes but is %d", ErrInvalidProto, len(bh.UncleHash), len(v))
+			}
+			copy(bh.UncleHash[:], v)
+		} else if num == protoHeaderDifficulty {
+			bh.Difficulty = new(big.Int).SetBytes(v)
+		} else if dst == nil {
+			bh.nonce.SetBytes(v)
+		} else {
+			*dst = append([]byte(nil), v...)
+		}
+		return n, nil
+	})
+}
+
+// consumeProtoFields calls consume with the value of each field in msg,
+// consume returns the length of the value it read
+func consumeProtoFields(msg []byte, consume func(num protowire.Number, typ protowire.Type, value []byte) (int, error)) error {
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			return fmt.Errorf("%w: %v", ErrInvalidProto, protowire.ParseError(n))
+		}
+		msg = msg[n:]
+		n, err := consume(num, typ, msg)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("%w: %v", ErrInvalidProto, protowire.ParseError(n))
+		}
+		msg = msg[n:]
+	}
+	return nil
+}
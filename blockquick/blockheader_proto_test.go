@@ -0,0 +1,114 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package blockquick
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestBlockquickStateProto(t *testing.T) {
+	var headers []*BlockHeader
+	for i := 0; i < 10; i++ {
+		header := newTestHeader()
+		header.number += uint64(i)
+		if i%2 == 1 {
+			header.Difficulty = big.NewInt(0x020000)
+		}
+		if i%3 == 0 {
+			header.UncleHash = [32]byte{byte(i + 1)}
+		}
+		headers = append(headers, &header)
+	}
+	path := filepath.Join(t.TempDir(), "blockquick.pb")
+	if err := SaveBlockquickStateProto(path, headers); err != nil {
+		t.Fatal(err)
+	}
+
+	// read the file without the generated types, all fields are unknown
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &emptypb.Empty{}
+	if err = (proto.UnmarshalOptions{DiscardUnknown: false}).Unmarshal(data, state); err != nil {
+		t.Fatal(err)
+	}
+	raw := state.ProtoReflect().GetUnknown()
+	count := 0
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 || num != protoStateHeaders || typ != protowire.BytesType {
+			t.Fatalf("unexpected state field %d %d", num, typ)
+		}
+		raw = raw[n:]
+		msg, n := protowire.ConsumeBytes(raw)
+		raw = raw[n:]
+		header := &emptypb.Empty{}
+		if err = proto.Unmarshal(msg, header); err != nil {
+			t.Fatal(err)
+		}
+		fields := 0
+		for fraw := header.ProtoReflect().GetUnknown(); len(fraw) > 0; fields++ {
+			num, typ, n := protowire.ConsumeField(fraw)
+			if n < 0 || num < protoHeaderTxHash || num > protoHeaderUncleHash {
+				t.Fatalf("unexpected header field %d %d", num, typ)
+			}
+			fraw = fraw[n:]
+		}
+		expected := 8 + count%2
+		if count%3 == 0 {
+			expected++
+		}
+		if fields != expected {
+			t.Fatalf("header has %d fields, expected %d", fields, expected)
+		}
+		count++
+	}
+	if count != len(headers) {
+		t.Fatalf("state has %d headers, expected %d", count, len(headers))
+	}
+
+	loaded, err := LoadBlockquickStateProto(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, headers) {
+		t.Fatalf("loaded headers differ from the saved headers")
+	}
+	for i, header := range loaded {
+		if header.UncleHash != headers[i].UncleHash || header.Hash() != headers[i].Hash() {
+			t.Fatalf("loaded header %d has uncle hash %x and hash %x, expected %x and %x", i, header.UncleHash, header.Hash(), headers[i].UncleHash, headers[i].Hash())
+		}
+	}
+}
+
+func TestLoadBlockquickStateProtoInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blockquick.pb")
+	if err := ioutil.WriteFile(path, []byte{0x0a, 0x05, 0x01}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBlockquickStateProto(path); !errors.Is(err, ErrInvalidProto) {
+		t.Fatalf("expected error for truncated state")
+	}
+	// a header with a 2 bytes uncle_hash field
+	header := protowire.AppendTag(nil, protoHeaderUncleHash, protowire.BytesType)
+	header = protowire.AppendBytes(header, []byte{1, 2})
+	state := protowire.AppendTag(nil, protoStateHeaders, protowire.BytesType)
+	state = protowire.AppendBytes(state, header)
+	if err := ioutil.WriteFile(path, state, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBlockquickStateProto(path); !errors.Is(err, ErrInvalidProto) {
+		t.Fatalf("expected error for short uncle hash but got %v", err)
+	}
+}
@@ -5,14 +5,17 @@ package blockquick
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"math/big"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/diodechain/diode_client/crypto/secp256k1"
 )
 
-func TestCheckSignature(t *testing.T) {
-	// Create a new block header
+func newTestHeader() BlockHeader {
 	header := BlockHeader{
 		txHash:      []byte{200, 183, 173, 94, 219, 199, 203, 146, 222, 81, 226, 35, 194, 242, 25, 106, 84, 45, 151, 139, 134, 136, 185, 158, 10, 147, 97, 204, 251, 90, 163, 84},
 		stateHash:   []byte{194, 10, 97, 79, 230, 9, 109, 13, 140, 98, 183, 88, 131, 161, 234, 129, 23, 217, 163, 185, 152, 169, 40, 201, 128, 33, 106, 164, 64, 210, 18, 117},
@@ -25,6 +28,11 @@ func TestCheckSignature(t *testing.T) {
 	}
 
 	header.nonce.SetString("3463199413688948191257806122414904513570931607746675394846934843169", 10)
+	return header
+}
+
+func TestCheckSignature(t *testing.T) {
+	header := newTestHeader()
 
 	msgHash, err := header.HashWithoutSig()
 	if err != nil {
@@ -45,3 +53,123 @@ func TestCheckSignature(t *testing.T) {
 		t.Fatal("invalid signature")
 	}
 }
+
+func TestBlockHeaderJSON(t *testing.T) {
+	header := newTestHeader()
+	data, err := json.Marshal(&header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"number":6406857`) || !strings.Contains(string(data), `"nonce":3463199413688948191257806122414904513570931607746675394846934843169`) {
+		t.Errorf("numeric fields should be json numbers: %s", data)
+	}
+	var decoded BlockHeader
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.txHash, header.txHash) ||
+		!bytes.Equal(decoded.stateHash, header.stateHash) ||
+		!bytes.Equal(decoded.prevBlock, header.prevBlock) ||
+		!bytes.Equal(decoded.minerSig, header.minerSig) ||
+		!bytes.Equal(decoded.minerPubkey, header.minerPubkey) ||
+		decoded.timestamp != header.timestamp ||
+		decoded.number != header.number ||
+		decoded.nonce.Cmp(&header.nonce) != 0 {
+		t.Errorf("decoded header doesn't match: %+v != %+v", decoded, header)
+	}
+	if decoded.Hash() != header.Hash() || !decoded.ValidateSig() {
+		t.Errorf("decoded header should have the same hash and a valid signature")
+	}
+}
+
+func TestBlockHeaderJSONValue(t *testing.T) {
+	header := newTestHeader()
+	header.UncleHash = [32]byte{1, 2, 3}
+	header.Difficulty = big.NewInt(42)
+	// the header value is marshaled with MarshalJSON as well
+	data, err := json.Marshal(struct{ Header BlockHeader }{header})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"uncleHash":"0x0102030000000000000000000000000000000000000000000000000000000000"`) {
+		t.Errorf("uncle hash should be hex encoded: %s", data)
+	}
+	var decoded struct{ Header BlockHeader }
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Header.UncleHash != header.UncleHash || decoded.Header.Difficulty.Cmp(header.Difficulty) != 0 || decoded.Header.Hash() != header.Hash() {
+		t.Errorf("decoded header doesn't match: %+v != %+v", decoded.Header, header)
+	}
+}
+
+func TestBlockHeaderAge(t *testing.T) {
+	header := newTestHeader()
+	header.timestamp = uint64(time.Now().Unix() - 60)
+	age := header.Age(time.Now())
+	if age < time.Minute || age > time.Minute+2*time.Second {
+		t.Fatalf("expected age of about one minute, got %s", age)
+	}
+	if age := header.Age(time.Unix(int64(header.timestamp), 0)); age != 0 {
+		t.Fatalf("expected zero age, got %s", age)
+	}
+}
+
+func TestBlockHeaderIsGenesis(t *testing.T) {
+	header := newTestHeader()
+	if header.IsGenesis() {
+		t.Fatalf("header with previous block should not be genesis")
+	}
+	header.prevBlock = make([]byte, 32)
+	if !header.IsGenesis() {
+		t.Fatalf("header with zero previous block should be genesis")
+	}
+	header.prevBlock = nil
+	if header.IsGenesis() {
+		t.Fatalf("header without previous block should not be genesis")
+	}
+}
+
+func TestNewHeaderFieldLength(t *testing.T) {
+	valid := newTestHeader()
+	if _, err := NewHeader(valid.txHash, valid.stateHash, valid.prevBlock, valid.minerSig, valid.minerPubkey, valid.timestamp, valid.number, valid.nonce, nil); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		header func(bh *BlockHeader)
+	}{
+		{"txHash", func(bh *BlockHeader) { bh.txHash = bh.txHash[:10] }},
+		{"stateHash", func(bh *BlockHeader) { bh.stateHash = bh.stateHash[:31] }},
+		{"prevBlock", func(bh *BlockHeader) { bh.prevBlock = nil }},
+		{"minerSig", func(bh *BlockHeader) { bh.minerSig = bh.minerSig[:64] }},
+		{"minerPubkey", func(bh *BlockHeader) { bh.minerPubkey = bh.minerPubkey[:33] }},
+	}
+	for _, tt := range tests {
+		bh := newTestHeader()
+		tt.header(&bh)
+		_, err := NewHeader(bh.txHash, bh.stateHash, bh.prevBlock, bh.minerSig, bh.minerPubkey, bh.timestamp, bh.number, bh.nonce, nil)
+		if !errors.Is(err, ErrInvalidHeaderField) || !strings.Contains(err.Error(), tt.name) {
+			t.Errorf("expected invalid %s error but got %v", tt.name, err)
+		}
+	}
+}
+
+func TestValidateChainGenesis(t *testing.T) {
+	genesis := newTestHeader()
+	genesis.prevBlock = make([]byte, 32)
+	genesis.number = 0
+	if err := ValidateChain([]BlockHeader{genesis}); err != nil {
+		t.Fatalf("block 0 genesis should be valid: %v", err)
+	}
+
+	// a zero parent doesn't make a genesis block in the middle of the chain
+	fake := newTestHeader()
+	fake.prevBlock = make([]byte, 32)
+	if err := ValidateChain([]BlockHeader{newTestHeader(), fake}); err == nil {
+		t.Fatalf("genesis block in the middle of the chain should be invalid")
+	}
+	if err := ValidateChain([]BlockHeader{fake, newTestHeader()}); err == nil {
+		t.Fatalf("genesis block with number %d should be invalid", fake.number)
+	}
+}
@@ -36,6 +36,25 @@ type BlockScore struct {
 	isFinal bool
 }
 
+// ValidateChain checks that each header is the signed parent of the next header,
+// a genesis header is only accepted as block 0 at the start of the chain
+func ValidateChain(bhs []BlockHeader) error {
+	for i := range bhs {
+		if bhs[i].IsGenesis() && (i != 0 || bhs[i].Number() != 0) {
+			return fmt.Errorf("received invalid genesis block %v at position %v", bhs[i].Number(), i)
+		}
+	}
+	for i := len(bhs) - 2; i >= 0; i-- {
+		if bhs[i].Hash() != bhs[i+1].Parent() {
+			return fmt.Errorf("recevied blocks parent is not his parent: %+v %+v", bhs[i+1], bhs[i])
+		}
+		if !bhs[i].ValidateSig() {
+			return fmt.Errorf("recevied blocks signature is not valid: %v", bhs[i])
+		}
+	}
+	return nil
+}
+
 // New creates a new BlockQuick window
 func New(bhs []BlockHeader, windowSize int) (*Window, error) {
 	if len(bhs) != windowSize {
@@ -72,6 +72,7 @@ func init() {
 	diodeCmd.Flag.StringVar(&fleetFake, "fleet", "", "@deprecated. Use: 'diode config set fleet=0x1234' instead")
 
 	diodeCmd.Flag.DurationVar(&cfg.RemoteRPCTimeout, "timeout", 5*time.Second, "timeout seconds to connect to the remote rpc server")
+	diodeCmd.Flag.Uint64Var(&cfg.NetworkID, "networkid", 0, "refuse edge servers that don't advertise this network id (default: any network)")
 	diodeCmd.Flag.DurationVar(&cfg.RetryWait, "retrywait", 1*time.Second, "wait seconds before next retry")
 	diodeCmd.Flag.Var(&cfg.RemoteRPCAddrs, "diodeaddrs", "addresses of Diode node server (default: asia.prenet.diode.io:41046, europe.prenet.diode.io:41046, usa.prenet.diode.io:41046)")
 	diodeCmd.Flag.Var(&cfg.SBlockdomains, "blockdomains", "domains (bns names) that are not allowed")
@@ -332,7 +333,11 @@ func (dio *Diode) Init() error {
 			cfg.FleetAddr = config.DefaultFleetAddr
 		}
 
-		cfg.ClientAddr = util.PubkeyToAddress(rpc.LoadClientPubKey())
+		clientAddr, err := util.PubkeyToAddress(rpc.LoadClientPubKey())
+		if err != nil {
+			return fmt.Errorf("couldn't load the client key: %v", err)
+		}
+		cfg.ClientAddr = clientAddr
 
 		if !cfg.LoadFromFile {
 			fleetAddr, err := db.DB.Get("fleet")
@@ -47,6 +47,7 @@ type Config struct {
 	RemoteRPCTimeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
 	RetryTimes       int           `yaml:"retrytimes,omitempty" json:"retrytimes,omitempty"`
 	RetryWait        time.Duration `yaml:"retrywait,omitempty" json:"retrywait,omitempty"`
+	NetworkID        uint64        `yaml:"networkid,omitempty" json:"networkid,omitempty"`
 	RlimitNofile     int           `yaml:"rlimit_nofile,omitempty" json:"rlimit_nofile,omitempty"`
 	LogFilePath      string        `yaml:"logfilepath,omitempty" json:"logfilepath,omitempty"`
 	SBlockdomains    StringValues  `yaml:"blockdomains,omitempty" json:"blockdomains,omitempty"`
@@ -4,6 +4,9 @@
 package contract
 
 import (
+	"context"
+	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/diodechain/diode_client/accounts/abi"
@@ -23,6 +26,15 @@ const (
 	DeviceRootIndex
 	DeviceAllowlistIndex
 	AccessAllowlistIndex
+	// AccessAllowlistExpiryIndex only exists from AccessAllowlistExpiryVersion,
+	// the contract of FleetContractBin doesn't have it
+	AccessAllowlistExpiryIndex
+
+	// FleetContractVersion is the version of FleetContractBin
+	FleetContractVersion = 1
+	// AccessAllowlistExpiryVersion is the first fleet contract version with
+	// expiring access allowlist entries
+	AccessAllowlistExpiryVersion = 2
 
 	// FleetContractABI is the input ABI used to generate the binding from.
 	FleetContractABI = "[{\"constant\":false,\"inputs\":[{\"name\":\"_client\",\"type\":\"address\"},{\"name\":\"_value\",\"type\":\"bool\"}],\"name\":\"SetDeviceAllowlist\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"\",\"type\":\"address\"},{\"name\":\"\",\"type\":\"address\"}],\"name\":\"accessAllowlist\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"accountant\",\"outputs\":[{\"name\":\"\",\"type\":\"address\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"_device\",\"type\":\"address\"},{\"name\":\"_client\",\"type\":\"address\"},{\"name\":\"_value\",\"type\":\"bool\"}],\"name\":\"SetAccessAllowlist\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"operator\",\"outputs\":[{\"name\":\"\",\"type\":\"address\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"\",\"type\":\"address\"}],\"name\":\"deviceAllowlist\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"name\":\"_diodeRegistry\",\"type\":\"address\"},{\"name\":\"_operator\",\"type\":\"address\"},{\"name\":\"_accountant\",\"type\":\"address\"}],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"}]"
@@ -30,9 +42,28 @@ const (
 	FleetContractBin = "0x608060405234801561001057600080fd5b5060405160608061030183398101604090815281516020830151919092015160018054600160a060020a03938416600160a060020a03199182161790915560008054948416948216949094179093556002805492909116919092161790556102848061007d6000396000f3006080604052600436106100775763ffffffff7c01000000000000000000000000000000000000000000000000000000006000350416633c5f7d46811461007c5780634ef1aee4146100a45780634fb3ccc5146100df578063504f04b714610110578063570ca7351461013c578063d90bd65114610151575b600080fd5b34801561008857600080fd5b506100a2600160a060020a03600435166024351515610172565b005b3480156100b057600080fd5b506100cb600160a060020a03600435811690602435166101b4565b604080519115158252519081900360200190f35b3480156100eb57600080fd5b506100f46101d4565b60408051600160a060020a039092168252519081900360200190f35b34801561011c57600080fd5b506100a2600160a060020a036004358116906024351660443515156101e3565b34801561014857600080fd5b506100f4610234565b34801561015d57600080fd5b506100cb600160a060020a0360043516610243565b600154600160a060020a0316331461018957600080fd5b600160a060020a03919091166000908152600660205260409020805460ff1916911515919091179055565b600760209081526000928352604080842090915290825290205460ff1681565b600254600160a060020a031681565b600154600160a060020a031633146101fa57600080fd5b600160a060020a03928316600090815260076020908152604080832094909516825292909252919020805460ff1916911515919091179055565b600154600160a060020a031681565b60066020526000908152604090205460ff16815600a165627a7a723058205bc6b976a1f573c8d758f7014f6797ea418c25bcfe315a780a9164cfc10d7ad80029"
 )
 
+var (
+	ErrInvalidAddressLength = fmt.Errorf("address should be 20 bytes")
+	ErrNoStorageReader      = fmt.Errorf("fleet contract has no storage reader")
+	ErrNoBlockNumberReader  = fmt.Errorf("fleet contract has no block number reader")
+)
+
+// StorageReader reads the raw storage slot of key in the contract at addr
+type StorageReader func(addr [20]byte, key []byte) ([]byte, error)
+
+// BlockNumberReader returns the current block number
+type BlockNumberReader func(ctx context.Context) (uint64, error)
+
 // FleetContract is fleet contract struct
 type FleetContract struct {
-	ABI abi.ABI
+	ABI     abi.ABI
+	Address [20]byte
+	Storage StorageReader
+	// BlockNumber is needed to check access grants that expire
+	BlockNumber BlockNumberReader
+	// Version is the version of the deployed contract, zero stands for
+	// FleetContractVersion
+	Version int
 }
 
 // Address represents an Ethereum address
@@ -49,6 +80,18 @@ func NewFleetContract() (fleetContract FleetContract, err error) {
 	return
 }
 
+// NewFleetContractAt returns fleet contract struct bound to the deployed
+// contract at addr, reading its state through storage
+func NewFleetContractAt(addr [20]byte, storage StorageReader) (fleetContract FleetContract, err error) {
+	fleetContract, err = NewFleetContract()
+	if err != nil {
+		return
+	}
+	fleetContract.Address = addr
+	fleetContract.Storage = storage
+	return
+}
+
 // DeployFleetContract returns deploy fleet contract data
 func (fleetContract *FleetContract) DeployFleetContract(_diodeRegistry Address, _operator Address, _accountant Address) (data []byte, err error) {
 	var decBin []byte
@@ -91,3 +134,105 @@ func AccessAllowlistKey(deviceAddr Address, clientAddr Address) []byte {
 	baseKey := crypto.Sha3Hash(append(padDeviceAddr, padIndex...))
 	return crypto.Sha3Hash(append(padClientAddr, baseKey...))
 }
+
+// AccessAllowlistExpiryKey returns storage key of the expiry block of the access
+// allowlist entry of givin address
+func AccessAllowlistExpiryKey(deviceAddr Address, clientAddr Address) []byte {
+	index := util.IntToBytes(AccessAllowlistExpiryIndex)
+	padIndex := util.PaddingBytesPrefix(index, 0, 32)
+	padDeviceAddr := util.PaddingBytesPrefix(deviceAddr[:], 0, 32)
+	padClientAddr := util.PaddingBytesPrefix(clientAddr[:], 0, 32)
+	baseKey := crypto.Sha3Hash(append(padDeviceAddr, padIndex...))
+	return crypto.Sha3Hash(append(padClientAddr, baseKey...))
+}
+
+// IsDeviceAllowed returns whether deviceAddr is in the device allowlist
+func (fleetContract *FleetContract) IsDeviceAllowed(ctx context.Context, deviceAddr []byte) (bool, error) {
+	device, err := toAddress(deviceAddr)
+	if err != nil {
+		return false, err
+	}
+	raw, err := fleetContract.readStorage(ctx, DeviceAllowlistKey(device))
+	if err != nil {
+		return false, err
+	}
+	return new(big.Int).SetBytes(raw).Sign() != 0, nil
+}
+
+// IsAccessAllowed returns whether accessAddr is in the access allowlist of deviceAddr,
+// from AccessAllowlistExpiryVersion entries with an expiry block are allowed until
+// that block, entries without an expiry don't expire
+func (fleetContract *FleetContract) IsAccessAllowed(ctx context.Context, deviceAddr []byte, accessAddr []byte) (bool, error) {
+	device, err := toAddress(deviceAddr)
+	if err != nil {
+		return false, err
+	}
+	client, err := toAddress(accessAddr)
+	if err != nil {
+		return false, err
+	}
+	raw, err := fleetContract.readStorage(ctx, AccessAllowlistKey(device, client))
+	if err != nil {
+		return false, err
+	}
+	if new(big.Int).SetBytes(raw).Sign() == 0 {
+		return false, nil
+	}
+	if fleetContract.Version < AccessAllowlistExpiryVersion {
+		return true, nil
+	}
+	raw, err = fleetContract.readStorage(ctx, AccessAllowlistExpiryKey(device, client))
+	if err != nil {
+		return false, err
+	}
+	expiryBlock := new(big.Int).SetBytes(raw)
+	if expiryBlock.Sign() == 0 {
+		return true, nil
+	}
+	if fleetContract.BlockNumber == nil {
+		return false, ErrNoBlockNumberReader
+	}
+	currentBlock, err := fleetContract.BlockNumber(ctx)
+	if err != nil {
+		return false, err
+	}
+	return expiryBlock.Cmp(new(big.Int).SetUint64(currentBlock)) >= 0, nil
+}
+
+// GetOperator returns the operator address of the fleet
+func (fleetContract *FleetContract) GetOperator(ctx context.Context) (operator [20]byte, err error) {
+	key := util.PaddingBytesPrefix(util.IntToBytes(OperatorIndex), 0, 32)
+	raw, err := fleetContract.readStorage(ctx, key)
+	if err != nil {
+		return
+	}
+	if len(raw) > 32 {
+		err = fmt.Errorf("storage slot should be at most 32 bytes, got %d", len(raw))
+		return
+	}
+	// addresses are right aligned in the 32 bytes slot
+	if len(raw) > 20 {
+		raw = raw[len(raw)-20:]
+	}
+	copy(operator[20-len(raw):], raw)
+	return
+}
+
+func (fleetContract *FleetContract) readStorage(ctx context.Context, key []byte) ([]byte, error) {
+	if fleetContract.Storage == nil {
+		return nil, ErrNoStorageReader
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return fleetContract.Storage(fleetContract.Address, key)
+}
+
+func toAddress(src []byte) (addr Address, err error) {
+	if len(src) != 20 {
+		err = ErrInvalidAddressLength
+		return
+	}
+	copy(addr[:], src)
+	return
+}
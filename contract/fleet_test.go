@@ -0,0 +1,136 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/diodechain/diode_client/util"
+)
+
+func newTestFleetContract(t *testing.T, fleet [20]byte, slots map[string][]byte) FleetContract {
+	storage := func(addr [20]byte, key []byte) ([]byte, error) {
+		if addr != fleet {
+			t.Fatalf("storage read from wrong contract %x", addr)
+		}
+		return slots[string(key)], nil
+	}
+	fleetContract, err := NewFleetContractAt(fleet, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fleetContract
+}
+
+func TestFleetContractStorageCalls(t *testing.T) {
+	ctx := context.Background()
+	fleet := Address{0xfe}
+	operator := Address{0x01, 0x02, 0x03}
+	device := Address{0xaa}
+	other := Address{0xbb}
+	client := Address{0xcc}
+	slots := map[string][]byte{
+		string(DeviceAllowlistKey(device)):                                     util.PaddingBytesPrefix([]byte{1}, 0, 32),
+		string(DeviceAllowlistKey(other)):                                      make([]byte, 32),
+		string(AccessAllowlistKey(device, client)):                             util.PaddingBytesPrefix([]byte{1}, 0, 32),
+		string(util.PaddingBytesPrefix(util.IntToBytes(OperatorIndex), 0, 32)): util.PaddingBytesPrefix(operator[:], 0, 32),
+	}
+	fleetContract := newTestFleetContract(t, fleet, slots)
+
+	if allowed, err := fleetContract.IsDeviceAllowed(ctx, device[:]); err != nil || !allowed {
+		t.Fatalf("device should be allowed: %v %v", allowed, err)
+	}
+	if allowed, err := fleetContract.IsDeviceAllowed(ctx, other[:]); err != nil || allowed {
+		t.Fatalf("zero slot should not be allowed: %v %v", allowed, err)
+	}
+	if allowed, err := fleetContract.IsDeviceAllowed(ctx, client[:]); err != nil || allowed {
+		t.Fatalf("missing slot should not be allowed: %v %v", allowed, err)
+	}
+	if allowed, err := fleetContract.IsAccessAllowed(ctx, device[:], client[:]); err != nil || !allowed {
+		t.Fatalf("access should be allowed: %v %v", allowed, err)
+	}
+	if allowed, err := fleetContract.IsAccessAllowed(ctx, other[:], client[:]); err != nil || allowed {
+		t.Fatalf("access should not be allowed: %v %v", allowed, err)
+	}
+	got, err := fleetContract.GetOperator(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != operator {
+		t.Fatalf("wrong operator %x", got)
+	}
+}
+
+func TestFleetContractStorageErrors(t *testing.T) {
+	fleetContract := newTestFleetContract(t, Address{}, nil)
+	if _, err := fleetContract.IsDeviceAllowed(context.Background(), []byte{1, 2, 3}); err != ErrInvalidAddressLength {
+		t.Fatalf("expected ErrInvalidAddressLength, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fleetContract.GetOperator(ctx); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	unbound, err := NewFleetContract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unbound.GetOperator(context.Background()); err != ErrNoStorageReader {
+		t.Fatalf("expected ErrNoStorageReader, got %v", err)
+	}
+}
+
+func TestFleetContractAccessExpiry(t *testing.T) {
+	ctx := context.Background()
+	fleet := Address{0xfe}
+	device := Address{0xaa}
+	client := Address{0xcc}
+	flag := util.PaddingBytesPrefix([]byte{1}, 0, 32)
+	tests := []struct {
+		name    string
+		allowed bool
+		expiry  uint64
+		expect  bool
+	}{
+		{"not allowlisted", false, 0, false},
+		{"not allowlisted with expiry", false, 200, false},
+		{"allowlisted without expiry", true, 0, true},
+		{"allowlisted until current block", true, 100, true},
+		{"allowlisted not expired", true, 200, true},
+		{"allowlisted expired", true, 99, false},
+	}
+	for _, test := range tests {
+		slots := map[string][]byte{}
+		if test.allowed {
+			slots[string(AccessAllowlistKey(device, client))] = flag
+		}
+		if test.expiry > 0 {
+			slots[string(AccessAllowlistExpiryKey(device, client))] = util.PaddingBytesPrefix(util.IntToBytes(int(test.expiry)), 0, 32)
+		}
+		fleetContract := newTestFleetContract(t, fleet, slots)
+		fleetContract.Version = AccessAllowlistExpiryVersion
+		fleetContract.BlockNumber = func(ctx context.Context) (uint64, error) {
+			return 100, nil
+		}
+		if allowed, err := fleetContract.IsAccessAllowed(ctx, device[:], client[:]); err != nil || allowed != test.expect {
+			t.Errorf("%s: expected %v but got %v %v", test.name, test.expect, allowed, err)
+		}
+		// contracts before AccessAllowlistExpiryVersion have no expiry slot
+		fleetContract.Version = FleetContractVersion
+		if allowed, err := fleetContract.IsAccessAllowed(ctx, device[:], client[:]); err != nil || allowed != test.allowed {
+			t.Errorf("%s: expected %v without expiry but got %v %v", test.name, test.allowed, allowed, err)
+		}
+	}
+
+	slots := map[string][]byte{
+		string(AccessAllowlistKey(device, client)):       flag,
+		string(AccessAllowlistExpiryKey(device, client)): flag,
+	}
+	fleetContract := newTestFleetContract(t, fleet, slots)
+	fleetContract.Version = AccessAllowlistExpiryVersion
+	if _, err := fleetContract.IsAccessAllowed(ctx, device[:], client[:]); err != ErrNoBlockNumberReader {
+		t.Fatalf("expected ErrNoBlockNumberReader, got %v", err)
+	}
+}
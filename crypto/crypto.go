@@ -3,11 +3,14 @@ package crypto
 import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/asn1"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/big"
 
@@ -93,6 +96,29 @@ func ToECDSA(d []byte) (*ecdsa.PrivateKey, error) {
 	return toECDSA(d, true)
 }
 
+// GenerateKey returns a new random secp256k1 private key
+func GenerateKey() (*ecdsa.PrivateKey, error) {
+	d := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(rand.Reader, d); err != nil {
+			return nil, err
+		}
+		// retry in the unlikely case that d is zero or >= N
+		if priv, err := toECDSA(d, true); err == nil {
+			return priv, nil
+		}
+	}
+}
+
+// MustGenerateKey returns a new random secp256k1 private key and panics on error
+func MustGenerateKey() *ecdsa.PrivateKey {
+	priv, err := GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	return priv
+}
+
 // ToECDSAUnsafe blindly converts a binary blob to a private key. It should almost
 // never be used unless you are sure the input is valid and want to avoid hitting
 // errors due to bad origin encoding (0 prefixes cut off).
@@ -139,8 +165,15 @@ func toECDSA(d []byte, strict bool) (*ecdsa.PrivateKey, error) {
 	return priv, nil
 }
 
-// UnmarshalPubkey converts bytes to a secp256k1 public key.
+// UnmarshalPubkey converts bytes to a secp256k1 public key, both the 65 bytes
+// uncompressed and the 33 bytes compressed (0x02 or 0x03 prefix) format are accepted.
 func UnmarshalPubkey(pub []byte) (*ecdsa.PublicKey, error) {
+	if len(pub) == 33 && (pub[0] == 0x02 || pub[0] == 0x03) {
+		pub = secp256k1.DecompressPubkeyBytes(pub)
+		if pub == nil {
+			return nil, errInvalidPubkey
+		}
+	}
 	x, y := elliptic.Unmarshal(S256(), pub)
 	if x == nil {
 		return nil, errInvalidPubkey
@@ -163,6 +196,29 @@ func Sha256(data []byte) []byte {
 	return sha256.Sum(nil)
 }
 
+// Sha256d returns the double sha256 of the data: sha256(sha256(data))
+func Sha256d(data []byte) []byte {
+	hash := sha256.New()
+	hash.Write(data)
+	first := hash.Sum(nil)
+	hash.Reset()
+	hash.Write(first)
+	return hash.Sum(first[:0])
+}
+
+// HMACSHA256 returns the hmac sha256 of the message with the given key
+func HMACSHA256(key []byte, message []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// VerifyHMACSHA256 returns true if mac is the valid hmac sha256 of the message,
+// the comparison is done in constant time
+func VerifyHMACSHA256(key []byte, message []byte, mac []byte) bool {
+	return hmac.Equal(mac, HMACSHA256(key, message))
+}
+
 // Sha3Hash the data
 func Sha3Hash(data []byte) []byte {
 	hash := sha3.NewLegacyKeccak256()
@@ -170,18 +226,40 @@ func Sha3Hash(data []byte) []byte {
 	return hash.Sum(nil)
 }
 
-// PubkeyFromCompressed returns public key generate from compressed public key
-func PubkeyFromCompressed(pubkey []byte) (dpubkey []byte) {
+// PubkeyFromCompressed returns the 64 bytes public key without the 0x04 prefix
+// of the 33 bytes compressed or 65 bytes uncompressed public key
+func PubkeyFromCompressed(pubkey []byte) ([]byte, error) {
 	if len(pubkey) == 33 {
-		dpubkey = secp256k1.DecompressPubkeyBytes(pubkey)
-		return
+		pubkey = secp256k1.DecompressPubkeyBytes(pubkey)
 	}
-	// TODO: fix this
-	if len(pubkey) != 65 {
-		log.Panicf("This is not a pubkey %v", pubkey)
+	if len(pubkey) != 65 || pubkey[0] != 4 {
+		return nil, errInvalidPubkey
 	}
-	dpubkey = pubkey[1:]
-	return
+	return pubkey[1:], nil
+}
+
+// RecoverPubkey returns the 65 bytes uncompressed public key of the signer of hash
+// sig[0] = recovery param
+func RecoverPubkey(hash []byte, sig []byte) ([]byte, error) {
+	return secp256k1.RecoverPubkey(hash, sig)
+}
+
+// RecoverAddress returns the 20 bytes address of the signer of hash
+func RecoverAddress(hash []byte, sig []byte) ([]byte, error) {
+	pubkey, err := RecoverPubkey(hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	return PubkeyToAddress(pubkey)
+}
+
+// PubkeyToAddress returns the 20 bytes address of the public key
+func PubkeyToAddress(pubkey []byte) ([]byte, error) {
+	dpubkey, err := PubkeyFromCompressed(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return Sha3Hash(dpubkey)[12:], nil
 }
 
 // HexToECDSA returns ecdsa private key for given hex string
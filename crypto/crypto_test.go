@@ -0,0 +1,131 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/diodechain/diode_client/crypto/secp256k1"
+)
+
+func TestSha256d(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "5df6e0e2761359d30a8275058e299fcc0381534545f55cf43e41983f5d4c9456"},
+		{"abc", "4f8b42c22dd3729b519ba6f68d2da7cc5b2d606d05daed5ad5128cc03e6c6358"},
+		{"hello", "9595c9df90075148eb06860365df33584b75bff782a510c6cd4883a419833d50"},
+	}
+	for _, v := range tests {
+		hash := hex.EncodeToString(Sha256d([]byte(v.input)))
+		if hash != v.expected {
+			t.Errorf("Sha256d(%q) = %s, expected %s", v.input, hash, v.expected)
+		}
+	}
+}
+
+func TestHMACSHA256(t *testing.T) {
+	keyBlocklen := make([]byte, 64)
+	for i := range keyBlocklen {
+		keyBlocklen[i] = byte(i)
+	}
+	// NIST FIPS 198 HMAC-SHA256 examples
+	tests := []struct {
+		key      []byte
+		message  string
+		expected string
+	}{
+		{keyBlocklen, "Sample message for keylen=blocklen", "8bb9a1db9806f20df7f77b82138c7914d174d59e13dc4d0169c9057b133e1d62"},
+		{keyBlocklen[:32], "Sample message for keylen<blocklen", "a28cf43130ee696a98f14a37678b56bcfcbdd9e5cf69717fecf5480f0ebdf790"},
+	}
+	for _, v := range tests {
+		mac := HMACSHA256(v.key, []byte(v.message))
+		if hex.EncodeToString(mac) != v.expected {
+			t.Errorf("HMACSHA256(%q) = %x, expected %s", v.message, mac, v.expected)
+		}
+		if !VerifyHMACSHA256(v.key, []byte(v.message), mac) {
+			t.Errorf("VerifyHMACSHA256(%q) should be true", v.message)
+		}
+		mac[0] ^= 1
+		if VerifyHMACSHA256(v.key, []byte(v.message), mac) {
+			t.Errorf("VerifyHMACSHA256(%q) should be false for a modified mac", v.message)
+		}
+	}
+}
+
+func TestRecoverAddress(t *testing.T) {
+	key, err := HexToECDSA("4646464646464646464646464646464646464646464646464646464646464646")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Sha3Hash([]byte("diode"))
+	sig, err := secp256k1.Sign(hash, key.D.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := RecoverPubkey(hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPubkey := MarshalPubkey(&key.PublicKey)
+	if !bytes.Equal(pubkey, expectedPubkey) {
+		t.Errorf("recovered pubkey %x, expected %x", pubkey, expectedPubkey)
+	}
+	addr, err := RecoverAddress(hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// address of the EIP155 example key
+	if hex.EncodeToString(addr) != "9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f" {
+		t.Errorf("recovered address %x doesn't match", addr)
+	}
+	if pubkeyAddr, err := PubkeyToAddress(expectedPubkey); err != nil || !bytes.Equal(addr, pubkeyAddr) {
+		t.Errorf("recovered address %x doesn't match pubkey address", addr)
+	}
+}
+
+func TestUnmarshalCompressedPubkey(t *testing.T) {
+	key, err := HexToECDSA("4646464646464646464646464646464646464646464646464646464646464646")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncompressed := MarshalPubkey(&key.PublicKey)
+	compressed := secp256k1.CompressPubkeyBytes(uncompressed)
+	pub, err := UnmarshalPubkey(uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpub, err := UnmarshalPubkey(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.X.Cmp(cpub.X) != 0 || pub.Y.Cmp(cpub.Y) != 0 {
+		t.Errorf("compressed pubkey (%x, %x) doesn't match (%x, %x)", cpub.X, cpub.Y, pub.X, pub.Y)
+	}
+	compressed[0] = 0x04
+	if _, err = UnmarshalPubkey(compressed); err == nil {
+		t.Errorf("33 bytes pubkey with wrong prefix should be rejected")
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		key := MustGenerateKey()
+		d := key.D.String()
+		if seen[d] {
+			t.Fatalf("generated duplicate key %s", d)
+		}
+		seen[d] = true
+		if !S256().IsOnCurve(key.X, key.Y) {
+			t.Fatalf("public key of %s is not on the curve", d)
+		}
+		if _, err := toECDSA(key.D.FillBytes(make([]byte, 32)), true); err != nil {
+			t.Fatalf("generated invalid key: %v", err)
+		}
+	}
+}
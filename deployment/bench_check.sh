@@ -0,0 +1,48 @@
+#!/bin/bash
+# Runs the edge benchmarks on the base revision and on the working tree and
+# fails if benchstat reports a significant regression of more than
+# BENCH_THRESHOLD percent in any benchmark unit (sec/op, B/op, allocs/op).
+#
+# usage: deployment/bench_check.sh <base revision>
+#
+# BENCHSTAT is the benchstat binary, make bench_check installs the version of
+# BENCHSTAT_VERSION because the csv format differs between benchstat versions.
+set -e
+
+BASE=${1:-origin/master}
+BENCHSTAT=${BENCHSTAT:-benchstat}
+BENCH_COUNT=${BENCH_COUNT:-10}
+BENCH_THRESHOLD=${BENCH_THRESHOLD:-20}
+BENCH="go test -run xxx -bench . -benchmem -count ${BENCH_COUNT} ./edge"
+
+TMP=$(mktemp -d)
+cleanup() {
+  git worktree remove --force "${TMP}/base" > /dev/null 2>&1 || true
+  rm -rf "${TMP}"
+}
+trap cleanup EXIT
+
+git worktree add --detach "${TMP}/base" "${BASE}" > /dev/null
+(cd "${TMP}/base" && ${BENCH}) > "${TMP}/base.txt"
+${BENCH} > "${TMP}/head.txt"
+
+${BENCHSTAT} "${TMP}/base.txt" "${TMP}/head.txt"
+${BENCHSTAT} -format csv "${TMP}/base.txt" "${TMP}/head.txt" > "${TMP}/bench.csv"
+
+# the "vs base" column holds the change of each benchmark, e.g. +25.00%,
+# insignificant changes are reported as ~
+awk -F, -v threshold="${BENCH_THRESHOLD}" '
+  {
+    for (i = 1; i <= NF; i++) {
+      if ($i == "vs base") { col = i; unit = $2; next }
+    }
+  }
+  col && $1 != "geomean" && $col ~ /^\+[0-9.]+%$/ {
+    change = substr($col, 2, length($col) - 2) + 0
+    if (change > threshold) {
+      printf "%s regressed by %s %s\n", $1, $col, unit
+      failed = 1
+    }
+  }
+  END { exit failed }
+' "${TMP}/bench.csv"
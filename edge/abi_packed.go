@@ -0,0 +1,135 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/diodechain/diode_client/util"
+)
+
+var (
+	ErrUnsupportedABIType = fmt.Errorf("unsupported abi type")
+	ErrPackedSlotOverflow = fmt.Errorf("abi types don't fit into a 32 bytes storage slot")
+	ErrNoStorageKey       = fmt.Errorf("account value has no storage key")
+)
+
+// DecodeABI decodes the storage value of the requested key as the solidity
+// types packed into one storage slot, see DecodePackedSlot
+func (acv *AccountValue) DecodeABI(types []string) ([]interface{}, error) {
+	if len(acv.StorageKey) == 0 {
+		return nil, ErrNoStorageKey
+	}
+	slot, err := acv.accountTree.Get(acv.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	return DecodePackedSlot(slot, types)
+}
+
+// DecodePackedSlot decodes the 32 bytes storage slot as the given solidity
+// types, solidity packs the first type into the lowest order bytes of the slot.
+// The values are decoded like go-ethereum does: address as Address, bool as bool,
+// (u)int8 to (u)int64 as the go integer type, other (u)intN as *big.Int and
+// bytesN as []byte of N bytes
+func DecodePackedSlot(slot []byte, types []string) ([]interface{}, error) {
+	if len(slot) > 32 {
+		return nil, fmt.Errorf("storage slot must be 32 bytes but is %d bytes", len(slot))
+	}
+	slot = util.PaddingBytesPrefix(slot, 0, 32)
+	values := make([]interface{}, len(types))
+	end := len(slot)
+	for i, typ := range types {
+		size, err := packedTypeSize(typ)
+		if err != nil {
+			return nil, err
+		}
+		if size > end {
+			return nil, fmt.Errorf("%w: %s", ErrPackedSlotOverflow, strings.Join(types, ","))
+		}
+		if values[i], err = decodePackedValue(typ, slot[end-size:end]); err != nil {
+			return nil, err
+		}
+		end -= size
+	}
+	return values, nil
+}
+
+// packedTypeSize returns the number of bytes of the solidity type in a packed slot
+func packedTypeSize(typ string) (int, error) {
+	switch {
+	case typ == "address":
+		return 20, nil
+	case typ == "bool":
+		return 1, nil
+	case typ == "uint" || typ == "int":
+		return 32, nil
+	case strings.HasPrefix(typ, "uint"):
+		return packedIntSize(typ, typ[4:])
+	case strings.HasPrefix(typ, "int"):
+		return packedIntSize(typ, typ[3:])
+	case strings.HasPrefix(typ, "bytes"):
+		size, err := strconv.Atoi(typ[5:])
+		if err != nil || size < 1 || size > 32 {
+			return 0, fmt.Errorf("%w: %s", ErrUnsupportedABIType, typ)
+		}
+		return size, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnsupportedABIType, typ)
+}
+
+func packedIntSize(typ string, bits string) (int, error) {
+	n, err := strconv.Atoi(bits)
+	if err != nil || n < 8 || n > 256 || n%8 != 0 {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedABIType, typ)
+	}
+	return n / 8, nil
+}
+
+func decodePackedValue(typ string, raw []byte) (interface{}, error) {
+	switch {
+	case typ == "address":
+		var addr Address
+		copy(addr[:], raw)
+		return addr, nil
+	case typ == "bool":
+		if raw[0] > 1 {
+			return nil, fmt.Errorf("improperly encoded bool value %d", raw[0])
+		}
+		return raw[0] == 1, nil
+	case strings.HasPrefix(typ, "bytes"):
+		return cloneBytes(raw), nil
+	case strings.HasPrefix(typ, "uint"):
+		value := new(big.Int).SetBytes(raw)
+		switch len(raw) {
+		case 1:
+			return uint8(value.Uint64()), nil
+		case 2:
+			return uint16(value.Uint64()), nil
+		case 4:
+			return uint32(value.Uint64()), nil
+		case 8:
+			return value.Uint64(), nil
+		}
+		return value, nil
+	}
+	value := new(big.Int).SetBytes(raw)
+	if raw[0]&0x80 != 0 {
+		value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(raw)*8)))
+	}
+	switch len(raw) {
+	case 1:
+		return int8(value.Int64()), nil
+	case 2:
+		return int16(value.Int64()), nil
+	case 4:
+		return int32(value.Int64()), nil
+	case 8:
+		return value.Int64(), nil
+	}
+	return value, nil
+}
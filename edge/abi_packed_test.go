@@ -0,0 +1,63 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/diodechain/diode_client/util"
+)
+
+func TestAccountValueDecodeABI(t *testing.T) {
+	// slot of struct { address owner; uint96 balance; } with the balance
+	// 1000000000000000000 packed above the owner
+	slot, err := util.DecodeString("0x000000000de0b6b3a76400005a0b54d5dc17e0aadc383d2db43b0a0d3e029c4c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := util.PaddingBytesPrefix([]byte{1}, 0, 32)
+	acv := &AccountValue{
+		accountTree: MerkleTree{Leaves: []MerkleTreeLeave{{Key: key, Value: slot}}},
+		StorageKey:  key,
+	}
+	values, err := acv.DecodeABI([]string{"address", "uint96"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, err := util.DecodeAddress("0x5a0b54d5dc17e0aadc383d2db43b0a0d3e029c4c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr, ok := values[0].(Address); !ok || addr != owner {
+		t.Fatalf("DecodeABI() address = %v", values[0])
+	}
+	balance, _ := new(big.Int).SetString("1000000000000000000", 10)
+	if value, ok := values[1].(*big.Int); !ok || value.Cmp(balance) != 0 {
+		t.Fatalf("DecodeABI() uint96 = %v", values[1])
+	}
+	if _, err := (&AccountValue{accountTree: acv.accountTree}).DecodeABI([]string{"address"}); !errors.Is(err, ErrNoStorageKey) {
+		t.Fatalf("DecodeABI() without storage key should fail with ErrNoStorageKey but got %v", err)
+	}
+}
+
+func TestDecodePackedSlot(t *testing.T) {
+	// bool true, int8 -2, uint16 0x0102, bytes2 0xabcd from the lowest order byte
+	slot := []byte{0xab, 0xcd, 0x01, 0x02, 0xfe, 0x01}
+	values, err := DecodePackedSlot(slot, []string{"bool", "int8", "uint16", "bytes2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0] != true || values[1] != int8(-2) || values[2] != uint16(0x0102) || !bytes.Equal(values[3].([]byte), []byte{0xab, 0xcd}) {
+		t.Fatalf("DecodePackedSlot() = %v", values)
+	}
+	if _, err := DecodePackedSlot(slot, []string{"address", "uint128"}); !errors.Is(err, ErrPackedSlotOverflow) {
+		t.Fatalf("DecodePackedSlot() should fail with ErrPackedSlotOverflow but got %v", err)
+	}
+	if _, err := DecodePackedSlot(slot, []string{"string"}); !errors.Is(err, ErrUnsupportedABIType) {
+		t.Fatalf("DecodePackedSlot() should fail with ErrUnsupportedABIType but got %v", err)
+	}
+}
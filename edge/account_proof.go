@@ -0,0 +1,43 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"fmt"
+)
+
+var (
+	ErrAccountNotInStateTree = fmt.Errorf("account is not in the state tree")
+	ErrStateTreeNotInRoots   = fmt.Errorf("state tree root is not in the state roots")
+	ErrStateRootsNotInBlock  = fmt.Errorf("state roots don't match the block state root")
+	ErrStorageRootMismatch   = fmt.Errorf("account roots don't match the account storage root")
+	ErrAccountHashMismatch   = fmt.Errorf("account hash doesn't match the state tree leave")
+)
+
+// VerifyAccountInBlock walks the proof chain of the account up to the state root of the block:
+// account roots -> account storage root, account hash -> state tree leave -> state roots -> block state root
+func VerifyAccountInBlock(account *Account, accountAddr []byte, blockStateRoot [32]byte, stateRoots *StateRoots, accountRoots *AccountRoots) error {
+	if account == nil || stateRoots == nil || accountRoots == nil {
+		return fmt.Errorf("account, state roots and account roots are required")
+	}
+	if !bytes.Equal(accountRoots.StorageRoot(), account.StorageRoot) {
+		return ErrStorageRootMismatch
+	}
+	stateTree := account.StateTree()
+	leave, err := stateTree.Get(accountAddr)
+	if err != nil {
+		return ErrAccountNotInStateTree
+	}
+	if len(account.AccountHash) == 0 || !bytes.Equal(leave, account.AccountHash) {
+		return ErrAccountHashMismatch
+	}
+	if stateRoots.Find(stateTree.RootHash) != int(stateTree.Modulo) {
+		return ErrStateTreeNotInRoots
+	}
+	if !bytes.Equal(stateRoots.StateRoot(), blockStateRoot[:]) {
+		return ErrStateRootsNotInBlock
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/diodechain/diode_client/crypto"
+	"github.com/diodechain/diode_client/util"
+)
+
+func newTestRoots(seed string) [][]byte {
+	roots := make([][]byte, 16)
+	for i := range roots {
+		roots[i] = crypto.Sha256([]byte{seed[0], byte(i)})
+	}
+	return roots
+}
+
+func newTestAccountProof(t *testing.T) (*Account, []byte, [32]byte, *StateRoots, *AccountRoots) {
+	accountAddr := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	accountRoots := &AccountRoots{AccountRoots: newTestRoots("a")}
+	account := &Account{
+		Address:     accountAddr,
+		StorageRoot: accountRoots.StorageRoot(),
+		Nonce:       7,
+		Balance:     big.NewInt(1000),
+	}
+	// level 1: the state tree containing the account hash
+	account.AccountHash = crypto.Sha256([]byte("account"))
+	stateTree, err := NewMerkleTree([]interface{}{[]byte{}, []byte{3}, []interface{}{accountAddr, account.AccountHash}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.stateTree = stateTree
+	// level 2: the state roots containing the state tree root
+	roots := newTestRoots("s")
+	roots[stateTree.Modulo] = stateTree.RootHash
+	stateRoots := &StateRoots{StateRoots: roots}
+	// level 3: the block state root
+	var blockStateRoot [32]byte
+	copy(blockStateRoot[:], stateRoots.StateRoot())
+	return account, accountAddr, blockStateRoot, stateRoots, accountRoots
+}
+
+func TestVerifyAccountInBlock(t *testing.T) {
+	account, accountAddr, blockStateRoot, stateRoots, accountRoots := newTestAccountProof(t)
+	if err := VerifyAccountInBlock(account, accountAddr, blockStateRoot, stateRoots, accountRoots); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyAccountInBlockCorrupted(t *testing.T) {
+	account, accountAddr, blockStateRoot, stateRoots, accountRoots := newTestAccountProof(t)
+	roots := append([][]byte{}, stateRoots.StateRoots...)
+	roots[0] = crypto.Sha256([]byte("corrupted"))
+	corrupted := &StateRoots{StateRoots: roots}
+	if err := VerifyAccountInBlock(account, accountAddr, blockStateRoot, corrupted, accountRoots); err != ErrStateRootsNotInBlock {
+		t.Errorf("expected ErrStateRootsNotInBlock but got %v", err)
+	}
+
+	roots = append([][]byte{}, stateRoots.StateRoots...)
+	roots[account.StateTree().Modulo] = crypto.Sha256([]byte("corrupted"))
+	corrupted = &StateRoots{StateRoots: roots}
+	if err := VerifyAccountInBlock(account, accountAddr, blockStateRoot, corrupted, accountRoots); err != ErrStateTreeNotInRoots {
+		t.Errorf("expected ErrStateTreeNotInRoots but got %v", err)
+	}
+
+	if err := VerifyAccountInBlock(account, []byte{0}, blockStateRoot, stateRoots, accountRoots); err != ErrAccountNotInStateTree {
+		t.Errorf("expected ErrAccountNotInStateTree but got %v", err)
+	}
+}
+
+func TestVerifyAccountInBlockTampered(t *testing.T) {
+	account, accountAddr, blockStateRoot, stateRoots, accountRoots := newTestAccountProof(t)
+	if err := VerifyAccountInBlock(account, accountAddr, blockStateRoot, stateRoots, nil); err == nil {
+		t.Errorf("expected error without account roots")
+	}
+
+	tampered := *account
+	tampered.AccountHash = crypto.Sha256([]byte("tampered"))
+	if err := VerifyAccountInBlock(&tampered, accountAddr, blockStateRoot, stateRoots, accountRoots); err != ErrAccountHashMismatch {
+		t.Errorf("expected ErrAccountHashMismatch for tampered account hash but got %v", err)
+	}
+
+	tampered = *account
+	tampered.AccountHash = nil
+	if err := VerifyAccountInBlock(&tampered, accountAddr, blockStateRoot, stateRoots, accountRoots); err != ErrAccountHashMismatch {
+		t.Errorf("expected ErrAccountHashMismatch without account hash but got %v", err)
+	}
+
+	tampered = *account
+	tampered.StorageRoot = (&AccountRoots{AccountRoots: newTestRoots("t")}).StorageRoot()
+	if err := VerifyAccountInBlock(&tampered, accountAddr, blockStateRoot, stateRoots, accountRoots); err != ErrStorageRootMismatch {
+		t.Errorf("expected ErrStorageRootMismatch but got %v", err)
+	}
+}
+
+func TestAccountCodeHash(t *testing.T) {
+	emptyCodeHash := "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
+	account := &Account{}
+	codeHash := account.CodeHash()
+	if util.EncodeToString(codeHash[:]) != emptyCodeHash {
+		t.Fatalf("empty code hash is %s", util.EncodeToString(codeHash[:]))
+	}
+
+	account.Code = []byte{0x60, 0x00}
+	codeHash = account.CodeHash()
+	for _, tt := range []struct {
+		leaveHash []byte
+		err       error
+	}{
+		{codeHash[:], nil},
+		{crypto.Sha3Hash(nil), ErrCodeHashMismatch},
+	} {
+		stateTree, err := NewMerkleTree([]interface{}{[]byte{}, []byte{3}, []interface{}{codeHashKey, tt.leaveHash}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		account.stateTree = stateTree
+		if err = account.verifyCodeHash(); err != tt.err {
+			t.Fatalf("verifyCodeHash() = %v, expected %v", err, tt.err)
+		}
+	}
+
+	// a relay must not skip the check by leaving out the code hash leave
+	stateTree, err := NewMerkleTree([]interface{}{[]byte{}, []byte{3}, []interface{}{[]byte("other"), codeHash[:]}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.stateTree = stateTree
+	if err = account.verifyCodeHash(); err != ErrCodeHashMissing {
+		t.Fatalf("verifyCodeHash() = %v, expected %v", err, ErrCodeHashMissing)
+	}
+}
+
+func TestParseAccountResponseInBlock(t *testing.T) {
+	accountAddr := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	accountRoots := &AccountRoots{AccountRoots: newTestRoots("a")}
+	accountHash := crypto.Sha256([]byte("account"))
+	code := []byte{0x60, 0x00}
+	rawTree := []interface{}{[]byte{}, []byte{3}, []interface{}{accountAddr, accountHash}, []interface{}{codeHashKey, crypto.Sha3Hash(code)}}
+	items := []Item{
+		{Key: "storageRoot", Value: accountRoots.StorageRoot()},
+		{Key: "nonce", Value: []byte{7}},
+		{Key: "code", Value: code},
+		{Key: "balance", Value: []byte{100}},
+		{Key: "accountHash", Value: accountHash},
+	}
+	res, err := parseAccountResponse(encodeTestResponse(t, "response", items, rawTree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := res.(*Account)
+	if !bytes.Equal(account.AccountHash, accountHash) {
+		t.Fatalf("expected account hash %x but got %x", accountHash, account.AccountHash)
+	}
+	roots := newTestRoots("s")
+	roots[account.StateTree().Modulo] = account.StateRoot()
+	stateRoots := &StateRoots{StateRoots: roots}
+	var blockStateRoot [32]byte
+	copy(blockStateRoot[:], stateRoots.StateRoot())
+	if err = VerifyAccountInBlock(account, accountAddr, blockStateRoot, stateRoots, accountRoots); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,117 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// AccountChange is a change of the balance or nonce of a watched account
+type AccountChange struct {
+	OldBalance  *big.Int
+	NewBalance  *big.Int
+	OldNonce    int64
+	NewNonce    int64
+	BlockNumber uint64
+}
+
+// WatchAccount polls the account at the block peak every interval and emits
+// the changes of its balance or nonce, send should deliver the encoded request
+// and return the response. The channel is closed when ctx is done, failed
+// polls are retried on the next interval.
+func WatchAccount(ctx context.Context, addr []byte, interval time.Duration, send func([]byte) ([]byte, error)) (<-chan AccountChange, error) {
+	if len(addr) != 20 {
+		return nil, fmt.Errorf("account address must be 20 bytes but is %d", len(addr))
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("watch interval must be positive but is %s", interval)
+	}
+	if send == nil {
+		return nil, fmt.Errorf("watch account needs a send function")
+	}
+	changes := make(chan AccountChange)
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var last *Account
+		var lastBlock, requestID uint64
+		for {
+			blockNumber, account, err := watchPoll(send, &requestID, lastBlock, addr)
+			if err == nil && account != nil {
+				if last != nil && (last.Nonce != account.Nonce || last.Balance.Cmp(account.Balance) != 0) {
+					change := AccountChange{
+						OldBalance:  last.Balance,
+						NewBalance:  account.Balance,
+						OldNonce:    last.Nonce,
+						NewNonce:    account.Nonce,
+						BlockNumber: blockNumber,
+					}
+					select {
+					case changes <- change:
+					case <-ctx.Done():
+						return
+					}
+				}
+				last = account
+				lastBlock = blockNumber
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// watchPoll returns the account at the block peak, the account is nil if
+// the block peak is still lastBlock
+func watchPoll(send func([]byte) ([]byte, error), requestID *uint64, lastBlock uint64, addr []byte) (uint64, *Account, error) {
+	*requestID++
+	res, err := watchCall(send, *requestID, "getblockpeak")
+	if err != nil {
+		return 0, nil, err
+	}
+	blockNumber, ok := res.(uint64)
+	if !ok {
+		return 0, nil, fmt.Errorf("unexpected getblockpeak response %T", res)
+	}
+	if blockNumber == lastBlock {
+		return blockNumber, nil, nil
+	}
+	*requestID++
+	if res, err = watchCall(send, *requestID, "getaccount", blockNumber, addr); err != nil {
+		return 0, nil, err
+	}
+	account, ok := res.(*Account)
+	if !ok {
+		return 0, nil, fmt.Errorf("unexpected getaccount response %T", res)
+	}
+	return blockNumber, account, nil
+}
+
+// watchCall sends the request and parses the response
+func watchCall(send func([]byte) ([]byte, error), requestID uint64, method string, args ...interface{}) (interface{}, error) {
+	buf := &bytes.Buffer{}
+	parse, err := NewMessage(buf, requestID, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := send(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	msg := Message{Len: len(res), Buffer: res}
+	if msg.IsError() {
+		rpcErr, _ := parseError(res)
+		return nil, rpcErr
+	}
+	return parse(res)
+}
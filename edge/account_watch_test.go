@@ -0,0 +1,79 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/diodechain/diode_client/crypto"
+	"github.com/diodechain/diode_client/rlp"
+)
+
+func encodeTestAccountResponse(t *testing.T, requestID uint64, balance byte) []byte {
+	items := []Item{
+		{Key: "storageRoot", Value: make([]byte, 32)},
+		{Key: "nonce", Value: []byte{1}},
+		{Key: "code", Value: make([]byte, 32)},
+		{Key: "balance", Value: []byte{balance}},
+	}
+	rawTree := []interface{}{[]byte{}, []byte{0}, []interface{}{make([]byte, 32), make([]byte, 32)}, []interface{}{codeHashKey, crypto.Sha3Hash(make([]byte, 32))}}
+	buffer, err := rlp.EncodeToBytes([]interface{}{requestID, []interface{}{"response", items, rawTree}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buffer
+}
+
+func TestWatchAccount(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var blockPeak uint64 = 999
+	send := func(req []byte) ([]byte, error) {
+		var request struct {
+			RequestID uint64
+			Payload   []rlp.RawValue
+		}
+		if err := rlp.DecodeBytes(req, &request); err != nil {
+			return nil, err
+		}
+		var method string
+		rlp.DecodeBytes(request.Payload[0], &method)
+		if method == "getblockpeak" {
+			return rlp.EncodeToBytes([]interface{}{request.RequestID, []interface{}{"response", atomic.AddUint64(&blockPeak, 1)}})
+		}
+		var blockNumber uint64
+		rlp.DecodeBytes(request.Payload[1], &blockNumber)
+		// the balance changes at block 1001
+		if blockNumber < 1001 {
+			return encodeTestAccountResponse(t, request.RequestID, 100), nil
+		}
+		return encodeTestAccountResponse(t, request.RequestID, 200), nil
+	}
+	changes, err := WatchAccount(ctx, make([]byte, 20), time.Millisecond, send)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case change := <-changes:
+		if change.BlockNumber != 1001 || change.OldBalance.Int64() != 100 || change.NewBalance.Int64() != 200 || change.OldNonce != 1 || change.NewNonce != 1 {
+			t.Fatalf("unexpected change %+v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no account change")
+	}
+	for atomic.LoadUint64(&blockPeak) < 1010 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	for change := range changes {
+		t.Fatalf("unexpected second change %+v", change)
+	}
+
+	if _, err = WatchAccount(ctx, make([]byte, 19), time.Millisecond, send); err == nil {
+		t.Fatalf("expected error for short address")
+	}
+}
@@ -0,0 +1,83 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultBlockquickWindowSize is the initial window size of the adaptive blockquick sync
+	DefaultBlockquickWindowSize uint64 = 10
+	// DefaultMaxBlockquickWindowSize is the default maximum window size of the adaptive blockquick sync
+	DefaultMaxBlockquickWindowSize uint64 = 100
+	// BlockquickFastLatency is the latency below which the window size is doubled
+	BlockquickFastLatency = 200 * time.Millisecond
+	// BlockquickSlowLatency is the latency above which the window size is halved
+	BlockquickSlowLatency = 1 * time.Second
+)
+
+// AdaptiveBlockquickSync adjusts the getblockquick2 window size based on the response latency
+type AdaptiveBlockquickSync struct {
+	windowSize    uint64
+	minWindowSize uint64
+	maxWindowSize uint64
+}
+
+// NewAdaptiveBlockquickSync returns an adaptive blockquick sync starting with the default window size
+func NewAdaptiveBlockquickSync(maxWindowSize uint64) *AdaptiveBlockquickSync {
+	if maxWindowSize < DefaultBlockquickWindowSize {
+		maxWindowSize = DefaultBlockquickWindowSize
+	}
+	return &AdaptiveBlockquickSync{
+		windowSize:    DefaultBlockquickWindowSize,
+		minWindowSize: 1,
+		maxWindowSize: maxWindowSize,
+	}
+}
+
+// WindowSize returns the current window size
+func (abs *AdaptiveBlockquickSync) WindowSize() uint64 {
+	return abs.windowSize
+}
+
+// Observe adjusts the window size to the latency of a response
+func (abs *AdaptiveBlockquickSync) Observe(latency time.Duration) {
+	if latency < BlockquickFastLatency {
+		abs.windowSize *= 2
+		if abs.windowSize > abs.maxWindowSize {
+			abs.windowSize = abs.maxWindowSize
+		}
+	} else if latency > BlockquickSlowLatency {
+		abs.windowSize /= 2
+		if abs.windowSize < abs.minWindowSize {
+			abs.windowSize = abs.minWindowSize
+		}
+	}
+}
+
+// Fetch returns count block numbers after lastValid, fetch is called with the adapted
+// window size until all block numbers were fetched
+func (abs *AdaptiveBlockquickSync) Fetch(lastValid uint64, count uint64, fetch func(lastValid uint64, windowSize uint64) ([]uint64, error)) ([]uint64, error) {
+	blockNumbers := make([]uint64, 0, count)
+	for uint64(len(blockNumbers)) < count {
+		windowSize := abs.windowSize
+		if rest := count - uint64(len(blockNumbers)); rest < windowSize {
+			windowSize = rest
+		}
+		start := time.Now()
+		sequence, err := fetch(lastValid, windowSize)
+		if err != nil {
+			return nil, err
+		}
+		abs.Observe(time.Since(start))
+		if len(sequence) == 0 {
+			return nil, fmt.Errorf("couldn't fetch block numbers after %d", lastValid)
+		}
+		blockNumbers = append(blockNumbers, sequence...)
+		lastValid = sequence[len(sequence)-1]
+	}
+	return blockNumbers, nil
+}
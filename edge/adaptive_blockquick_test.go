@@ -0,0 +1,48 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveBlockquickSyncFast(t *testing.T) {
+	sync := NewAdaptiveBlockquickSync(100)
+	rounds := 0
+	fetch := func(lastValid uint64, windowSize uint64) ([]uint64, error) {
+		rounds++
+		sequence := make([]uint64, windowSize)
+		for i := range sequence {
+			sequence[i] = lastValid + uint64(i) + 1
+		}
+		return sequence, nil
+	}
+	// 10 + 20 + 40 + 80 + 100
+	blockNumbers, err := sync.Fetch(1000, 250, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rounds != 5 {
+		t.Errorf("expected 5 rounds but got %d", rounds)
+	}
+	if sync.WindowSize() != 100 {
+		t.Errorf("expected window size 100 but got %d", sync.WindowSize())
+	}
+	if len(blockNumbers) != 250 || blockNumbers[0] != 1001 || blockNumbers[249] != 1250 {
+		t.Errorf("wrong block numbers fetched %d", len(blockNumbers))
+	}
+}
+
+func TestAdaptiveBlockquickSyncSlow(t *testing.T) {
+	sync := NewAdaptiveBlockquickSync(100)
+	sync.Observe(2 * time.Second)
+	if sync.WindowSize() != 5 {
+		t.Errorf("expected window size 5 but got %d", sync.WindowSize())
+	}
+	sync.Observe(500 * time.Millisecond)
+	if sync.WindowSize() != 5 {
+		t.Errorf("expected window size 5 but got %d", sync.WindowSize())
+	}
+}
@@ -0,0 +1,138 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/diodechain/diode_client/crypto"
+	"github.com/diodechain/diode_client/crypto/secp256k1"
+	"github.com/diodechain/diode_client/util"
+)
+
+// Run with `make bench`, the baselines below were measured on an
+// x86_64 linux Xeon machine. `make bench_check` compares the benchmarks
+// with the base branch and fails on a regression of more than 20%, CI
+// runs it for pull requests.
+
+func newTestBlockHeaderItems(b testing.TB) []Item {
+	header := newTestBlockHeader(b)
+	hash := header.Hash()
+	var nonce big.Int
+	nonce.SetString(testHeaderNonce, 10)
+	items := []Item{
+		{Key: "transaction_hash", Value: testHeaderTxHash},
+		{Key: "state_hash", Value: testHeaderStateHash},
+		{Key: "block_hash", Value: hash[:]},
+		{Key: "previous_block", Value: testHeaderPrevBlock},
+		{Key: "nonce", Value: nonce.Bytes()},
+		{Key: "miner_signature", Value: testHeaderMinerSig},
+		{Key: "timestamp", Value: util.DecodeUintToBytes(testHeaderTimestamp)},
+		{Key: "number", Value: util.DecodeUintToBytes(testHeaderNumber)},
+	}
+	return items
+}
+
+func newTestBlockHeaderResponse(b *testing.B) []byte {
+	items := newTestBlockHeaderItems(b)
+	return encodeTestResponse(b, "response", items, secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey))
+}
+
+func newTestAccountResponse(b testing.TB) []byte {
+	rawTree := []interface{}{[]byte{}, []byte{0}}
+	for i := 0; i < 16; i++ {
+		key := util.PaddingBytesPrefix([]byte{byte(i)}, 0, 32)
+		value := util.PaddingBytesPrefix([]byte{byte(i + 1)}, 0, 32)
+		rawTree = append(rawTree, []interface{}{key, value})
+	}
+	code := make([]byte, 32)
+	rawTree = append(rawTree, []interface{}{codeHashKey, crypto.Sha3Hash(code)})
+	items := []Item{
+		{Key: "storageRoot", Value: make([]byte, 32)},
+		{Key: "nonce", Value: []byte{1}},
+		{Key: "code", Value: code},
+		{Key: "balance", Value: []byte{100}},
+	}
+	return encodeTestResponse(b, "response", items, rawTree)
+}
+
+// baseline: 1000 ns/op, 136 B/op, 6 allocs/op
+func BenchmarkNewMessageGetBlockHeader(b *testing.B) {
+	b.ReportAllocs()
+	buf := &bytes.Buffer{}
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if _, err := NewMessage(buf, uint64(i), "getblockheader2", uint64(6406857)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// baseline: 1300 ns/op, 184 B/op, 7 allocs/op
+func BenchmarkNewMessagePortOpen(b *testing.B) {
+	b.ReportAllocs()
+	buf := &bytes.Buffer{}
+	deviceID := make([]byte, 20)
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if _, err := NewMessage(buf, uint64(i), "portopen", deviceID, "tcp:8080", "rw"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// baseline: 450 ns/op, 48 B/op, 3 allocs/op (pooled decoder)
+func BenchmarkParseBlockPeakResponse(b *testing.B) {
+	buffer := encodeTestResponse(b, "response", uint64(42))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseBlockPeakResponse(buffer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// baseline: 148000 ns/op, 3888 B/op, 114 allocs/op (signature check)
+func BenchmarkParseBlockHeaderResponse(b *testing.B) {
+	buffer := newTestBlockHeaderResponse(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseBlockHeaderResponse(buffer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// baseline: 63000 ns/op, 18377 B/op, 406 allocs/op (16 leaves)
+func BenchmarkParseAccountResponse(b *testing.B) {
+	buffer := newTestAccountResponse(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseAccountResponse(buffer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// baseline: 1500 ns/op, 544 B/op, 10 allocs/op
+func BenchmarkParseDeviceTicketTooLow(b *testing.B) {
+	buffer := encodeTestResponse(b, "response", "too_low", make([]byte, 32), uint64(10), uint64(1024), make([]byte, 20), make([]byte, 65))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res, err := parseDeviceTicketResponse(buffer)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if ticket, ok := res.(DeviceTicket); !ok || !errors.Is(ticket.Err, ErrTicketTooLow) {
+			b.Fatalf("expected too low ticket but got %v", res)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultBlockCacheSize is the default number of blocks kept in a BlockCache
+const DefaultBlockCacheSize = 16
+
+type blockCacheEntry struct {
+	blockNumber uint64
+	block       *Block
+}
+
+// BlockCache keeps the last used getblock responses, the least recently
+// used block is evicted when the cache is full
+type BlockCache struct {
+	maxSize int
+	mx      sync.Mutex
+	order   *list.List
+	blocks  map[uint64]*list.Element
+}
+
+// NewBlockCache returns an empty cache that keeps up to maxSize blocks
+func NewBlockCache(maxSize int) *BlockCache {
+	if maxSize <= 0 {
+		maxSize = DefaultBlockCacheSize
+	}
+	return &BlockCache{
+		maxSize: maxSize,
+		order:   list.New(),
+		blocks:  make(map[uint64]*list.Element, maxSize),
+	}
+}
+
+// Get returns the cached block and marks it as recently used
+func (bc *BlockCache) Get(blockNumber uint64) (*Block, bool) {
+	bc.mx.Lock()
+	defer bc.mx.Unlock()
+	elem, ok := bc.blocks[blockNumber]
+	if !ok {
+		return nil, false
+	}
+	bc.order.MoveToFront(elem)
+	return elem.Value.(*blockCacheEntry).block, true
+}
+
+// Put adds the block to the cache
+func (bc *BlockCache) Put(blockNumber uint64, block *Block) {
+	bc.mx.Lock()
+	defer bc.mx.Unlock()
+	if elem, ok := bc.blocks[blockNumber]; ok {
+		elem.Value.(*blockCacheEntry).block = block
+		bc.order.MoveToFront(elem)
+		return
+	}
+	bc.blocks[blockNumber] = bc.order.PushFront(&blockCacheEntry{blockNumber: blockNumber, block: block})
+	if bc.order.Len() > bc.maxSize {
+		oldest := bc.order.Back()
+		bc.order.Remove(oldest)
+		delete(bc.blocks, oldest.Value.(*blockCacheEntry).blockNumber)
+	}
+}
+
+// Len returns the number of cached blocks
+func (bc *BlockCache) Len() int {
+	bc.mx.Lock()
+	defer bc.mx.Unlock()
+	return bc.order.Len()
+}
+
+// Close removes all blocks from the cache
+func (bc *BlockCache) Close() error {
+	bc.mx.Lock()
+	defer bc.mx.Unlock()
+	bc.order.Init()
+	bc.blocks = make(map[uint64]*list.Element)
+	return nil
+}
@@ -0,0 +1,43 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"io"
+	"testing"
+)
+
+func TestBlockCacheEviction(t *testing.T) {
+	cache := NewBlockCache(3)
+	blocks := make([]*Block, 5)
+	for i := 1; i <= 3; i++ {
+		blocks[i] = &Block{Coinbase: []byte{byte(i)}}
+		cache.Put(uint64(i), blocks[i])
+	}
+	if block, ok := cache.Get(1); !ok || block != blocks[1] {
+		t.Fatalf("expected block 1 in the cache")
+	}
+	blocks[4] = &Block{Coinbase: []byte{4}}
+	cache.Put(4, blocks[4])
+
+	if _, ok := cache.Get(2); ok {
+		t.Errorf("expected block 2 to be evicted")
+	}
+	for _, blockNumber := range []uint64{1, 3, 4} {
+		if block, ok := cache.Get(blockNumber); !ok || block != blocks[blockNumber] {
+			t.Errorf("expected block %d in the cache", blockNumber)
+		}
+	}
+	if cache.Len() != 3 {
+		t.Errorf("expected 3 cached blocks but got %d", cache.Len())
+	}
+
+	var closer io.Closer = cache
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(1); ok || cache.Len() != 0 {
+		t.Errorf("expected an empty cache after close")
+	}
+}
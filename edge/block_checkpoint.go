@@ -0,0 +1,44 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"fmt"
+
+	"github.com/diodechain/diode_client/blockquick"
+)
+
+var (
+	ErrBlockHashMismatch = fmt.Errorf("block hash doesn't match the known block hash")
+	ErrUnknownBlockHash  = fmt.Errorf("block hash is not known")
+)
+
+// VerifyBlockHeaderAgainstKnown checks the hash of the given header against the
+// previously validated hashes, headers with an unknown block number pass (soft mode)
+func VerifyBlockHeaderAgainstKnown(header *blockquick.BlockHeader, knownHashes map[uint64][32]byte) error {
+	return verifyBlockHeaderAgainstKnown(header, knownHashes, false)
+}
+
+// VerifyBlockHeaderAgainstKnownStrict is like VerifyBlockHeaderAgainstKnown but
+// rejects headers with an unknown block number (strict mode)
+func VerifyBlockHeaderAgainstKnownStrict(header *blockquick.BlockHeader, knownHashes map[uint64][32]byte) error {
+	return verifyBlockHeaderAgainstKnown(header, knownHashes, true)
+}
+
+func verifyBlockHeaderAgainstKnown(header *blockquick.BlockHeader, knownHashes map[uint64][32]byte, strict bool) error {
+	if header == nil {
+		return fmt.Errorf("block header is nil")
+	}
+	known, ok := knownHashes[header.Number()]
+	if !ok {
+		if strict {
+			return ErrUnknownBlockHash
+		}
+		return nil
+	}
+	if header.Hash() != known {
+		return ErrBlockHashMismatch
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/diodechain/diode_client/blockquick"
+)
+
+// signed block header fixture of block 6406857
+var (
+	testHeaderTxHash             = []byte{200, 183, 173, 94, 219, 199, 203, 146, 222, 81, 226, 35, 194, 242, 25, 106, 84, 45, 151, 139, 134, 136, 185, 158, 10, 147, 97, 204, 251, 90, 163, 84}
+	testHeaderStateHash          = []byte{194, 10, 97, 79, 230, 9, 109, 13, 140, 98, 183, 88, 131, 161, 234, 129, 23, 217, 163, 185, 152, 169, 40, 201, 128, 33, 106, 164, 64, 210, 18, 117}
+	testHeaderPrevBlock          = []byte{0, 0, 39, 225, 2, 205, 90, 142, 203, 98, 195, 69, 19, 213, 225, 75, 37, 95, 220, 249, 148, 16, 117, 192, 187, 192, 254, 68, 82, 172, 151, 35}
+	testHeaderMinerSig           = []byte{0, 151, 29, 1, 22, 133, 215, 29, 173, 153, 188, 19, 243, 24, 254, 211, 246, 212, 253, 133, 116, 69, 102, 108, 209, 217, 190, 222, 15, 4, 91, 222, 199, 35, 24, 137, 45, 75, 22, 30, 123, 7, 111, 231, 12, 37, 180, 192, 30, 182, 166, 139, 165, 41, 22, 231, 88, 171, 122, 85, 9, 102, 17, 59, 155}
+	testHeaderMinerPubkey        = []byte{4, 240, 109, 136, 233, 104, 32, 42, 9, 32, 30, 49, 36, 9, 71, 113, 84, 5, 145, 198, 153, 140, 65, 255, 115, 225, 201, 43, 238, 145, 40, 51, 57, 223, 28, 51, 5, 240, 23, 148, 82, 169, 121, 93, 195, 255, 93, 116, 12, 250, 38, 210, 124, 133, 157, 232, 176, 58, 120, 206, 87, 232, 249, 95, 7}
+	testHeaderTimestamp   uint64 = 1700916441
+	testHeaderNumber      uint64 = 6406857
+	testHeaderNonce              = "3463199413688948191257806122414904513570931607746675394846934843169"
+)
+
+func newTestBlockHeader(t testing.TB) *blockquick.BlockHeader {
+	var nonce big.Int
+	nonce.SetString(testHeaderNonce, 10)
+	header, err := blockquick.NewHeader(testHeaderTxHash, testHeaderStateHash, testHeaderPrevBlock, testHeaderMinerSig, testHeaderMinerPubkey, testHeaderTimestamp, testHeaderNumber, nonce, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &header
+}
+
+func TestVerifyBlockHeaderAgainstKnown(t *testing.T) {
+	header := newTestBlockHeader(t)
+	known := map[uint64][32]byte{header.Number(): header.Hash()}
+	if err := VerifyBlockHeaderAgainstKnownStrict(header, known); err != nil {
+		t.Fatal(err)
+	}
+	forged := map[uint64][32]byte{header.Number(): {1}}
+	if err := VerifyBlockHeaderAgainstKnown(header, forged); err != ErrBlockHashMismatch {
+		t.Errorf("expected ErrBlockHashMismatch but got %v", err)
+	}
+}
+
+func TestVerifyBlockHeaderAgainstKnownStrict(t *testing.T) {
+	header := newTestBlockHeader(t)
+	known := map[uint64][32]byte{header.Number() - 1: {1}}
+	if err := VerifyBlockHeaderAgainstKnown(header, known); err != nil {
+		t.Errorf("soft mode should allow unknown header but got %v", err)
+	}
+	if err := VerifyBlockHeaderAgainstKnownStrict(header, known); err != ErrUnknownBlockHash {
+		t.Errorf("expected ErrUnknownBlockHash but got %v", err)
+	}
+}
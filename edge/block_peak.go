@@ -0,0 +1,67 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrBlockPeakDecreased = fmt.Errorf("block peak decreased")
+)
+
+// BlockPeakDecreasedError is returned by MonotonicBlockPeak when the server
+// returned a block peak below the last seen one
+type BlockPeakDecreasedError struct {
+	Previous uint64
+	Peak     uint64
+}
+
+func (err *BlockPeakDecreasedError) Error() string {
+	return fmt.Sprintf("%v from %d to %d", ErrBlockPeakDecreased, err.Previous, err.Peak)
+}
+
+// Unwrap returns ErrBlockPeakDecreased, so errors.Is matches it
+func (err *BlockPeakDecreasedError) Unwrap() error {
+	return ErrBlockPeakDecreased
+}
+
+// MonotonicBlockPeak polls the block peak and rejects block peaks that
+// decrease across calls, such as the ones of a rogue server
+type MonotonicBlockPeak struct {
+	mx   sync.Mutex
+	poll func(ctx context.Context) (uint64, error)
+	last uint64
+}
+
+// NewMonotonicBlockPeak returns a MonotonicBlockPeak polling with poll,
+// e.g. ClientSession.GetBlockPeak
+func NewMonotonicBlockPeak(poll func(ctx context.Context) (uint64, error)) *MonotonicBlockPeak {
+	return &MonotonicBlockPeak{poll: poll}
+}
+
+// Poll returns the block peak, a lower peak returns 0 with a BlockPeakDecreasedError
+// that carries the previous and the new peak, the last seen peak is kept
+func (bp *MonotonicBlockPeak) Poll(ctx context.Context) (uint64, error) {
+	peak, err := bp.poll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	bp.mx.Lock()
+	defer bp.mx.Unlock()
+	if peak < bp.last {
+		return 0, &BlockPeakDecreasedError{Previous: bp.last, Peak: peak}
+	}
+	bp.last = peak
+	return peak, nil
+}
+
+// Last returns the highest block peak seen so far
+func (bp *MonotonicBlockPeak) Last() uint64 {
+	bp.mx.Lock()
+	defer bp.mx.Unlock()
+	return bp.last
+}
@@ -0,0 +1,43 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMonotonicBlockPeak(t *testing.T) {
+	peaks := []uint64{100, 101, 99}
+	bp := NewMonotonicBlockPeak(func(ctx context.Context) (uint64, error) {
+		peak := peaks[0]
+		peaks = peaks[1:]
+		return peak, nil
+	})
+	ctx := context.Background()
+	for _, want := range []uint64{100, 101} {
+		peak, err := bp.Poll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if peak != want {
+			t.Fatalf("Poll() = %d, want %d", peak, want)
+		}
+	}
+	peak, err := bp.Poll(ctx)
+	if peak != 0 {
+		t.Errorf("Poll() = %d with an error, want 0", peak)
+	}
+	if !errors.Is(err, ErrBlockPeakDecreased) {
+		t.Fatalf("Poll() should fail with ErrBlockPeakDecreased but got %v", err)
+	}
+	var decreased *BlockPeakDecreasedError
+	if !errors.As(err, &decreased) || decreased.Previous != 101 || decreased.Peak != 99 {
+		t.Fatalf("Poll() error = %#v", err)
+	}
+	if bp.Last() != 101 {
+		t.Fatalf("Last() = %d, want 101", bp.Last())
+	}
+}
@@ -0,0 +1,66 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/diodechain/diode_client/rlp"
+)
+
+// decodeBufferPool holds the readers of the message parsers
+var decodeBufferPool = NewBufferPool()
+
+// streamPool holds the rlp streams of the message parsers
+var streamPool = sync.Pool{
+	New: func() interface{} {
+		return new(rlp.Stream)
+	},
+}
+
+// BufferPool reuses the readers of the rlp decoders to reduce the garbage
+// of high throughput parsing
+type BufferPool struct {
+	pool sync.Pool
+}
+
+// NewBufferPool returns an empty pool
+func NewBufferPool() *BufferPool {
+	return &BufferPool{
+		pool: sync.Pool{
+			New: func() interface{} {
+				return bytes.NewReader(nil)
+			},
+		},
+	}
+}
+
+// Get returns a reader that is reset to an empty buffer
+func (bp *BufferPool) Get() *bytes.Reader {
+	return bp.pool.Get().(*bytes.Reader)
+}
+
+// Put returns the reader to the pool, the buffer it reads is released
+func (bp *BufferPool) Put(r *bytes.Reader) {
+	r.Reset(nil)
+	bp.pool.Put(r)
+}
+
+// decodeMessage decodes buffer into v with a pooled reader and stream, messages
+// larger than DefaultMaxMessageSize are rejected with ErrMessageTooLarge, the pooled
+// stream keeps its reader which is reset by Put
+func decodeMessage(buffer []byte, v interface{}) error {
+	if err := validateMessageSize(buffer, DefaultMaxMessageSize); err != nil {
+		return err
+	}
+	r := decodeBufferPool.Get()
+	r.Reset(buffer)
+	stream := streamPool.Get().(*rlp.Stream)
+	stream.Reset(r, uint64(len(buffer)))
+	err := stream.Decode(v)
+	streamPool.Put(stream)
+	decodeBufferPool.Put(r)
+	return err
+}
@@ -0,0 +1,42 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/diodechain/diode_client/rlp"
+)
+
+func TestBufferPoolGet(t *testing.T) {
+	pool := NewBufferPool()
+	r := pool.Get()
+	r.Reset([]byte{1, 2, 3})
+	pool.Put(r)
+	if r = pool.Get(); r.Len() != 0 {
+		t.Fatalf("expected an empty reader but %d bytes are left", r.Len())
+	}
+}
+
+func TestDecodeMessageAllocs(t *testing.T) {
+	buffer := encodeTestResponse(t, "response", uint64(42))
+	unpooled := testing.AllocsPerRun(10000, func() {
+		var response blockPeakResponse
+		if err := validatePayloadLength(buffer, 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := rlp.NewStream(bytes.NewReader(buffer), uint64(len(buffer))).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+	})
+	pooled := testing.AllocsPerRun(10000, func() {
+		if _, err := parseBlockPeakResponse(buffer); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if pooled > unpooled/2 {
+		t.Fatalf("expected at most %v allocations per parse but got %v", unpooled/2, pooled)
+	}
+}
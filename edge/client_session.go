@@ -0,0 +1,232 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/diodechain/diode_client/util"
+)
+
+var (
+	ErrSessionClosed = fmt.Errorf("client session is closed")
+)
+
+// SessionConfig configures a ClientSession
+type SessionConfig struct {
+	// OnRequest is called with the inbound requests of the server
+	OnRequest func(req InboundRequest)
+}
+
+type sessionResult struct {
+	res interface{}
+	err error
+}
+
+// ClientSession owns an edge server connection, it frames the messages, assigns
+// the request ids, dispatches the responses and tracks the open ports
+type ClientSession struct {
+	Ports *PortTracker
+
+	conn       net.Conn
+	config     SessionConfig
+	requestID  uint64
+	dispatcher *ResponseDispatcher
+	writeMx    sync.Mutex
+
+	refMx sync.Mutex
+	refs  map[string]uint64
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	err       error
+}
+
+// NewClientSession returns a session on conn and starts reading its messages
+func NewClientSession(conn net.Conn, config SessionConfig) *ClientSession {
+	cs := &ClientSession{
+		Ports:      NewPortTracker(),
+		conn:       conn,
+		config:     config,
+		dispatcher: NewResponseDispatcher(),
+		refs:       make(map[string]uint64),
+		closed:     make(chan struct{}),
+	}
+	go cs.readLoop()
+	return cs
+}
+
+// PortOpen opens the port of the device
+func (cs *ClientSession) PortOpen(ctx context.Context, deviceID Address, port string, mode string) (*PortOpen, error) {
+	requestID, res, err := cs.call(ctx, "portopen", deviceID[:], port, mode)
+	portOpen, ok := res.(*PortOpen)
+	if err != nil {
+		return portOpen, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("unexpected portopen response %T", res)
+	}
+	cs.refMx.Lock()
+	cs.refs[portOpen.Ref] = requestID
+	cs.refMx.Unlock()
+	cs.Ports.Open(requestID, util.EncodeToString(deviceID[:]))
+	return portOpen, nil
+}
+
+// PortSend sends data to the opened port
+func (cs *ClientSession) PortSend(ctx context.Context, ref string, data []byte) error {
+	_, res, err := cs.call(ctx, "portsend", ref, data)
+	if err != nil {
+		return err
+	}
+	if portSend, ok := res.(*PortSend); !ok || !portSend.Ok {
+		return fmt.Errorf("portsend to %s failed", ref)
+	}
+	return nil
+}
+
+// PortClose closes the port
+func (cs *ClientSession) PortClose(ref string) error {
+	cs.closePort(ref)
+	buf := &bytes.Buffer{}
+	if _, err := NewMessage(buf, cs.nextRequestID(), "portclose", ref); err != nil {
+		return err
+	}
+	return cs.write(buf.Bytes())
+}
+
+// GetBlockPeak returns the block peak of the server
+func (cs *ClientSession) GetBlockPeak(ctx context.Context) (uint64, error) {
+	_, res, err := cs.call(ctx, "getblockpeak")
+	if err != nil {
+		return 0, err
+	}
+	blockPeak, ok := res.(uint64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected getblockpeak response %T", res)
+	}
+	return blockPeak, nil
+}
+
+// GetAccount returns the account at the given block
+func (cs *ClientSession) GetAccount(ctx context.Context, blockNumber uint64, account Address) (*Account, error) {
+	_, res, err := cs.call(ctx, "getaccount", blockNumber, account[:])
+	if err != nil {
+		return nil, err
+	}
+	acc, ok := res.(*Account)
+	if !ok {
+		return nil, fmt.Errorf("unexpected getaccount response %T", res)
+	}
+	return acc, nil
+}
+
+// Close closes the connection, pending calls return ErrSessionClosed
+func (cs *ClientSession) Close() error {
+	return cs.closeWithError(ErrSessionClosed)
+}
+
+// Done is closed when the session is closed
+func (cs *ClientSession) Done() <-chan struct{} {
+	return cs.closed
+}
+
+func (cs *ClientSession) closeWithError(err error) (cerr error) {
+	cs.closeOnce.Do(func() {
+		cs.err = err
+		close(cs.closed)
+		cerr = cs.conn.Close()
+	})
+	return
+}
+
+func (cs *ClientSession) nextRequestID() uint64 {
+	return atomic.AddUint64(&cs.requestID, 1)
+}
+
+func (cs *ClientSession) call(ctx context.Context, method string, args ...interface{}) (uint64, interface{}, error) {
+	requestID := cs.nextRequestID()
+	buf := &bytes.Buffer{}
+	parse, err := NewMessage(buf, requestID, method, args...)
+	if err != nil {
+		return requestID, nil, err
+	}
+	result := make(chan sessionResult, 1)
+	cs.dispatcher.Register(requestID, parse, func(res interface{}, err error) {
+		result <- sessionResult{res: res, err: err}
+	})
+	if err = cs.write(buf.Bytes()); err != nil {
+		cs.dispatcher.Cancel(requestID)
+		return requestID, nil, err
+	}
+	select {
+	case r := <-result:
+		if rpcErr, ok := r.err.(Error); ok {
+			r.err = fmt.Errorf("%s: %s", method, rpcErr.Message)
+		}
+		return requestID, r.res, r.err
+	case <-ctx.Done():
+		cs.dispatcher.Cancel(requestID)
+		return requestID, nil, ctx.Err()
+	case <-cs.closed:
+		cs.dispatcher.Cancel(requestID)
+		return requestID, nil, cs.err
+	}
+}
+
+// write sends the message with its two bytes length prefix
+func (cs *ClientSession) write(msg []byte) error {
+	frame, err := encodeFrame(msg, false)
+	if err != nil {
+		return err
+	}
+	cs.writeMx.Lock()
+	defer cs.writeMx.Unlock()
+	select {
+	case <-cs.closed:
+		return cs.err
+	default:
+	}
+	_, err = cs.conn.Write(frame)
+	return err
+}
+
+func (cs *ClientSession) readLoop() {
+	for {
+		msg, err := readFrame(cs.conn, false)
+		if err != nil {
+			cs.closeWithError(err)
+			return
+		}
+		if msg.IsResponse() {
+			cs.dispatcher.Dispatch(msg.Buffer)
+			continue
+		}
+		req, err := ParseInboundRequest(msg.Buffer)
+		if err != nil {
+			continue
+		}
+		if portClose, ok := req.(*PortCloseRequest); ok {
+			cs.closePort(portClose.Ref)
+		}
+		if cs.config.OnRequest != nil {
+			cs.config.OnRequest(req)
+		}
+	}
+}
+
+func (cs *ClientSession) closePort(ref string) {
+	cs.refMx.Lock()
+	requestID, ok := cs.refs[ref]
+	delete(cs.refs, ref)
+	cs.refMx.Unlock()
+	if ok {
+		cs.Ports.Close(requestID)
+	}
+}
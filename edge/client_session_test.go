@@ -0,0 +1,109 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diodechain/diode_client/rlp"
+)
+
+// testEdgeServer answers the client session calls on the other end of a pipe
+func testEdgeServer(t *testing.T, conn net.Conn, closed chan<- string) {
+	lenByt := make([]byte, 2)
+	for {
+		if _, err := io.ReadFull(conn, lenByt); err != nil {
+			return
+		}
+		buffer := make([]byte, binary.BigEndian.Uint16(lenByt))
+		if _, err := io.ReadFull(conn, buffer); err != nil {
+			return
+		}
+		var request struct {
+			RequestID uint64
+			Payload   []rlp.RawValue
+		}
+		if err := rlp.DecodeBytes(buffer, &request); err != nil {
+			t.Error(err)
+			return
+		}
+		var method, ref string
+		rlp.DecodeBytes(request.Payload[0], &method)
+		var payload []interface{}
+		switch method {
+		case "getblockpeak":
+			payload = []interface{}{"response", uint64(42)}
+		case "portopen":
+			payload = []interface{}{"response", "ok", "ref1"}
+		case "portsend":
+			payload = []interface{}{"response", "ok"}
+		case "portclose":
+			rlp.DecodeBytes(request.Payload[1], &ref)
+			closed <- ref
+			continue
+		default:
+			payload = []interface{}{"error", method, "not found"}
+		}
+		res, err := rlp.EncodeToBytes([]interface{}{request.RequestID, payload})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		frame := make([]byte, 2)
+		binary.BigEndian.PutUint16(frame, uint16(len(res)))
+		if _, err = conn.Write(append(frame, res...)); err != nil {
+			return
+		}
+	}
+}
+
+func TestClientSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	clientConn, serverConn := net.Pipe()
+	closed := make(chan string, 1)
+	go testEdgeServer(t, serverConn, closed)
+	session := NewClientSession(clientConn, SessionConfig{})
+	defer session.Close()
+
+	blockPeak, err := session.GetBlockPeak(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blockPeak != 42 {
+		t.Fatalf("expected block peak 42 but got %d", blockPeak)
+	}
+
+	portOpen, err := session.PortOpen(ctx, Address{1}, "80", "rw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if portOpen.Ref != "ref1" || session.Ports.Count() != 1 {
+		t.Fatalf("expected open port ref1 but got %+v with %d ports", portOpen, session.Ports.Count())
+	}
+	if err = session.PortSend(ctx, portOpen.Ref, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err = session.PortClose(portOpen.Ref); err != nil {
+		t.Fatal(err)
+	}
+	if ref := <-closed; ref != "ref1" || session.Ports.Count() != 0 {
+		t.Fatalf("expected portclose of ref1 but got %s with %d ports", ref, session.Ports.Count())
+	}
+
+	if _, err = session.GetAccount(ctx, 1, Address{2}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error but got %v", err)
+	}
+
+	session.Close()
+	if _, err = session.GetBlockPeak(ctx); err != ErrSessionClosed {
+		t.Fatalf("expected ErrSessionClosed but got %v", err)
+	}
+}
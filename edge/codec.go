@@ -0,0 +1,66 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+
+	"github.com/diodechain/diode_client/rlp"
+)
+
+// Codec encodes requests and decodes messages independent of the transport
+type Codec interface {
+	Encode(requestID uint64, method string, args ...interface{}) ([]byte, error)
+	Decode(buffer []byte) (requestID uint64, method string, payload interface{}, err error)
+}
+
+// codecEnvelope is the [requestID, [method, ...]] frame of every rlp message
+type codecEnvelope struct {
+	RequestID uint64
+	Payload   []rlp.RawValue
+}
+
+// Encode returns the rlp encoded request, see NewMessage
+func (RLPProtocol) Encode(requestID uint64, method string, args ...interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if _, err := NewMessage(buf, requestID, method, args...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode returns the request id, the method and the payload of the message.
+// The payload of an inbound request is its InboundRequest and the payload of
+// an error is its Error. Responses don't name their request, so their payloads
+// are the raw fields after the "response" method, they are parsed by the parser
+// returned by NewMessage.
+func (p RLPProtocol) Decode(buffer []byte) (requestID uint64, method string, payload interface{}, err error) {
+	if err = ValidateFrame(buffer); err != nil {
+		return
+	}
+	if err = validateMessageSize(buffer, p.MaxMessageSize()); err != nil {
+		return
+	}
+	var envelope codecEnvelope
+	if err = decodeMessage(buffer, &envelope); err != nil {
+		return
+	}
+	if len(envelope.Payload) == 0 {
+		err = ErrUnexpectedFieldCount{Expected: 1, Actual: 0}
+		return
+	}
+	if err = rlp.DecodeBytes(envelope.Payload[0], &method); err != nil {
+		return
+	}
+	requestID = envelope.RequestID
+	switch method {
+	case "response":
+		payload = envelope.Payload[1:]
+	case "error":
+		payload, err = parseError(buffer)
+	default:
+		payload, err = ParseInboundRequest(buffer)
+	}
+	return
+}
@@ -0,0 +1,59 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"testing"
+
+	"github.com/diodechain/diode_client/rlp"
+)
+
+func TestRLPCodec(t *testing.T) {
+	var codec Codec = RLPProtocol{}
+
+	buffer, err := codec.Encode(7, "portsend", "ref1", []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requestID, method, payload, err := codec.Decode(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portSend, ok := payload.(*PortSendRequest)
+	if requestID != 7 || method != "portsend" || !ok || portSend.Ref != "ref1" || string(portSend.Data) != "data" {
+		t.Fatalf("unexpected portsend %d %s %+v", requestID, method, payload)
+	}
+
+	buffer, err = rlp.EncodeToBytes([]interface{}{uint64(8), []interface{}{"response", uint64(42)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requestID, method, payload, err = codec.Decode(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, ok := payload.([]rlp.RawValue)
+	if requestID != 8 || method != "response" || !ok || len(fields) != 1 {
+		t.Fatalf("unexpected response %d %s %+v", requestID, method, payload)
+	}
+	blockPeak, err := parseBlockPeakResponse(buffer)
+	if err != nil || blockPeak.(uint64) != 42 {
+		t.Fatalf("expected block peak 42 but got %v %v", blockPeak, err)
+	}
+
+	buffer, err = rlp.EncodeToBytes([]interface{}{uint64(9), []interface{}{"error", "getblockpeak", "not found"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, method, payload, err = codec.Decode(buffer); err != nil || method != "error" || payload.(Error).Message != "not found" {
+		t.Fatalf("unexpected error %s %+v %v", method, payload, err)
+	}
+
+	if _, _, _, err = codec.Decode([]byte("HTTP/1.1 200 OK")); err != ErrNotRLPFrame {
+		t.Fatalf("expected ErrNotRLPFrame but got %v", err)
+	}
+	if _, err = codec.Encode(1, "setfleet", "fleet"); err == nil {
+		t.Fatalf("expected setfleet encode error")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/ecdsa"
 	"encoding/binary"
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/diodechain/diode_client/crypto"
@@ -17,6 +18,9 @@ import (
 var (
 	ErrTicketTooLow = fmt.Errorf("too low")
 	ErrTicketTooOld = fmt.Errorf("too old")
+
+	ErrInvalidServerSig = fmt.Errorf("device ticket is not signed by the server")
+	ErrInvalidLocalAddr = fmt.Errorf("local address is not an ip address")
 )
 
 // DeviceTicket struct for connection and transmission
@@ -36,6 +40,27 @@ type DeviceTicket struct {
 	deviceAddress *util.Address
 }
 
+// Clone returns a copy of the ticket that doesn't share the byte slices
+func (ct *DeviceTicket) Clone() *DeviceTicket {
+	clone := *ct
+	clone.BlockHash = cloneBytes(ct.BlockHash)
+	clone.LocalAddr = cloneBytes(ct.LocalAddr)
+	clone.DeviceSig = cloneBytes(ct.DeviceSig)
+	clone.ServerSig = cloneBytes(ct.ServerSig)
+	if ct.deviceAddress != nil {
+		deviceAddress := *ct.deviceAddress
+		clone.deviceAddress = &deviceAddress
+	}
+	return &clone
+}
+
+func cloneBytes(src []byte) []byte {
+	if src == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(src)), src...)
+}
+
 // ValidateValues checks length of byte[] arrays and returns an error message
 func (ct *DeviceTicket) ValidateValues() error {
 	if len(ct.BlockHash) != 32 {
@@ -44,12 +69,22 @@ func (ct *DeviceTicket) ValidateValues() error {
 	return nil
 }
 
+// SigningBytes returns the canonical encoding of the ticket that the device signs,
+// it's the bytes32[6] message the DiodeRegistry contract verifies
+func (ct *DeviceTicket) SigningBytes() ([]byte, error) {
+	if err := ct.ValidateValues(); err != nil {
+		return nil, err
+	}
+	return ct.arrayBlob()[:192], nil
+}
+
 // HashWithoutSig returns hash of device object without device signature
 func (ct *DeviceTicket) HashWithoutSig() ([]byte, error) {
-	if err := ct.ValidateValues(); err != nil {
+	msg, err := ct.SigningBytes()
+	if err != nil {
 		return nil, err
 	}
-	return crypto.Sha3Hash(ct.arrayBlob()[:192]), nil
+	return crypto.Sha3Hash(msg), nil
 }
 
 // Hash returns hash of device object
@@ -127,6 +162,18 @@ func (ct *DeviceTicket) GetServerIDs() (ret []Address) {
 	return
 }
 
+// LocalAddrIP returns the LocalAddr as ip address, it's nil if LocalAddr is empty
+func (ct *DeviceTicket) LocalAddrIP() (net.IP, error) {
+	switch len(ct.LocalAddr) {
+	case 0:
+		return nil, nil
+	case net.IPv4len, net.IPv6len:
+		return net.IP(ct.LocalAddr), nil
+	default:
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidLocalAddr, len(ct.LocalAddr))
+	}
+}
+
 // DeviceAddress returns device address
 func (ct *DeviceTicket) DeviceAddress() (Address, error) {
 	if ct.deviceAddress == nil {
@@ -134,7 +181,10 @@ func (ct *DeviceTicket) DeviceAddress() (Address, error) {
 		if err != nil {
 			return [20]byte{}, err
 		}
-		addr := util.PubkeyToAddress(devicePubkey)
+		addr, err := util.PubkeyToAddress(devicePubkey)
+		if err != nil {
+			return [20]byte{}, err
+		}
 		ct.deviceAddress = &addr
 	}
 
@@ -185,5 +235,10 @@ func (ct *DeviceTicket) ValidateServerSig() bool {
 		ct.Err = fmt.Errorf("failed to recover server public key: %s", err.Error())
 		return false
 	}
-	return util.PubkeyToAddress(pub) == ct.ServerID
+	addr, err := util.PubkeyToAddress(pub)
+	if err != nil {
+		ct.Err = fmt.Errorf("invalid server public key: %s", err.Error())
+		return false
+	}
+	return addr == ct.ServerID
 }
@@ -0,0 +1,71 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"encoding/json"
+
+	"github.com/diodechain/diode_client/util"
+)
+
+// deviceTicketJSON is the REST API representation of device ticket, binaries are 0x prefixed hex
+type deviceTicketJSON struct {
+	ServerID         string `json:"serverID"`
+	BlockNumber      uint64 `json:"blockNumber"`
+	BlockHash        string `json:"blockHash"`
+	FleetAddr        string `json:"fleetAddr"`
+	TotalConnections uint64 `json:"totalConnections"`
+	TotalBytes       uint64 `json:"totalBytes"`
+	LocalAddr        string `json:"localAddr"`
+	DeviceSig        string `json:"deviceSig"`
+	ServerSig        string `json:"serverSig"`
+}
+
+// MarshalJSON returns json of device ticket with hex encoded binaries
+func (ct DeviceTicket) MarshalJSON() ([]byte, error) {
+	return json.Marshal(deviceTicketJSON{
+		ServerID:         ct.ServerID.HexString(),
+		BlockNumber:      ct.BlockNumber,
+		BlockHash:        util.EncodeToStringOmitEmpty(ct.BlockHash),
+		FleetAddr:        ct.FleetAddr.HexString(),
+		TotalConnections: ct.TotalConnections,
+		TotalBytes:       ct.TotalBytes,
+		LocalAddr:        util.EncodeToStringOmitEmpty(ct.LocalAddr),
+		DeviceSig:        util.EncodeToStringOmitEmpty(ct.DeviceSig),
+		ServerSig:        util.EncodeToStringOmitEmpty(ct.ServerSig),
+	})
+}
+
+// UnmarshalJSON decodes device ticket from json with hex encoded binaries
+func (ct *DeviceTicket) UnmarshalJSON(data []byte) (err error) {
+	var dt deviceTicketJSON
+	if err = json.Unmarshal(data, &dt); err != nil {
+		return
+	}
+	ticket := DeviceTicket{
+		BlockNumber:      dt.BlockNumber,
+		TotalConnections: dt.TotalConnections,
+		TotalBytes:       dt.TotalBytes,
+	}
+	if ticket.ServerID, err = util.DecodeAddress(dt.ServerID); err != nil {
+		return
+	}
+	if ticket.FleetAddr, err = util.DecodeAddress(dt.FleetAddr); err != nil {
+		return
+	}
+	if ticket.BlockHash, err = util.DecodeStringOmitEmpty(dt.BlockHash); err != nil {
+		return
+	}
+	if ticket.LocalAddr, err = util.DecodeStringOmitEmpty(dt.LocalAddr); err != nil {
+		return
+	}
+	if ticket.DeviceSig, err = util.DecodeStringOmitEmpty(dt.DeviceSig); err != nil {
+		return
+	}
+	if ticket.ServerSig, err = util.DecodeStringOmitEmpty(dt.ServerSig); err != nil {
+		return
+	}
+	*ct = ticket
+	return
+}
@@ -0,0 +1,225 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/diodechain/diode_client/crypto"
+	"github.com/diodechain/diode_client/crypto/secp256k1"
+	"github.com/diodechain/diode_client/rlp"
+	"github.com/diodechain/diode_client/util"
+)
+
+func newTestDeviceTicket() *DeviceTicket {
+	return &DeviceTicket{
+		ServerID:         Address{1, 2, 3},
+		BlockNumber:      1234,
+		BlockHash:        crypto.Sha256([]byte("block")),
+		FleetAddr:        Address{4, 5, 6},
+		TotalConnections: 7,
+		TotalBytes:       8192,
+		LocalAddr:        []byte{0, 1, 2, 3},
+		DeviceSig:        bytes.Repeat([]byte{9}, 65),
+		ServerSig:        bytes.Repeat([]byte{10}, 65),
+	}
+}
+
+func TestDeviceTicketJSON(t *testing.T) {
+	ticket := newTestDeviceTicket()
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"serverID":"0x0102030000000000000000000000000000000000"`) {
+		t.Errorf("server id should be hex encoded: %s", data)
+	}
+	var decoded DeviceTicket
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ServerID != ticket.ServerID ||
+		decoded.BlockNumber != ticket.BlockNumber ||
+		!bytes.Equal(decoded.BlockHash, ticket.BlockHash) ||
+		decoded.FleetAddr != ticket.FleetAddr ||
+		decoded.TotalConnections != ticket.TotalConnections ||
+		decoded.TotalBytes != ticket.TotalBytes ||
+		!bytes.Equal(decoded.LocalAddr, ticket.LocalAddr) ||
+		!bytes.Equal(decoded.DeviceSig, ticket.DeviceSig) ||
+		!bytes.Equal(decoded.ServerSig, ticket.ServerSig) {
+		t.Errorf("decoded ticket doesn't match: %+v != %+v", decoded, *ticket)
+	}
+	// tickets are passed around as values, e.g. by parseDeviceTicketResponse
+	valueData, err := json.Marshal(*ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(valueData, data) {
+		t.Errorf("value ticket should be hex encoded: %s", valueData)
+	}
+}
+
+func encodeTestObjectResponse(t *testing.T, blockNumber uint64) []byte {
+	var response objectResponse
+	response.RequestID = 1
+	response.Payload.Type = "response"
+	response.Payload.Ticket.Location = "location"
+	response.Payload.Ticket.ServerID = make([]byte, 20)
+	response.Payload.Ticket.PeakBlock = blockNumber
+	response.Payload.Ticket.FleetAddr = make([]byte, 20)
+	buffer, err := rlp.EncodeToBytes(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buffer
+}
+
+func TestParseAndValidateDeviceTicket(t *testing.T) {
+	currentPeak := uint64(10000)
+	_, err := ParseAndValidateDeviceTicket(encodeTestObjectResponse(t, currentPeak-101), currentPeak, 100)
+	if err != ErrTicketTooOld {
+		t.Errorf("expected ErrTicketTooOld but got %v", err)
+	}
+	ticket, err := ParseAndValidateDeviceTicket(encodeTestObjectResponse(t, currentPeak-100), currentPeak, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket.BlockNumber != currentPeak-100 {
+		t.Errorf("expected block number %d but got %d", currentPeak-100, ticket.BlockNumber)
+	}
+}
+
+func encodeTestSignedObjectResponse(t *testing.T, ticket *DeviceTicket, totalBytes uint64) []byte {
+	var response objectResponse
+	response.RequestID = 1
+	response.Payload.Type = "response"
+	response.Payload.Ticket.Location = "location"
+	response.Payload.Ticket.ServerID = ticket.ServerID[:]
+	response.Payload.Ticket.PeakBlock = ticket.BlockNumber
+	response.Payload.Ticket.FleetAddr = ticket.FleetAddr[:]
+	response.Payload.Ticket.TotalConnections = ticket.TotalConnections
+	response.Payload.Ticket.TotalBytes = totalBytes
+	response.Payload.Ticket.LocalAddr = ticket.LocalAddr
+	response.Payload.Ticket.DeviceSig = ticket.DeviceSig
+	response.Payload.Ticket.ServerSig = ticket.ServerSig
+	buffer, err := rlp.EncodeToBytes(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buffer
+}
+
+func TestParseAndVerifyDeviceObject(t *testing.T) {
+	deviceKey, err := crypto.HexToECDSA("4646464646464646464646464646464646464646464646464646464646464646")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverKey, err := crypto.HexToECDSA("0101010101010101010101010101010101010101010101010101010101010101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverPubkey := crypto.MarshalPubkey(&serverKey.PublicKey)
+	ticket := newTestDeviceTicket()
+	if ticket.ServerID, err = util.PubkeyToAddress(serverPubkey); err != nil {
+		t.Fatal(err)
+	}
+	if err = ticket.Sign(deviceKey); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := ticket.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket.ServerSig, err = secp256k1.Sign(hash, serverKey.D.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	verified, err := ParseAndVerifyDeviceObject(encodeTestSignedObjectResponse(t, ticket, ticket.TotalBytes), ticket.BlockHash, serverPubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.TotalBytes != ticket.TotalBytes {
+		t.Errorf("expected total bytes %d but got %d", ticket.TotalBytes, verified.TotalBytes)
+	}
+	_, err = ParseAndVerifyDeviceObject(encodeTestSignedObjectResponse(t, ticket, ticket.TotalBytes+1), ticket.BlockHash, serverPubkey)
+	if err != ErrInvalidServerSig {
+		t.Errorf("expected ErrInvalidServerSig for tampered ticket but got %v", err)
+	}
+}
+
+func TestDeviceTicketSigningBytes(t *testing.T) {
+	ticket := newTestDeviceTicket()
+	// bytes32[6]{blockhash, fleet, server, total connections, total bytes, sha256(local address)}
+	expected := "496aca80e4d8f29fb8e8cd816c3afb48d3f103970b3a2ee1600c08ca67326dee" +
+		"0000000000000000000000000405060000000000000000000000000000000000" +
+		"0000000000000000000000000102030000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000007" +
+		"0000000000000000000000000000000000000000000000000000000000002000" +
+		"054edec1d0211f624fed0cbca9d4f9400b0e491c43742af2c5b0abebf0c990d8"
+	msg, err := ticket.SigningBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(msg) != expected {
+		t.Errorf("unexpected signing bytes %x", msg)
+	}
+	hash, err := ticket.HashWithoutSig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hash, crypto.Sha3Hash(msg)) {
+		t.Errorf("HashWithoutSig should hash the signing bytes")
+	}
+	ticket.BlockHash = ticket.BlockHash[:31]
+	if _, err = ticket.SigningBytes(); err == nil {
+		t.Errorf("SigningBytes should validate the block hash")
+	}
+}
+
+func TestDeviceTicketLocalAddrIP(t *testing.T) {
+	tests := []struct {
+		localAddr []byte
+		ip        net.IP
+		err       error
+	}{
+		{[]byte{192, 168, 1, 2}, net.IPv4(192, 168, 1, 2), nil},
+		{net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::1"), nil},
+		{nil, nil, nil},
+		{[]byte{1, 2, 3, 4, 5}, nil, ErrInvalidLocalAddr},
+	}
+	for _, tt := range tests {
+		ticket := &DeviceTicket{LocalAddr: tt.localAddr}
+		ip, err := ticket.LocalAddrIP()
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("LocalAddrIP() of %v returned error %v, expected %v", tt.localAddr, err, tt.err)
+		}
+		if !ip.Equal(tt.ip) || (ip == nil) != (tt.ip == nil) {
+			t.Fatalf("LocalAddrIP() of %v = %v, expected %v", tt.localAddr, ip, tt.ip)
+		}
+	}
+}
+
+func TestDeviceTicketClone(t *testing.T) {
+	ticket := newTestDeviceTicket()
+	clone := ticket.Clone()
+	if !reflect.DeepEqual(ticket, clone) {
+		t.Fatalf("clone differs from the ticket")
+	}
+	ticket.DeviceSig[0] = 0xff
+	ticket.BlockHash[0]++
+	ticket.LocalAddr[0]++
+	ticket.ServerSig[0]++
+	if clone.DeviceSig[0] != 9 || clone.ServerSig[0] != 10 || clone.LocalAddr[0] != 0 {
+		t.Errorf("clone shares byte slices with the ticket")
+	}
+	if bytes.Equal(ticket.BlockHash, clone.BlockHash) {
+		t.Errorf("clone shares the block hash with the ticket")
+	}
+}
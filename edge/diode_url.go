@@ -0,0 +1,66 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/diodechain/diode_client/util"
+)
+
+var (
+	ErrInvalidDiodeURL = fmt.Errorf("invalid diode url")
+)
+
+// DiodeURL addresses the port of a device in a fleet
+type DiodeURL struct {
+	DeviceID  [20]byte
+	FleetAddr [20]byte
+	Port      uint16
+	Path      string
+}
+
+// ParseDiodeURL parses urls of the form diode://0x<device>.<fleet>:<port>/path
+func ParseDiodeURL(rawURL string) (*DiodeURL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDiodeURL, err)
+	}
+	if u.Scheme != "diode" {
+		return nil, fmt.Errorf("%w: scheme must be diode but is '%s'", ErrInvalidDiodeURL, u.Scheme)
+	}
+	names := strings.Split(u.Hostname(), ".")
+	if len(names) != 2 {
+		return nil, fmt.Errorf("%w: host must be <device>.<fleet> but is '%s'", ErrInvalidDiodeURL, u.Hostname())
+	}
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	if err != nil || port == 0 {
+		return nil, fmt.Errorf("%w: port must be between 1 and 65535 but is '%s'", ErrInvalidDiodeURL, u.Port())
+	}
+	du := &DiodeURL{Port: uint16(port), Path: u.Path}
+	for i, dst := range []*[20]byte{&du.DeviceID, &du.FleetAddr} {
+		name := names[i]
+		if !strings.HasPrefix(name, "0x") {
+			name = "0x" + name
+		}
+		if !util.IsAddress([]byte(name)) {
+			return nil, fmt.Errorf("%w: '%s' is not an address", ErrInvalidDiodeURL, names[i])
+		}
+		addr, err := util.DecodeAddress(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDiodeURL, err)
+		}
+		*dst = addr
+	}
+	return du, nil
+}
+
+// PortOpenArgs returns the portopen arguments as checked by ValidatePortOpenArgs,
+// the port is opened in "rw" mode
+func (du *DiodeURL) PortOpenArgs() (deviceID []byte, port uint64, mode string) {
+	return cloneBytes(du.DeviceID[:]), uint64(du.Port), "rw"
+}
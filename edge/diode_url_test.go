@@ -0,0 +1,52 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseDiodeURL(t *testing.T) {
+	device := [20]byte{0x11, 0x22, 0x33}
+	fleet := [20]byte{0xaa, 0xbb, 0xcc}
+	deviceHex := "0x1122330000000000000000000000000000000000"
+	fleetHex := "aabbcc0000000000000000000000000000000000"
+	tests := []struct {
+		raw  string
+		port uint16
+		path string
+	}{
+		{"diode://" + deviceHex + "." + fleetHex + ":80/", 80, "/"},
+		{"diode://" + deviceHex + ".0x" + fleetHex + ":8080/index.html", 8080, "/index.html"},
+		{"diode://" + deviceHex + "." + fleetHex + ":65535", 65535, ""},
+	}
+	for _, tt := range tests {
+		du, err := ParseDiodeURL(tt.raw)
+		if err != nil {
+			t.Fatalf("ParseDiodeURL(%s) failed: %v", tt.raw, err)
+		}
+		if du.DeviceID != device || du.FleetAddr != fleet || du.Port != tt.port || du.Path != tt.path {
+			t.Fatalf("ParseDiodeURL(%s) = %+v", tt.raw, du)
+		}
+		deviceID, port, mode := du.PortOpenArgs()
+		if !bytes.Equal(deviceID, device[:]) || port != uint64(tt.port) || mode != "rw" {
+			t.Fatalf("PortOpenArgs() = %x, %d, %s", deviceID, port, mode)
+		}
+		if err := ValidatePortOpenArgs(deviceID, port, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, raw := range []string{
+		"diode://" + deviceHex + ":80/",
+		"http://" + deviceHex + "." + fleetHex + ":80/",
+		"diode://" + deviceHex + "." + fleetHex + "/",
+		"diode://0x1122." + fleetHex + ":80/",
+	} {
+		if _, err := ParseDiodeURL(raw); !errors.Is(err, ErrInvalidDiodeURL) {
+			t.Fatalf("ParseDiodeURL(%s) should fail with ErrInvalidDiodeURL but got %v", raw, err)
+		}
+	}
+}
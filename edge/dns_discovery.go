@@ -0,0 +1,79 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// TXTResolver looks up the TXT records of a domain name, it's implemented by net.Resolver
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DNSDiscovery discovers edge servers from the _diode._tcp TXT records of a domain
+type DNSDiscovery struct {
+	Resolver TXTResolver
+}
+
+// NewDNSDiscovery returns dns discovery that uses the default resolver
+func NewDNSDiscovery() *DNSDiscovery {
+	return &DNSDiscovery{Resolver: net.DefaultResolver}
+}
+
+// Resolve returns the servers of the _diode._tcp.<host> TXT records,
+// each record has the format: host=<h>; edgePort=<p>; serverPort=<q>
+func (dd *DNSDiscovery) Resolve(ctx context.Context, host string) ([]*ServerObj, error) {
+	records, err := dd.Resolver.LookupTXT(ctx, "_diode._tcp."+host)
+	if err != nil {
+		return nil, err
+	}
+	servers := make([]*ServerObj, 0, len(records))
+	for _, record := range records {
+		server, err := parseServerTXTRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+func parseServerTXTRecord(record string) (*ServerObj, error) {
+	server := &ServerObj{
+		Extra: map[string]big.Int{},
+	}
+	for _, field := range strings.Split(record, ";") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("wrong server txt record field: %s", field)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		var err error
+		switch key {
+		case "host":
+			server.Host = []byte(value)
+		case "edgePort":
+			server.EdgePort, err = strconv.ParseUint(value, 10, 16)
+		case "serverPort":
+			server.ServerPort, err = strconv.ParseUint(value, 10, 16)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("wrong server txt record %s: %v", key, err)
+		}
+	}
+	if len(server.Host) == 0 || server.EdgePort == 0 {
+		return nil, fmt.Errorf("server txt record requires host and edgePort: %s", record)
+	}
+	return server, nil
+}
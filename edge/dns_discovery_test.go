@@ -0,0 +1,57 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type mockTXTResolver map[string][]string
+
+func (resolver mockTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if records, ok := resolver[name]; ok {
+		return records, nil
+	}
+	return nil, fmt.Errorf("no such host %s", name)
+}
+
+func TestDNSDiscoveryResolve(t *testing.T) {
+	discovery := &DNSDiscovery{
+		Resolver: mockTXTResolver{
+			"_diode._tcp.diode.io": {
+				"host=eu1.prenet.diode.io; edgePort=41046; serverPort=51054",
+				"host=us1.prenet.diode.io;edgePort=443;serverPort=51054",
+			},
+		},
+	}
+	servers, err := discovery.Resolve(context.Background(), "diode.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 2 {
+		t.Fatalf("expected 2 servers but got %d", len(servers))
+	}
+	if string(servers[0].Host) != "eu1.prenet.diode.io" || servers[0].EdgePort != 41046 || servers[0].ServerPort != 51054 {
+		t.Errorf("wrong server %+v", servers[0])
+	}
+	if string(servers[1].Host) != "us1.prenet.diode.io" || servers[1].EdgePort != 443 || servers[1].ServerPort != 51054 {
+		t.Errorf("wrong server %+v", servers[1])
+	}
+}
+
+func TestDNSDiscoveryWrongRecord(t *testing.T) {
+	discovery := &DNSDiscovery{
+		Resolver: mockTXTResolver{
+			"_diode._tcp.diode.io": {"host=eu1.prenet.diode.io; edgePort=abc"},
+		},
+	}
+	if _, err := discovery.Resolve(context.Background(), "diode.io"); err == nil {
+		t.Errorf("expected error for wrong edgePort")
+	}
+	if _, err := discovery.Resolve(context.Background(), "unknown.io"); err == nil {
+		t.Errorf("expected error for unknown host")
+	}
+}
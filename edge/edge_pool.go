@@ -0,0 +1,173 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultProbeTimeout is the time an edge server has to answer the latency probe
+	DefaultProbeTimeout = 5 * time.Second
+)
+
+var (
+	ErrNoEdgeServer = fmt.Errorf("no reachable edge server")
+)
+
+// EdgeSender sends the request to an edge server and returns the response,
+// it should return when ctx is done
+type EdgeSender func(ctx context.Context, raw []byte) ([]byte, error)
+
+// Latency returns the round trip time of a getblockpeak request sent with send,
+// the response is discarded
+func (obj *ServerObj) Latency(ctx context.Context, send EdgeSender) (time.Duration, error) {
+	buf := &bytes.Buffer{}
+	if _, err := NewMessage(buf, 1, "getblockpeak"); err != nil {
+		return 0, err
+	}
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		_, err := send(ctx, buf.Bytes())
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return time.Since(start), err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// Health returns the resource usage reported by the healthcheck request sent with send
+func (obj *ServerObj) Health(ctx context.Context, send EdgeSender) (*HealthStatus, error) {
+	buf := &bytes.Buffer{}
+	parse, err := NewMessage(buf, 1, "healthcheck")
+	if err != nil {
+		return nil, err
+	}
+	raw, err := send(ctx, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	res, err := parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	var status *HealthStatus
+	err = Payload(res, &status)
+	return status, err
+}
+
+// EdgeServer is an edge server and the function that sends its requests
+type EdgeServer struct {
+	Server *ServerObj
+	Send   EdgeSender
+}
+
+// EdgePool holds the reachable edge servers ordered by latency, or by latency and
+// load with WithHealthScore
+type EdgePool struct {
+	servers      []EdgeServer
+	latencies    []time.Duration
+	probeTimeout time.Duration
+	loadPenalty  time.Duration
+}
+
+// EdgePoolOption configures an EdgePool
+type EdgePoolOption func(*EdgePool)
+
+// WithProbeTimeout sets the time each server has to answer the latency probe
+func WithProbeTimeout(timeout time.Duration) EdgePoolOption {
+	return func(pool *EdgePool) {
+		pool.probeTimeout = timeout
+	}
+}
+
+// WithHealthScore ranks the servers by their latency plus a penalty for the load
+// reported by the healthcheck request, a fully loaded server ranks like a server
+// that is loadPenalty slower. Servers that don't answer the healthcheck are
+// ranked by their latency only
+func WithHealthScore(loadPenalty time.Duration) EdgePoolOption {
+	return func(pool *EdgePool) {
+		pool.loadPenalty = loadPenalty
+	}
+}
+
+// NewEdgePool measures the latency of the servers in parallel and ranks them,
+// servers that fail or don't answer within the probe timeout are dropped
+func NewEdgePool(ctx context.Context, servers []EdgeServer, opts ...EdgePoolOption) (*EdgePool, error) {
+	pool := &EdgePool{probeTimeout: DefaultProbeTimeout}
+	for _, opt := range opts {
+		opt(pool)
+	}
+	latencies := make([]time.Duration, len(servers))
+	scores := make([]time.Duration, len(servers))
+	errs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, server EdgeServer) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, pool.probeTimeout)
+			defer cancel()
+			latencies[i], errs[i] = server.Server.Latency(probeCtx, server.Send)
+			scores[i] = latencies[i]
+			if errs[i] != nil || pool.loadPenalty == 0 {
+				return
+			}
+			if status, err := server.Server.Health(probeCtx, server.Send); err == nil {
+				scores[i] += time.Duration(float64(pool.loadPenalty) * status.Load() / 100)
+			}
+		}(i, server)
+	}
+	wg.Wait()
+
+	type rankedServer struct {
+		server  EdgeServer
+		latency time.Duration
+		score   time.Duration
+	}
+	ranked := make([]rankedServer, 0, len(servers))
+	for i, server := range servers {
+		if errs[i] == nil {
+			ranked = append(ranked, rankedServer{server: server, latency: latencies[i], score: scores[i]})
+		}
+	}
+	if len(ranked) == 0 {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, ErrNoEdgeServer
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score < ranked[j].score })
+	pool.servers = make([]EdgeServer, len(ranked))
+	pool.latencies = make([]time.Duration, len(ranked))
+	for i, r := range ranked {
+		pool.servers[i] = r.server
+		pool.latencies[i] = r.latency
+	}
+	return pool, nil
+}
+
+// Servers returns the servers, the best ranked first
+func (pool *EdgePool) Servers() []EdgeServer {
+	return pool.servers
+}
+
+// Latency returns the measured latency of the i-th server
+func (pool *EdgePool) Latency(i int) time.Duration {
+	return pool.latencies[i]
+}
+
+// Send sends the request to the best ranked server
+func (pool *EdgePool) Send(ctx context.Context, raw []byte) ([]byte, error) {
+	return pool.servers[0].Send(ctx, raw)
+}
@@ -0,0 +1,129 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/diodechain/diode_client/rlp"
+)
+
+func newTestDelayedSender(t *testing.T, delay time.Duration, requests *int32) EdgeSender {
+	return func(ctx context.Context, raw []byte) ([]byte, error) {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		atomic.AddInt32(requests, 1)
+		return encodeTestResponse(t, "response", uint64(1)), nil
+	}
+}
+
+func TestEdgePoolRanksByLatency(t *testing.T) {
+	var slowRequests, fastRequests int32
+	slow := EdgeServer{Server: &ServerObj{Host: []byte("slow.example")}, Send: newTestDelayedSender(t, 50*time.Millisecond, &slowRequests)}
+	fast := EdgeServer{Server: &ServerObj{Host: []byte("fast.example")}, Send: newTestDelayedSender(t, time.Millisecond, &fastRequests)}
+	failing := EdgeServer{Server: &ServerObj{}, Send: func(context.Context, []byte) ([]byte, error) { return nil, fmt.Errorf("unreachable") }}
+	pool, err := NewEdgePool(context.Background(), []EdgeServer{slow, failing, fast})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pool.Servers()) != 2 || pool.Latency(0) > pool.Latency(1) {
+		t.Fatalf("unexpected ranking %v %v", pool.Latency(0), pool.Latency(1))
+	}
+	atomic.StoreInt32(&slowRequests, 0)
+	atomic.StoreInt32(&fastRequests, 0)
+	if _, err = pool.Send(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&fastRequests) != 1 || atomic.LoadInt32(&slowRequests) != 0 {
+		t.Fatalf("the first request should be sent to the fast server")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = NewEdgePool(ctx, []EdgeServer{slow}); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded but got %v", err)
+	}
+}
+
+func TestEdgePoolProbeTimeout(t *testing.T) {
+	var fastRequests int32
+	fast := EdgeServer{Server: &ServerObj{Host: []byte("fast.example")}, Send: newTestDelayedSender(t, time.Millisecond, &fastRequests)}
+	returned := make(chan struct{}, 2)
+	hanging := EdgeServer{Server: &ServerObj{Host: []byte("hanging.example")}, Send: func(ctx context.Context, raw []byte) ([]byte, error) {
+		<-ctx.Done()
+		returned <- struct{}{}
+		return nil, ctx.Err()
+	}}
+	start := time.Now()
+	pool, err := NewEdgePool(context.Background(), []EdgeServer{hanging, fast}, WithProbeTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("probing took %v", elapsed)
+	}
+	if len(pool.Servers()) != 1 || string(pool.Servers()[0].Server.Host) != "fast.example" {
+		t.Fatalf("only the fast server should be ranked")
+	}
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatalf("the probe of the hanging server was not cancelled")
+	}
+
+	if _, err = NewEdgePool(context.Background(), []EdgeServer{hanging}, WithProbeTimeout(time.Millisecond)); err != ErrNoEdgeServer {
+		t.Fatalf("expected ErrNoEdgeServer but got %v", err)
+	}
+}
+
+// newTestHealthSender answers getblockpeak after delay and healthcheck with the load
+func newTestHealthSender(t *testing.T, delay time.Duration, load uint64) EdgeSender {
+	return func(ctx context.Context, raw []byte) ([]byte, error) {
+		var request struct {
+			RequestID uint64
+			Payload   []rlp.RawValue
+		}
+		var method string
+		if err := rlp.DecodeBytes(raw, &request); err != nil {
+			return nil, err
+		}
+		if err := rlp.DecodeBytes(request.Payload[0], &method); err != nil {
+			return nil, err
+		}
+		if method == "healthcheck" {
+			return encodeTestResponse(t, "response", load*100, load*100, uint64(1), uint64(60)), nil
+		}
+		time.Sleep(delay)
+		return encodeTestResponse(t, "response", uint64(1)), nil
+	}
+}
+
+func TestEdgePoolHealthScore(t *testing.T) {
+	loaded := EdgeServer{Server: &ServerObj{Host: []byte("loaded.example")}, Send: newTestHealthSender(t, time.Millisecond, 90)}
+	idle := EdgeServer{Server: &ServerObj{Host: []byte("idle.example")}, Send: newTestHealthSender(t, 30*time.Millisecond, 10)}
+	pool, err := NewEdgePool(context.Background(), []EdgeServer{loaded, idle})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host := string(pool.Servers()[0].Server.Host); host != "loaded.example" {
+		t.Fatalf("without health score the fastest server should rank first, got %s", host)
+	}
+	pool, err = NewEdgePool(context.Background(), []EdgeServer{loaded, idle}, WithHealthScore(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host := string(pool.Servers()[0].Server.Host); host != "idle.example" {
+		t.Fatalf("with health score the idle server should rank first, got %s", host)
+	}
+	if pool.Latency(0) < 30*time.Millisecond {
+		t.Fatalf("latency should be the measured latency, got %v", pool.Latency(0))
+	}
+}
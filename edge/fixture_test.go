@@ -0,0 +1,238 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/diodechain/diode_client/blockquick"
+	"github.com/diodechain/diode_client/crypto/secp256k1"
+	"github.com/diodechain/diode_client/rlp"
+)
+
+// TestFixture is a request and the response fields that the server sends back
+type TestFixture struct {
+	Method string
+	// WireMethod is the method of the encoded request if it differs from Method
+	WireMethod string
+	Args       []interface{}
+	// Response are the response payload fields after "response"
+	Response       []interface{}
+	ExpectedResult interface{}
+	// Check replaces the comparison with ExpectedResult for results with
+	// unexported fields
+	Check func(res interface{}) bool
+}
+
+// encodeTestResponse encodes the payload as message with request id 1
+func encodeTestResponse(tb testing.TB, payload ...interface{}) []byte {
+	buffer, err := rlp.EncodeToBytes([]interface{}{uint64(1), payload})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return buffer
+}
+
+// BuildFixture encodes the request of the fixture with protocol and returns
+// the encoded response to it and the parser of the request
+func BuildFixture(t *testing.T, protocol EdgeProtocol, f TestFixture) (raw []byte, callback func([]byte) (interface{}, error)) {
+	buf := &bytes.Buffer{}
+	callback, err := protocol.NewMessage(buf, 1, f.Method, f.Args...)
+	if err != nil {
+		t.Fatalf("%s: %v", f.Method, err)
+	}
+	var request struct {
+		RequestID uint64
+		Payload   []rlp.RawValue
+	}
+	if err = rlp.DecodeBytes(buf.Bytes(), &request); err != nil {
+		t.Fatalf("%s: %v", f.Method, err)
+	}
+	wireMethod := f.WireMethod
+	if wireMethod == "" {
+		wireMethod = f.Method
+	}
+	var method string
+	if err = rlp.DecodeBytes(request.Payload[0], &method); err != nil || method != wireMethod {
+		t.Fatalf("%s: request has method %q %v", f.Method, method, err)
+	}
+	payload := append([]interface{}{"response"}, f.Response...)
+	if raw, err = rlp.EncodeToBytes([]interface{}{request.RequestID, payload}); err != nil {
+		t.Fatalf("%s: %v", f.Method, err)
+	}
+	return
+}
+
+var rlpResponseMethods = []string{
+	"getblock", "getblockpeak", "getblockheader2", "getblockquick", "getblockquick2",
+	"getaccount", "getaccountnonce", "getaccountroots", "getaccountvalue", "getaccountvaluerange",
+	"getaccountroots_range", "getlogs", "ticket", "portopen", "portsend", "portsend_seq",
+	"portsend_ack", "setfleet", "getobject", "getnode", "getstateroots", "sendtransaction", "healthcheck",
+}
+
+// newTestFixtures returns a fixture for the methods of the protocol
+func newTestFixtures(t *testing.T) []TestFixture {
+	deviceID := make([]byte, 20)
+	roots := newTestRoots("c")
+	hash := [32]byte{1}
+	items := newTestBlockHeaderItems(t)
+	minerPubkey := secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey)
+	proof := []interface{}{[]byte{}, []byte{0}, []interface{}{make([]byte, 32), []byte{1}}}
+	return []TestFixture{
+		{Method: "getblockpeak", Response: []interface{}{uint64(42)}, ExpectedResult: uint64(42)},
+		{Method: "portopen", Args: []interface{}{deviceID, "tcp:80", "rw"}, Response: []interface{}{"ok", "ref1"}, ExpectedResult: &PortOpen{Ref: "ref1", Ok: true}},
+		{Method: "portsend", Args: []interface{}{"ref1", []byte("data")}, Response: []interface{}{"ok"}, ExpectedResult: &PortSend{Ok: true}},
+		{Method: "portsend_ack", Args: []interface{}{"ref1", uint32(3), []byte("data")}, Response: []interface{}{"ref1", uint32(3)}, ExpectedResult: &PortSend{Ref: "ref1", Seq: 3, HasSeq: true, Ok: true}},
+		{Method: "setfleet", Args: []interface{}{deviceID}, Response: []interface{}{"ok"}, ExpectedResult: true},
+		{Method: "getaccountroots", Args: []interface{}{uint64(1), deviceID}, Response: []interface{}{roots}, ExpectedResult: &AccountRoots{AccountRoots: roots}},
+		{Method: "getstateroots", Args: []interface{}{uint64(1)}, Response: []interface{}{roots}, ExpectedResult: &StateRoots{StateRoots: roots}},
+		{Method: "sendtransaction", Args: []interface{}{[]byte{1}}, Response: []interface{}{"ok"}, ExpectedResult: "ok"},
+		{Method: "getaccountroots_range", Args: []interface{}{uint64(1), uint64(1), deviceID}, Response: []interface{}{[]interface{}{[]interface{}{uint64(1), roots}}}, ExpectedResult: []AccountRootsAtBlock{{BlockNumber: 1, Roots: &AccountRoots{AccountRoots: roots}}}},
+		{Method: "healthcheck", Response: []interface{}{uint64(5025), uint64(1000), uint64(3), uint64(60)}, ExpectedResult: &HealthStatus{CPUPercent: 50.25, MemPercent: 10, OpenConnections: 3, UptimeSeconds: 60}},
+		{Method: "sendtransaction", Args: []interface{}{[]byte{1}}, Response: []interface{}{hash[:], uint8(1)}, ExpectedResult: &SendTransactionResult{TxHash: hash, Status: 1}},
+		{Method: "getlogs", Args: []interface{}{uint64(1), uint64(2), deviceID, [][]byte{hash[:]}}, Response: []interface{}{[]interface{}{[]interface{}{deviceID, [][]byte{hash[:]}, []byte("data"), uint64(2), hash[:]}}}, ExpectedResult: []EventLog{{Topics: [][32]byte{hash}, Data: []byte("data"), BlockNumber: 2, TxHash: hash}}},
+		{Method: "portsend_seq", Args: []interface{}{"ref1", uint32(3), []byte("data")}, Response: []interface{}{"ok"}, ExpectedResult: &PortSend{Ok: true}},
+		{Method: "getblock", Args: []interface{}{uint64(1)}, Response: responseFields(t, encodeTestBlockResponse(t)), Check: func(res interface{}) bool {
+			return len(res.(*Block).Transactions) == 0
+		}},
+		{Method: "getblockheader2", Args: []interface{}{testHeaderNumber}, Response: []interface{}{items, minerPubkey}, Check: func(res interface{}) bool {
+			header := res.(blockquick.BlockHeader)
+			return header.Number() == testHeaderNumber
+		}},
+		{Method: "getblockquick", Args: []interface{}{uint64(99), uint64(3)}, Response: []interface{}{[]uint64{testHeaderNumber}}, ExpectedResult: []uint64{testHeaderNumber}},
+		{Method: "getblockquick2", Args: []interface{}{uint64(99), uint64(3)}, Response: []interface{}{[]uint64{testHeaderNumber}}, ExpectedResult: []uint64{testHeaderNumber}},
+		{Method: "getaccount", Args: []interface{}{uint64(1), deviceID}, Response: responseFields(t, newTestAccountResponse(t)), Check: func(res interface{}) bool {
+			account := res.(*Account)
+			return account.Nonce == 1 && account.Balance.Uint64() == 100
+		}},
+		{Method: "getaccountnonce", WireMethod: "getaccount", Args: []interface{}{uint64(1), deviceID}, Response: responseFields(t, newTestAccountResponse(t)), ExpectedResult: uint64(1)},
+		{Method: "getaccountvalue", Args: []interface{}{uint64(1), deviceID, hash[:]}, Response: []interface{}{proof}, Check: func(res interface{}) bool {
+			value := res.(*AccountValue)
+			tree := value.AccountTree()
+			return bytes.Equal(value.StorageKey, hash[:]) && tree.VerifyLeaf(make([]byte, 32))
+		}},
+		{Method: "getaccountvaluerange", Args: []interface{}{uint64(1), uint64(1), deviceID, hash[:]}, Response: []interface{}{[]interface{}{[]interface{}{uint64(1), proof}}}, Check: func(res interface{}) bool {
+			values := res.([]AccountValueAtBlock)
+			return len(values) == 1 && values[0].BlockNumber == 1
+		}},
+		{Method: "ticket", Args: []interface{}{uint64(1), deviceID, uint64(1), uint64(1024), []byte("local"), make([]byte, 65)}, Response: []interface{}{"thanks!", []byte{1}}, ExpectedResult: DeviceTicket{}},
+		{Method: "getobject", Args: []interface{}{deviceID}, Response: responseFields(t, encodeTestObjectResponse(t, 100)), Check: func(res interface{}) bool {
+			return res.(*DeviceTicket).BlockNumber == 100
+		}},
+		{Method: "getnode", Args: []interface{}{deviceID}, Response: responseFields(t, encodeTestServerObjResponse(t, 15)), Check: func(res interface{}) bool {
+			return res.(*ServerObj).NetworkID == 15
+		}},
+	}
+}
+
+func TestProtocolConformance(t *testing.T) {
+	fixtures := newTestFixtures(t)
+	covered := make(map[string]bool, len(fixtures))
+	for _, f := range fixtures {
+		covered[f.Method] = true
+		raw, callback := BuildFixture(t, RLPProtocol{}, f)
+		res, err := callback(raw)
+		if err != nil {
+			t.Errorf("%s: %v", f.Method, err)
+			continue
+		}
+		if f.Check != nil {
+			if !f.Check(res) {
+				t.Errorf("%s: unexpected result %#v", f.Method, res)
+			}
+		} else if !reflect.DeepEqual(res, f.ExpectedResult) {
+			t.Errorf("%s: expected %#v but got %#v", f.Method, f.ExpectedResult, res)
+		}
+	}
+	// every method with a response parser needs a fixture
+	for _, method := range rlpResponseMethods {
+		if !covered[method] {
+			t.Errorf("%s: missing fixture", method)
+		}
+	}
+}
+
+func TestBlockquickResponseVersions(t *testing.T) {
+	// getblockquick (v1) response of request 1 with the block numbers 100, 101 and 102,
+	// it's the same ["response", [numbers...]] payload as getblockquick2
+	v1, err := hex.DecodeString("cf01cd88726573706f6e7365c3646566")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockNumbers := []uint64{100, 101, 102}
+	callback, err := RLPProtocol{}.NewMessage(&bytes.Buffer{}, 1, "getblockquick", uint64(99), uint64(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := callback(v1)
+	if err != nil {
+		t.Fatalf("getblockquick: %v", err)
+	}
+	v2 := TestFixture{Method: "getblockquick2", Args: []interface{}{uint64(99), uint64(3)}, Response: []interface{}{blockNumbers}}
+	raw, callback := BuildFixture(t, RLPProtocol{}, v2)
+	if !bytes.Equal(raw, v1) {
+		t.Fatalf("getblockquick and getblockquick2 responses differ %x %x", v1, raw)
+	}
+	res2, err := callback(raw)
+	if err != nil {
+		t.Fatalf("getblockquick2: %v", err)
+	}
+	if !reflect.DeepEqual(res, blockNumbers) || !reflect.DeepEqual(res, res2) {
+		t.Fatalf("getblockquick and getblockquick2 block numbers differ %v %v", res, res2)
+	}
+}
+
+func TestParseBlockHeaders(t *testing.T) {
+	items := newTestBlockHeaderItems(t)
+	minerPubkey := secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey)
+	f := TestFixture{Method: "getblockheader2", Args: []interface{}{testHeaderNumber}, Response: []interface{}{items, minerPubkey}}
+	raw, callback := BuildFixture(t, RLPProtocol{}, f)
+	res, err := callback(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v3Raw, err := encodeV3Message(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the window of two headers as framed on the edge connection
+	frames := func(msg []byte) []byte {
+		frame, err := encodeFrame(msg, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(frame, frame...)
+	}
+	v2Window, v3Window := frames(raw), frames(v3Raw)
+
+	mux, err := NewProtocolMultiplexer(ProtocolVersion{RLP_V2, RLPProtocol{}}, ProtocolVersion{RLP_V3, RLPV3Protocol{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []uint64{RLP_V2, RLP_V3} {
+		window, other := v2Window, v3Window
+		if version == RLP_V3 {
+			window, other = v3Window, v2Window
+		}
+		if err = mux.Select(version); err != nil {
+			t.Fatal(err)
+		}
+		headers, err := mux.ParseBlockHeaders(window, 2)
+		if err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+		if len(headers) != 2 || !reflect.DeepEqual(*headers[0], res) || !reflect.DeepEqual(*headers[1], res) {
+			t.Fatalf("v%d headers differ from getblockheader2 %v %+v", version, headers, res)
+		}
+		if _, err = mux.ParseBlockHeaders(other, 2); err == nil {
+			t.Errorf("v%d should reject the window of the other version", version)
+		}
+		if _, err = mux.ParseBlockHeaders(window, 1); err == nil {
+			t.Errorf("v%d should reject more headers than the window size", version)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/diodechain/diode_client/contract"
+	"github.com/diodechain/diode_client/rlp"
+	"github.com/diodechain/diode_client/util"
+)
+
+// DeviceRootKey returns storage key of the device root of fleet contract
+func DeviceRootKey() []byte {
+	index := util.IntToBytes(contract.DeviceRootIndex)
+	return util.PaddingBytesPrefix(index, 0, 32)
+}
+
+var (
+	ErrDeviceRootMismatch = fmt.Errorf("device tree doesn't match the device root")
+)
+
+// GetFleetDeviceList returns the devices of the DeviceRoot merkle tree of the given fleet
+// at blockNumber, query must return the getaccountvalue proofs of the fleet at blockNumber.
+// The server only reveals the leaves of the bucket of the DeviceRoot key in the proof,
+// the devices of the pruned branches are not in the list.
+func GetFleetDeviceList(ctx context.Context, fleetAddr [20]byte, blockNumber uint64, query func(key []byte) ([]byte, error)) ([][20]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tree, root, err := readFleetProof(query, DeviceRootKey())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query device root of fleet %x at block %d: %v", fleetAddr, blockNumber, err)
+	}
+	if len(root) != 32 {
+		return nil, fmt.Errorf("device root of fleet %x must be 32 bytes but is %d bytes", fleetAddr, len(root))
+	}
+	return treeDevices(tree)
+}
+
+// readFleetProof decodes the merkle proof of the storage key returned by query
+// and returns it with the value of the key
+func readFleetProof(query func(key []byte) ([]byte, error), key []byte) (tree MerkleTree, value []byte, err error) {
+	raw, err := query(key)
+	if err != nil {
+		return
+	}
+	var rawTree []interface{}
+	if err = rlp.DecodeBytes(raw, &rawTree); err != nil {
+		return
+	}
+	if tree, err = NewMerkleTree(rawTree); err != nil {
+		return
+	}
+	value, err = tree.Get(key)
+	return
+}
+
+// treeDevices returns the device addresses stored in the leaves of the proof of
+// the device root, the leave of the device root itself is skipped
+func treeDevices(tree MerkleTree) ([][20]byte, error) {
+	devices := make([][20]byte, 0, len(tree.Leaves))
+	for _, leave := range tree.Leaves {
+		if bytes.Equal(leave.Key, DeviceRootKey()) {
+			continue
+		}
+		if len(leave.Value) < 20 {
+			return nil, errWrongTree
+		}
+		var device [20]byte
+		copy(device[:], leave.Value[len(leave.Value)-20:])
+		if device == util.EmptyAddress {
+			continue
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// FleetAccessListIterator iterates over the devices of the DeviceRoot merkle tree
+// and reports whether each device is in the fleet allowlist
+type FleetAccessListIterator struct {
+	deviceRoot [32]byte
+	query      func(key []byte) ([]byte, error)
+	devices    [][20]byte
+	loaded     bool
+}
+
+// NewFleetAccessListIterator returns an iterator over the devices of the fleet
+// with the device root deviceRoot, query must return the getaccountvalue proofs
+// of the fleet. Like GetFleetDeviceList only the devices revealed in the proof of
+// the DeviceRoot key are iterated.
+func NewFleetAccessListIterator(deviceRoot [32]byte, query func(key []byte) ([]byte, error)) *FleetAccessListIterator {
+	return &FleetAccessListIterator{
+		deviceRoot: deviceRoot,
+		query:      query,
+	}
+}
+
+// Next returns the next device and whether it is allowlisted, io.EOF is returned
+// after the last device
+func (it *FleetAccessListIterator) Next(ctx context.Context) (addr [20]byte, allowed bool, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if !it.loaded {
+		if err = it.load(); err != nil {
+			return
+		}
+	}
+	if len(it.devices) == 0 {
+		err = io.EOF
+		return
+	}
+	addr = it.devices[0]
+	_, raw, err := readFleetProof(it.query, contract.DeviceAllowlistKey(addr))
+	if err != nil {
+		return
+	}
+	it.devices = it.devices[1:]
+	allowed = new(big.Int).SetBytes(raw).Sign() != 0
+	return
+}
+
+// load reads the devices of the proof of the DeviceRoot key, the proof is
+// rejected unless the DeviceRoot value is deviceRoot
+func (it *FleetAccessListIterator) load() error {
+	tree, root, err := readFleetProof(it.query, DeviceRootKey())
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(root, it.deviceRoot[:]) {
+		return fmt.Errorf("%w: %x != %x", ErrDeviceRootMismatch, root, it.deviceRoot)
+	}
+	if it.devices, err = treeDevices(tree); err != nil {
+		return err
+	}
+	it.loaded = true
+	return nil
+}
@@ -0,0 +1,121 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/diodechain/diode_client/contract"
+	"github.com/diodechain/diode_client/rlp"
+	"github.com/diodechain/diode_client/util"
+)
+
+// newTestFleetProof returns the rlp encoded getaccountvalue proof with the
+// storage leaves
+func newTestFleetProof(t *testing.T, leaves ...MerkleTreeLeave) []byte {
+	rawTree := []interface{}{[]byte{}, []byte{0}}
+	for _, leave := range leaves {
+		rawTree = append(rawTree, []interface{}{leave.Key, leave.Value})
+	}
+	if _, err := NewMerkleTree(rawTree); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := rlp.EncodeToBytes(rawTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+// newTestDeviceLeaves returns the storage leaves of the devices
+func newTestDeviceLeaves(devices [][20]byte) []MerkleTreeLeave {
+	leaves := make([]MerkleTreeLeave, len(devices))
+	for i, device := range devices {
+		leaves[i] = MerkleTreeLeave{
+			Key:   util.PaddingBytesPrefix([]byte{byte(i)}, 0, 32),
+			Value: util.PaddingBytesPrefix(device[:], 0, 32),
+		}
+	}
+	return leaves
+}
+
+// newTestProofQuery returns the proofs of the storage keys
+func newTestProofQuery(proofs map[string][]byte) func(key []byte) ([]byte, error) {
+	return func(key []byte) ([]byte, error) {
+		proof, ok := proofs[string(key)]
+		if !ok {
+			return nil, fmt.Errorf("unexpected storage key %x", key)
+		}
+		return proof, nil
+	}
+}
+
+func TestGetFleetDeviceList(t *testing.T) {
+	fleetAddr := [20]byte{9}
+	devices := [][20]byte{{1}, {2}, {3}}
+	root := MerkleTreeLeave{Key: DeviceRootKey(), Value: util.PaddingBytesPrefix([]byte{7}, 0, 32)}
+	proofs := map[string][]byte{
+		string(DeviceRootKey()): newTestFleetProof(t, append(newTestDeviceLeaves(devices), root)...),
+	}
+	list, err := GetFleetDeviceList(context.Background(), fleetAddr, 100, newTestProofQuery(proofs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(devices) {
+		t.Fatalf("expected %d devices but got %d", len(devices), len(list))
+	}
+	for i, device := range devices {
+		if list[i] != device {
+			t.Errorf("expected device %x but got %x", device, list[i])
+		}
+	}
+
+	// a proof without the device root is rejected
+	proofs[string(DeviceRootKey())] = newTestFleetProof(t, newTestDeviceLeaves(devices)...)
+	if _, err = GetFleetDeviceList(context.Background(), fleetAddr, 100, newTestProofQuery(proofs)); err == nil {
+		t.Fatalf("proof without the device root should fail")
+	}
+}
+
+func TestFleetAccessListIterator(t *testing.T) {
+	devices := [][20]byte{{1}, {2}, {3}, {4}, {5}}
+	var root [32]byte
+	root[31] = 7
+	rootLeave := MerkleTreeLeave{Key: DeviceRootKey(), Value: root[:]}
+	proofs := map[string][]byte{
+		string(DeviceRootKey()): newTestFleetProof(t, append(newTestDeviceLeaves(devices), rootLeave)...),
+	}
+	for i, device := range devices {
+		// every second device is allowlisted
+		key := contract.DeviceAllowlistKey(device)
+		value := util.PaddingBytesPrefix([]byte{byte((i + 1) % 2)}, 0, 32)
+		proofs[string(key)] = newTestFleetProof(t, MerkleTreeLeave{Key: key, Value: value})
+	}
+	it := NewFleetAccessListIterator(root, newTestProofQuery(proofs))
+	for i, device := range devices {
+		addr, allowed, err := it.Next(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr != device {
+			t.Errorf("expected device %x but got %x", device, addr)
+		}
+		if allowed != (i%2 == 0) {
+			t.Errorf("device %x allowed should be %v", device, i%2 == 0)
+		}
+	}
+	if _, _, err := it.Next(context.Background()); err != io.EOF {
+		t.Errorf("expected io.EOF after the last device but got %v", err)
+	}
+
+	// the proof of another device root is rejected
+	it = NewFleetAccessListIterator([32]byte{1}, newTestProofQuery(proofs))
+	if _, _, err := it.Next(context.Background()); !errors.Is(err, ErrDeviceRootMismatch) {
+		t.Errorf("expected ErrDeviceRootMismatch but got %v", err)
+	}
+}
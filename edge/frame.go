@@ -0,0 +1,80 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/diodechain/diode_client/crypto"
+)
+
+var (
+	ErrChecksumMismatch = fmt.Errorf("message checksum mismatch")
+)
+
+// messageChecksum returns the first 4 bytes of the sha256 of buffer
+func messageChecksum(buffer []byte) (checksum [4]byte) {
+	copy(checksum[:], crypto.Sha256(buffer))
+	return
+}
+
+// WriteFrame writes buffer with its two bytes length prefix and four bytes
+// checksum suffix, it is meant for lossy links such as serial or BLE
+func WriteFrame(w io.Writer, buffer []byte) error {
+	frame, err := encodeFrame(buffer, true)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(frame)
+	return err
+}
+
+// ReadFrame reads a frame written by WriteFrame and verifies its checksum
+func ReadFrame(r io.Reader) (Message, error) {
+	return readFrame(r, true)
+}
+
+// encodeFrame returns buffer with its two bytes length prefix, followed by the
+// four bytes checksum if withChecksum is set
+func encodeFrame(buffer []byte, withChecksum bool) ([]byte, error) {
+	if len(buffer) > 0xffff {
+		return nil, fmt.Errorf("message of %d bytes is too large", len(buffer))
+	}
+	frame := make([]byte, 2, len(buffer)+6)
+	binary.BigEndian.PutUint16(frame, uint16(len(buffer)))
+	frame = append(frame, buffer...)
+	if withChecksum {
+		checksum := messageChecksum(buffer)
+		frame = append(frame, checksum[:]...)
+	}
+	return frame, nil
+}
+
+// readFrame reads a frame of encodeFrame, the checksum is verified if withChecksum is set
+func readFrame(r io.Reader, withChecksum bool) (msg Message, err error) {
+	lenByt := make([]byte, 2)
+	if _, err = io.ReadFull(r, lenByt); err != nil {
+		return
+	}
+	size := int(binary.BigEndian.Uint16(lenByt))
+	if withChecksum {
+		size += 4
+	}
+	buffer := make([]byte, size)
+	if _, err = io.ReadFull(r, buffer); err != nil {
+		return
+	}
+	msg.Len = len(buffer) + 2
+	msg.Buffer = buffer
+	if withChecksum {
+		msg.Buffer = buffer[:len(buffer)-4]
+		copy(msg.Checksum[:], buffer[len(buffer)-4:])
+		if msg.Checksum != messageChecksum(msg.Buffer) {
+			err = ErrChecksumMismatch
+		}
+	}
+	return
+}
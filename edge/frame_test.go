@@ -0,0 +1,37 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestReadFrame(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if _, err := NewMessage(buf, 1, "getblockpeak"); err != nil {
+		t.Fatal(err)
+	}
+	payload := buf.Bytes()
+	frame := &bytes.Buffer{}
+	if err := WriteFrame(frame, payload); err != nil {
+		t.Fatal(err)
+	}
+	raw := frame.Bytes()
+	msg, err := ReadFrame(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(msg.Buffer, payload) || msg.Len != len(raw) || msg.Checksum != messageChecksum(payload) {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	for i := 2; i < len(raw); i++ {
+		corrupt := append([]byte(nil), raw...)
+		corrupt[i] ^= 0x01
+		if _, err = ReadFrame(bytes.NewReader(corrupt)); !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("corrupt byte %d: expected ErrChecksumMismatch but got %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"sync"
+)
+
+// PortOpenFunc sends a portopen request to the given device and port
+type PortOpenFunc func(ctx context.Context, deviceID Address, port string, mode string) (*PortOpen, error)
+
+type portOpenKey struct {
+	deviceID Address
+	port     string
+	mode     string
+}
+
+type portOpenCall struct {
+	done     chan struct{}
+	portOpen *PortOpen
+	err      error
+}
+
+// IdempotentPortOpener merges concurrent portopen requests for the same device,
+// port and mode into a single wire request, all callers receive the same response
+type IdempotentPortOpener struct {
+	open     PortOpenFunc
+	inflight sync.Map
+}
+
+// NewIdempotentPortOpener returns an opener that sends portopen requests with open
+func NewIdempotentPortOpener(open PortOpenFunc) *IdempotentPortOpener {
+	return &IdempotentPortOpener{open: open}
+}
+
+// PortOpen sends the portopen request, or waits for the response of the
+// request that is already in flight for the same device, port and mode
+func (opener *IdempotentPortOpener) PortOpen(ctx context.Context, deviceID Address, port string, mode string) (*PortOpen, error) {
+	key := portOpenKey{deviceID: deviceID, port: port, mode: mode}
+	call := &portOpenCall{done: make(chan struct{})}
+	if existing, loaded := opener.inflight.LoadOrStore(key, call); loaded {
+		call = existing.(*portOpenCall)
+		select {
+		case <-call.done:
+			return call.portOpen, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call.portOpen, call.err = opener.open(ctx, deviceID, port, mode)
+	opener.inflight.Delete(key)
+	close(call.done)
+	return call.portOpen, call.err
+}
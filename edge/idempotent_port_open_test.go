@@ -0,0 +1,85 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotentPortOpener(t *testing.T) {
+	var sent int32
+	release := make(chan struct{})
+	opener := NewIdempotentPortOpener(func(ctx context.Context, deviceID Address, port string, mode string) (*PortOpen, error) {
+		atomic.AddInt32(&sent, 1)
+		<-release
+		return &PortOpen{Ref: "ref", DeviceID: deviceID, Ok: true}, nil
+	})
+	device := Address{1}
+	results := make([]*PortOpen, 2)
+	var wg sync.WaitGroup
+	open := func(i int) {
+		defer wg.Done()
+		portOpen, err := opener.PortOpen(context.Background(), device, "tcp:80", "rw")
+		if err != nil {
+			t.Error(err)
+		}
+		results[i] = portOpen
+	}
+	wg.Add(2)
+	go open(0)
+	// wait until the first request is on the wire before sending the duplicate
+	for atomic.LoadInt32(&sent) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go open(1)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if sent != 1 {
+		t.Errorf("expected 1 wire message but got %d", sent)
+	}
+	if results[0] == nil || results[0] != results[1] {
+		t.Errorf("both callers should receive the same response")
+	}
+}
+
+func TestIdempotentPortOpenerModes(t *testing.T) {
+	var sent int32
+	release := make(chan struct{})
+	opener := NewIdempotentPortOpener(func(ctx context.Context, deviceID Address, port string, mode string) (*PortOpen, error) {
+		atomic.AddInt32(&sent, 1)
+		<-release
+		return &PortOpen{Ref: mode, DeviceID: deviceID, Ok: true}, nil
+	})
+	device := Address{1}
+	modes := []string{"r", "rw"}
+	results := make([]*PortOpen, len(modes))
+	var wg sync.WaitGroup
+	for i, mode := range modes {
+		wg.Add(1)
+		go func(i int, mode string) {
+			defer wg.Done()
+			portOpen, err := opener.PortOpen(context.Background(), device, "tcp:80", mode)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = portOpen
+		}(i, mode)
+	}
+	// both requests have to be on the wire before any response arrives
+	for atomic.LoadInt32(&sent) < int32(len(modes)) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	for i, mode := range modes {
+		if results[i] == nil || results[i].Ref != mode {
+			t.Errorf("expected the %s response but got %+v", mode, results[i])
+		}
+	}
+}
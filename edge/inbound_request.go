@@ -3,7 +3,18 @@
 // Licensed under the Diode License, Version 1.1
 package edge
 
+import "github.com/diodechain/diode_client/rlp"
+
 // Inbound request struct
+// inboundRequestMethod decodes the method name of any inbound request
+type inboundRequestMethod struct {
+	RequestID uint64
+	Payload   struct {
+		Method string
+		Args   []rlp.RawValue `rlp:"tail"`
+	}
+}
+
 type portOpenInboundRequest struct {
 	RequestID uint64
 	Payload   struct {
@@ -20,6 +31,18 @@ type portSendInboundRequest struct {
 		Method string
 		Ref    string
 		Data   []byte
+		// optional sequence number of the frame
+		Seq []uint32 `rlp:"tail"`
+	}
+}
+
+type portSendSeqInboundRequest struct {
+	RequestID uint64
+	Payload   struct {
+		Method string
+		Ref    string
+		Seq    uint32
+		Data   []byte
 	}
 }
 
@@ -28,6 +51,8 @@ type portCloseInboundRequest struct {
 	Payload   struct {
 		Method string
 		Ref    string
+		// optional close reason, missing means ReasonNormal
+		Reason []string `rlp:"tail"`
 	}
 }
 
@@ -39,3 +64,72 @@ type goodbyeInboundRequest struct {
 		Message string
 	}
 }
+
+// RequestKind identifies the type of an inbound request
+type RequestKind int
+
+const (
+	PortOpenRequestKind RequestKind = iota + 1
+	PortSendRequestKind
+	SequencedPortSendRequestKind
+	PortCloseRequestKind
+	GoodbyeRequestKind
+)
+
+// InboundRequest is a request sent by the server, it's implemented by the typed
+// requests that ParseInboundRequest returns
+type InboundRequest interface {
+	Kind() RequestKind
+}
+
+// PortOpenRequest is an inbound portopen request
+type PortOpenRequest struct {
+	PortOpen
+}
+
+// PortSendRequest is an inbound portsend request
+type PortSendRequest struct {
+	PortSend
+}
+
+// SequencedPortSendRequest is an inbound portsend_seq request
+type SequencedPortSendRequest struct {
+	SequencedPortSend
+}
+
+// PortCloseRequest is an inbound portclose request
+type PortCloseRequest struct {
+	PortClose
+}
+
+// GoodbyeRequest is an inbound goodbye request
+type GoodbyeRequest struct {
+	Goodbye
+}
+
+func (req *PortOpenRequest) Kind() RequestKind          { return PortOpenRequestKind }
+func (req *PortSendRequest) Kind() RequestKind          { return PortSendRequestKind }
+func (req *SequencedPortSendRequest) Kind() RequestKind { return SequencedPortSendRequestKind }
+func (req *PortCloseRequest) Kind() RequestKind         { return PortCloseRequestKind }
+func (req *GoodbyeRequest) Kind() RequestKind           { return GoodbyeRequestKind }
+
+// ParseInboundRequest parses the inbound request into its typed InboundRequest
+func ParseInboundRequest(buffer []byte) (InboundRequest, error) {
+	req, err := parseInboundRequest(buffer)
+	if err != nil {
+		return nil, err
+	}
+	switch req := req.(type) {
+	case *PortOpen:
+		return &PortOpenRequest{*req}, nil
+	case *PortSend:
+		return &PortSendRequest{*req}, nil
+	case *SequencedPortSend:
+		return &SequencedPortSendRequest{*req}, nil
+	case *PortClose:
+		return &PortCloseRequest{*req}, nil
+	case Goodbye:
+		return &GoodbyeRequest{req}, nil
+	}
+	return nil, ErrUnknownRequest
+}
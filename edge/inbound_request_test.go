@@ -0,0 +1,101 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseInboundRequestKind(t *testing.T) {
+	deviceID := Address{1, 2, 3}
+	tests := []struct {
+		buffer []byte
+		kind   RequestKind
+	}{
+		{encodeTestResponse(t, "portopen", "tcp:80", "ref", deviceID[:]), PortOpenRequestKind},
+		{encodeTestResponse(t, "portsend", "ref", []byte("data")), PortSendRequestKind},
+		{encodeTestResponse(t, "portsend_seq", "ref", uint32(2), []byte("data")), SequencedPortSendRequestKind},
+		{encodeTestResponse(t, "portclose", "ref"), PortCloseRequestKind},
+		{encodeTestResponse(t, "goodbye", "ticket_expected", "bye"), GoodbyeRequestKind},
+		// the method is decoded, other method names in the payload are ignored
+		{encodeTestResponse(t, "portsend", "ref", []byte("portsend_seq")), PortSendRequestKind},
+		{encodeTestResponse(t, "portclose", "portopen"), PortCloseRequestKind},
+	}
+	for _, test := range tests {
+		req, err := ParseInboundRequest(test.buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.Kind() != test.kind {
+			t.Errorf("expected kind %d but got %d (%T)", test.kind, req.Kind(), req)
+		}
+	}
+	req, err := ParseInboundRequest(encodeTestResponse(t, "portsend", "ref", []byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if portSend := req.(*PortSendRequest); portSend.Ref != "ref" || string(portSend.Data) != "data" {
+		t.Errorf("unexpected portsend request %+v", portSend)
+	}
+	if _, err = ParseInboundRequest(encodeTestResponse(t, "unknown")); err != ErrUnknownRequest {
+		t.Errorf("expected ErrUnknownRequest but got %v", err)
+	}
+}
+
+func TestParseInboundPortCloseReason(t *testing.T) {
+	for _, reason := range []string{ReasonNormal, ReasonError, ReasonTimeout} {
+		buf := &bytes.Buffer{}
+		if _, err := NewMessage(buf, 1, "portclose", "ref", reason); err != nil {
+			t.Fatal(err)
+		}
+		req, err := parseInboundRequest(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if portClose := req.(*PortClose); portClose.Ref != "ref" || portClose.Reason != reason {
+			t.Errorf("expected reason %s but got %+v", reason, portClose)
+		}
+	}
+	// older servers don't send a reason
+	req, err := parseInboundRequest(encodeTestResponse(t, "portclose", "ref"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if portClose := req.(*PortClose); portClose.Reason != ReasonNormal {
+		t.Errorf("expected reason %s but got %s", ReasonNormal, portClose.Reason)
+	}
+}
+
+func TestPortOpenDeviceAddr(t *testing.T) {
+	deviceID := Address{1, 2, 3}
+	portOpen := &PortOpen{RawDeviceID: deviceID[:]}
+	addr, err := portOpen.DeviceAddrBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != deviceID {
+		t.Errorf("expected device address %x but got %x", deviceID, addr)
+	}
+	if portOpen.DeviceAddrString() != "0x0102030000000000000000000000000000000000" {
+		t.Errorf("unexpected device address string %s", portOpen.DeviceAddrString())
+	}
+
+	portOpen = &PortOpen{RawDeviceID: deviceID[:19]}
+	if _, err = portOpen.DeviceAddrBytes(); !errors.Is(err, ErrInvalidDeviceID) {
+		t.Errorf("expected ErrInvalidDeviceID but got %v", err)
+	}
+	if portOpen.DeviceAddrString() != "0x01020300000000000000000000000000000000" {
+		t.Errorf("unexpected device address string %s", portOpen.DeviceAddrString())
+	}
+	// the parser returns the request so that the error is sent back to the server
+	req, err := parseInboundPortOpenRequest(encodeTestResponse(t, "portopen", "tcp:80", "ref", deviceID[:19]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if portOpen := req.(*PortOpen); portOpen.Ok || portOpen.Ref != "ref" || !errors.Is(portOpen.Err, ErrInvalidDeviceID) {
+		t.Errorf("expected portopen with ErrInvalidDeviceID from the parser but got %+v", portOpen)
+	}
+}
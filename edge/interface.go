@@ -0,0 +1,105 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"io"
+
+	"github.com/diodechain/diode_client/blockquick"
+)
+
+// EdgeProtocol encodes requests and parses the messages of one protocol version
+type EdgeProtocol interface {
+	NewMessage(writer io.Writer, requestID uint64, method string, args ...interface{}) (func(buffer []byte) (interface{}, error), error)
+	Parse(buffer []byte) (interface{}, error)
+	// ParseBlockHeaders parses the length prefixed getblockheader2 responses of
+	// a blockquick window of at most size headers
+	ParseBlockHeaders(raw []byte, size int) ([]*blockquick.BlockHeader, error)
+}
+
+// RLPProtocol is the rlp encoded edge protocol implemented by this package
+type RLPProtocol struct {
+	tracing        bool
+	maxMessageSize int
+}
+
+// RLPProtocolOption configures a RLPProtocol
+type RLPProtocolOption func(*RLPProtocol)
+
+// WithTracing emits runtime/trace regions around the encoding and parsing of
+// each message
+func WithTracing(enabled bool) RLPProtocolOption {
+	return func(p *RLPProtocol) {
+		p.tracing = enabled
+	}
+}
+
+// WithMaxMessageSize rejects messages larger than size bytes with ErrMessageTooLarge,
+// the size is capped at DefaultMaxMessageSize which the parsers never exceed
+func WithMaxMessageSize(size int) RLPProtocolOption {
+	return func(p *RLPProtocol) {
+		p.maxMessageSize = size
+	}
+}
+
+// NewRLPProtocol returns the protocol with the given options
+func NewRLPProtocol(opts ...RLPProtocolOption) RLPProtocol {
+	var p RLPProtocol
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// MaxMessageSize returns the largest message in bytes that the protocol parses
+func (p RLPProtocol) MaxMessageSize() int {
+	if p.maxMessageSize <= 0 || p.maxMessageSize > DefaultMaxMessageSize {
+		return DefaultMaxMessageSize
+	}
+	return p.maxMessageSize
+}
+
+// NewMessage encodes the request, see NewMessage
+func (p RLPProtocol) NewMessage(writer io.Writer, requestID uint64, method string, args ...interface{}) (func(buffer []byte) (interface{}, error), error) {
+	var parse func(buffer []byte) (interface{}, error)
+	var err error
+	if p.tracing {
+		parse, err = newTracedMessage(writer, requestID, method, args...)
+	} else {
+		parse, err = NewMessage(writer, requestID, method, args...)
+	}
+	if err != nil || parse == nil {
+		return parse, err
+	}
+	maxSize := p.MaxMessageSize()
+	return func(buffer []byte) (interface{}, error) {
+		if err := validateMessageSize(buffer, maxSize); err != nil {
+			return nil, err
+		}
+		return parse(buffer)
+	}, nil
+}
+
+// Parse parses the response, error or inbound request in buffer
+func (p RLPProtocol) Parse(buffer []byte) (interface{}, error) {
+	if err := validateMessageSize(buffer, p.MaxMessageSize()); err != nil {
+		return nil, err
+	}
+	msg := Message{Len: len(buffer), Buffer: buffer}
+	if msg.IsError() {
+		return msg.ReadAsError()
+	}
+	if msg.IsResponse() {
+		return msg.ReadAsResponse()
+	}
+	return msg.ReadAsInboundRequest()
+}
+
+// ParseBlockHeaders parses the block headers of the window, see EdgeProtocol
+func (p RLPProtocol) ParseBlockHeaders(raw []byte, size int) ([]*blockquick.BlockHeader, error) {
+	maxSize := p.MaxMessageSize()
+	return parseBlockHeaders(raw, size, func(msg []byte) ([]byte, error) {
+		return msg, validateMessageSize(msg, maxSize)
+	})
+}
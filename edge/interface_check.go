@@ -0,0 +1,11 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+// compile time checks of the EdgeProtocol implementations
+var (
+	_ EdgeProtocol = RLPProtocol{}
+	_ EdgeProtocol = RLPV3Protocol{}
+	_ EdgeProtocol = (*ProtocolMultiplexer)(nil)
+)
@@ -16,6 +16,8 @@ import (
 var (
 	errWrongTree   = fmt.Errorf("wrong merkle tree data")
 	errKeyNotFound = fmt.Errorf("key not found in merkle tree")
+
+	ErrDuplicateMerkleKey = fmt.Errorf("duplicate key in merkle tree")
 )
 
 // MerkleTreeNode struct for node of merkle tree
@@ -49,10 +51,29 @@ func (mt *MerkleTree) Get(key []byte) ([]byte, error) {
 	return nil, errKeyNotFound
 }
 
+// VerifyLeaf returns true if the given key is a leave of the merkle tree
+func (mt *MerkleTree) VerifyLeaf(key []byte) bool {
+	_, err := mt.Get(key)
+	return err == nil
+}
+
+// checkDuplicateKeys returns ErrDuplicateMerkleKey if a key appears in more than one leave
+func checkDuplicateKeys(leaves []MerkleTreeLeave) error {
+	seen := make(map[string]struct{}, len(leaves))
+	for _, leave := range leaves {
+		key := string(leave.Key)
+		if _, ok := seen[key]; ok {
+			return ErrDuplicateMerkleKey
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
 func (mt *MerkleTree) parse() (rootHash []byte, modulo uint64, leaves []MerkleTreeLeave, err error) {
 	var parsed interface{}
 
-	parsed, modulo, leaves, err = mt.mtp.rparse(mt.RawTree, 0, 0)
+	parsed, modulo, leaves, err = mt.mtp.rparseIterative(mt.RawTree)
 	if err != nil {
 		return
 	}
@@ -109,7 +130,9 @@ func (mt MerkleTreeParser) parseProof(proof interface{}, depth int, bits uint64)
 		err = errWrongTree
 		return
 	}
-	modulo = util.DecodeBytesToUint(bytModulo)
+	if modulo, err = util.DecodeBytesToUint(bytModulo); err != nil {
+		return
+	}
 	bertProof := bert.List{
 		Items: []bert.Term{
 			prefix,
@@ -158,49 +181,81 @@ func setBit(n uint64, pos int, bit uint64) uint64 {
 	return n
 }
 
-// parse recursively
-func (mt MerkleTreeParser) rparse(proof interface{}, depth int, bits uint64) (interface{}, uint64, []MerkleTreeLeave, error) {
-	val := reflect.ValueOf(proof)
-	kind := val.Kind()
-	if kind != reflect.Slice && kind != reflect.Array {
-		return nil, 0, nil, errWrongTree
-	}
-	if bytVal, ok := val.Interface().([]byte); ok {
-		return bytVal, 0, nil, nil
-	}
-	proofLen := val.Len()
-	if proofLen == 0 {
-		return nil, 0, nil, errWrongTree
-	}
-	// This can be a [prefix, pos | rest] list if and only if
-	// prefix is a byte array of less than 32 bytes
-	leftRaw := val.Index(0).Interface()
-	if bytVal, ok := leftRaw.([]byte); ok {
-		if len(bytVal) < 32 {
-			return mt.parseProof(proof, depth, bits)
-		}
-	}
-	if proofLen != 2 {
-		return nil, 0, nil, errWrongTree
-	}
+// rparseFrame is a node of the proof on the stack of rparseIterative
+type rparseFrame struct {
+	proof interface{}
+	depth int
+	bits  uint64
+	// visited is true once the children of the node are on the stack
+	visited bool
+}
 
-	depth = depth + 1
-	leftItem, lmodulo, lleaves, err := mt.rparse(leftRaw, depth, setBit(bits, depth, 0))
-	if err != nil {
-		return nil, 0, nil, err
-	}
-	rightRaw := val.Index(1).Interface()
-	rightItem, rmodulo, rleaves, err := mt.rparse(rightRaw, depth, setBit(bits, depth, 1))
-	if err != nil {
-		return nil, 0, nil, err
-	}
-	tree := [2]bert.Term{
-		leftItem,
-		rightItem,
-	}
+// rparseResult is the parsed node of the proof
+type rparseResult struct {
+	hash   interface{}
+	modulo uint64
+	leaves []MerkleTreeLeave
+}
 
-	rootHash, err := util.BertHash(tree)
-	modulo := lmodulo + rmodulo
-	leaves := append(lleaves, rleaves...)
-	return rootHash, modulo, leaves, err
+// rparseIterative parses the proof like rparse(proof, 0, 0) with a stack on
+// the heap, the goroutine stack doesn't grow with the depth of the tree
+func (mt MerkleTreeParser) rparseIterative(proof interface{}) (interface{}, uint64, []MerkleTreeLeave, error) {
+	stack := []rparseFrame{{proof: proof}}
+	var results []rparseResult
+	for len(stack) > 0 {
+		top := len(stack) - 1
+		frame := stack[top]
+		if frame.visited {
+			// both children are parsed, the right one is on top
+			left, right := results[len(results)-2], results[len(results)-1]
+			results = results[:len(results)-2]
+			rootHash, err := util.BertHash([2]bert.Term{left.hash, right.hash})
+			if err != nil {
+				return nil, 0, nil, err
+			}
+			results = append(results, rparseResult{
+				hash:   rootHash,
+				modulo: left.modulo + right.modulo,
+				leaves: append(left.leaves, right.leaves...),
+			})
+			stack = stack[:top]
+			continue
+		}
+		val := reflect.ValueOf(frame.proof)
+		kind := val.Kind()
+		if kind != reflect.Slice && kind != reflect.Array {
+			return nil, 0, nil, errWrongTree
+		}
+		if bytVal, ok := val.Interface().([]byte); ok {
+			results = append(results, rparseResult{hash: bytVal})
+			stack = stack[:top]
+			continue
+		}
+		proofLen := val.Len()
+		if proofLen == 0 {
+			return nil, 0, nil, errWrongTree
+		}
+		leftRaw := val.Index(0).Interface()
+		if bytVal, ok := leftRaw.([]byte); ok && len(bytVal) < 32 {
+			rootHash, modulo, leaves, err := mt.parseProof(frame.proof, frame.depth, frame.bits)
+			if err != nil {
+				return nil, 0, nil, err
+			}
+			results = append(results, rparseResult{hash: rootHash, modulo: modulo, leaves: leaves})
+			stack = stack[:top]
+			continue
+		}
+		if proofLen != 2 {
+			return nil, 0, nil, errWrongTree
+		}
+		depth := frame.depth + 1
+		stack[top].visited = true
+		// the left child is pushed last so that it's parsed first
+		stack = append(stack,
+			rparseFrame{proof: val.Index(1).Interface(), depth: depth, bits: setBit(frame.bits, depth, 1)},
+			rparseFrame{proof: leftRaw, depth: depth, bits: setBit(frame.bits, depth, 0)},
+		)
+	}
+	result := results[0]
+	return result.hash, result.modulo, result.leaves, nil
 }
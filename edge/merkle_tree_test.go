@@ -6,9 +6,12 @@ package edge
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"testing"
 
+	"github.com/diodechain/diode_client/crypto"
 	"github.com/diodechain/diode_client/util"
+	bert "github.com/diodechain/gobert"
 )
 
 func TestValidateMerkleTree(t *testing.T) {
@@ -78,3 +81,128 @@ func assertSame(t *testing.T, rawTestTree []interface{}, key []uint8, expected [
 		fmt.Printf("Expected: %v got: %v\n", expected, value)
 	}
 }
+
+func newTestAddressProof(t *testing.T, modulo byte, addr [20]byte) MerkleTree {
+	key := crypto.Sha3Hash(addr[:])
+	value := util.PaddingBytesPrefix([]byte{1}, 0, 32)
+	tree, err := NewMerkleTree([]interface{}{[]byte{}, []byte{modulo}, []interface{}{key, value}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestNewMerkleTreeDuplicateKey(t *testing.T) {
+	key := crypto.Sha3Hash([]byte{1})
+	other := crypto.Sha3Hash([]byte{2})
+	value := util.PaddingBytesPrefix([]byte{1}, 0, 32)
+	_, err := NewMerkleTree([]interface{}{[]byte{}, []byte{0}, []interface{}{key, value}, []interface{}{key, value}})
+	if err != ErrDuplicateMerkleKey {
+		t.Fatalf("expected ErrDuplicateMerkleKey, got %v", err)
+	}
+	tree, err := NewMerkleTree([]interface{}{[]byte{}, []byte{0}, []interface{}{key, value}, []interface{}{other, value}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tree.VerifyLeaf(key) || !tree.VerifyLeaf(other) {
+		t.Fatalf("both keys should be leaves of the tree")
+	}
+}
+
+func TestAccountRootsVerifyAddress(t *testing.T) {
+	addrs := [][20]byte{{1}, {2}}
+	roots := &AccountRoots{AccountRoots: make([][]byte, 16)}
+	proofs := make([]MerkleTree, len(addrs))
+	for i, addr := range addrs {
+		proofs[i] = newTestAddressProof(t, byte(i), addr)
+		roots.AccountRoots[i] = proofs[i].RootHash
+	}
+	for i, addr := range addrs {
+		if !roots.VerifyAddress(addr, &proofs[i]) {
+			t.Errorf("address %x should be verified", addr)
+		}
+	}
+	unknown := [20]byte{3}
+	if roots.VerifyAddress(unknown, &proofs[0]) {
+		t.Errorf("address %x is not in the proof", unknown)
+	}
+	unknownProof := newTestAddressProof(t, 2, unknown)
+	if roots.VerifyAddress(unknown, &unknownProof) {
+		t.Errorf("proof root of address %x is not in the account roots", unknown)
+	}
+}
+
+// rparse is the recursive reference of rparseIterative
+func (mt MerkleTreeParser) rparse(proof interface{}, depth int, bits uint64) (interface{}, uint64, []MerkleTreeLeave, error) {
+	val := reflect.ValueOf(proof)
+	kind := val.Kind()
+	if kind != reflect.Slice && kind != reflect.Array {
+		return nil, 0, nil, errWrongTree
+	}
+	if bytVal, ok := val.Interface().([]byte); ok {
+		return bytVal, 0, nil, nil
+	}
+	proofLen := val.Len()
+	if proofLen == 0 {
+		return nil, 0, nil, errWrongTree
+	}
+	// This can be a [prefix, pos | rest] list if and only if
+	// prefix is a byte array of less than 32 bytes
+	leftRaw := val.Index(0).Interface()
+	if bytVal, ok := leftRaw.([]byte); ok {
+		if len(bytVal) < 32 {
+			return mt.parseProof(proof, depth, bits)
+		}
+	}
+	if proofLen != 2 {
+		return nil, 0, nil, errWrongTree
+	}
+
+	depth = depth + 1
+	leftItem, lmodulo, lleaves, err := mt.rparse(leftRaw, depth, setBit(bits, depth, 0))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	rightRaw := val.Index(1).Interface()
+	rightItem, rmodulo, rleaves, err := mt.rparse(rightRaw, depth, setBit(bits, depth, 1))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	tree := [2]bert.Term{
+		leftItem,
+		rightItem,
+	}
+
+	rootHash, err := util.BertHash(tree)
+	modulo := lmodulo + rmodulo
+	leaves := append(lleaves, rleaves...)
+	return rootHash, modulo, leaves, err
+}
+
+func TestRParseIterativeDeepTree(t *testing.T) {
+	key := crypto.Sha3Hash([]byte{1})
+	var tree interface{} = []interface{}{[]byte{}, []byte{3}, []interface{}{key, util.PaddingBytesPrefix([]byte{1}, 0, 32)}}
+	for i := 0; i < 50; i++ {
+		sibling := crypto.Sha256([]byte{byte(i)})
+		if i%2 == 0 {
+			tree = []interface{}{tree, sibling}
+		} else {
+			tree = []interface{}{sibling, tree}
+		}
+	}
+	mtp := MerkleTreeParser{}
+	hash, modulo, leaves, err := mtp.rparseIterative(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhash, rmodulo, rleaves, err := mtp.rparse(tree, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hash.([]byte), rhash.([]byte)) || modulo != rmodulo || len(leaves) != 1 || len(rleaves) != 1 {
+		t.Fatalf("iterative parse differs from the recursive parse")
+	}
+	if _, _, _, err = mtp.rparseIterative([]interface{}{tree, tree, tree}); err != errWrongTree {
+		t.Fatalf("expected errWrongTree but got %v", err)
+	}
+}
@@ -8,6 +8,8 @@ package edge
 type Message struct {
 	Len    int
 	Buffer []byte
+	// Checksum is the first 4 bytes of the sha256 of Buffer, it is set by ReadFrame
+	Checksum [4]byte
 }
 
 // ResponseID returns response identifier of the message
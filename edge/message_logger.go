@@ -0,0 +1,106 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/diodechain/diode_client/util"
+)
+
+const (
+	messageLogBufferSize = 1024
+)
+
+// MessageLogEntry is a single line of the message log
+type MessageLogEntry struct {
+	Timestamp int64  `json:"ts"`
+	Direction string `json:"direction"`
+	Method    string `json:"method"`
+	RequestID uint64 `json:"requestID"`
+	Hex       string `json:"hex"`
+}
+
+// MessageLogger writes rpc messages as newline delimited json,
+// messages are dropped instead of blocking when the buffer is full
+type MessageLogger struct {
+	entries chan MessageLogEntry
+	done    chan struct{}
+	mx      sync.RWMutex
+	closed  bool
+	dropped uint64
+}
+
+// NewMessageLogger returns a message logger that writes to w
+func NewMessageLogger(w io.Writer) *MessageLogger {
+	logger := &MessageLogger{
+		entries: make(chan MessageLogEntry, messageLogBufferSize),
+		done:    make(chan struct{}),
+	}
+	go logger.run(w)
+	return logger
+}
+
+func (logger *MessageLogger) run(w io.Writer) {
+	defer close(logger.done)
+	encoder := json.NewEncoder(w)
+	for entry := range logger.entries {
+		// ignore write errors, the message log is best effort only
+		_ = encoder.Encode(entry)
+	}
+}
+
+func (logger *MessageLogger) log(direction string, method string, requestID uint64, raw []byte) {
+	if logger == nil {
+		return
+	}
+	entry := MessageLogEntry{
+		Timestamp: time.Now().Unix(),
+		Direction: direction,
+		Method:    method,
+		RequestID: requestID,
+		Hex:       util.EncodeToString(raw),
+	}
+	logger.mx.RLock()
+	defer logger.mx.RUnlock()
+	if logger.closed {
+		return
+	}
+	select {
+	case logger.entries <- entry:
+	default:
+		atomic.AddUint64(&logger.dropped, 1)
+	}
+}
+
+// LogOutbound logs a message that is sent to the server
+func (logger *MessageLogger) LogOutbound(method string, requestID uint64, raw []byte) {
+	logger.log("out", method, requestID, raw)
+}
+
+// LogInbound logs a message that is received from the server
+func (logger *MessageLogger) LogInbound(method string, requestID uint64, raw []byte) {
+	logger.log("in", method, requestID, raw)
+}
+
+// Dropped returns the number of messages that were dropped because the buffer was full
+func (logger *MessageLogger) Dropped() uint64 {
+	return atomic.LoadUint64(&logger.dropped)
+}
+
+// Close flushes the pending messages and stops the logger,
+// messages that are logged after Close() are ignored
+func (logger *MessageLogger) Close() {
+	logger.mx.Lock()
+	if !logger.closed {
+		logger.closed = true
+		close(logger.entries)
+	}
+	logger.mx.Unlock()
+	<-logger.done
+}
@@ -0,0 +1,97 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMessageLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.log")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := NewMessageLogger(file)
+	for i := 0; i < 1000; i++ {
+		if i%2 == 0 {
+			logger.LogOutbound("getblockpeak", uint64(i), []byte{byte(i)})
+		} else {
+			logger.LogInbound("getblockpeak", uint64(i), []byte{byte(i)})
+		}
+	}
+	logger.Close()
+	if err = file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry MessageLogEntry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Method != "getblockpeak" {
+			t.Errorf("expected method getblockpeak but got %s", entry.Method)
+		}
+		lines++
+	}
+	if lines != 1000 {
+		t.Errorf("expected 1000 lines but got %d (dropped %d)", lines, logger.Dropped())
+	}
+}
+
+// blockingWriter blocks the writes until release is closed
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+	lines   int
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	if w.lines == 0 {
+		close(w.started)
+	}
+	<-w.release
+	w.lines += bytes.Count(p, []byte("\n"))
+	return len(p), nil
+}
+
+func TestMessageLoggerDropped(t *testing.T) {
+	w := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+	logger := NewMessageLogger(w)
+	// the first message is taken from the buffer and blocks in the writer
+	logger.LogOutbound("getblockpeak", 0, nil)
+	<-w.started
+	for i := 1; i <= messageLogBufferSize+10; i++ {
+		logger.LogInbound("getblockpeak", uint64(i), nil)
+	}
+	if logger.Dropped() != 10 {
+		t.Errorf("expected 10 dropped messages but got %d", logger.Dropped())
+	}
+	close(w.release)
+	logger.Close()
+	if w.lines != messageLogBufferSize+1 {
+		t.Errorf("expected %d lines but got %d", messageLogBufferSize+1, w.lines)
+	}
+
+	// logging after close is ignored
+	logger.LogInbound("getblockpeak", 1, nil)
+	logger.LogOutbound("getblockpeak", 1, nil)
+	logger.Close()
+	if logger.Dropped() != 10 {
+		t.Errorf("expected 10 dropped messages after close but got %d", logger.Dropped())
+	}
+}
@@ -0,0 +1,65 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/diodechain/diode_client/blockquick"
+)
+
+var (
+	ErrInvalidPayloadTarget = fmt.Errorf("payload target must be a non nil pointer to a response payload type")
+)
+
+// ParsedMessage is the untyped result of a response parser
+type ParsedMessage = interface{}
+
+// responsePayloadTypes is the set of types the response parsers return
+var responsePayloadTypes = map[reflect.Type]bool{}
+
+func init() {
+	for _, payload := range []interface{}{
+		uint64(0), "", []uint64(nil), blockquick.BlockHeader{}, DeviceTicket{}, (*DeviceTicket)(nil),
+		(*Account)(nil), (*AccountRoots)(nil), (*AccountValue)(nil), (*StateRoots)(nil), (*ServerObj)(nil),
+		(*PortOpen)(nil), (*PortSend)(nil), (*HealthStatus)(nil), []AccountValueAtBlock(nil), (*Block)(nil),
+		[]EventLog(nil), []AccountRootsAtBlock(nil), (*SendTransactionResult)(nil), false,
+		(*SequencedPortSend)(nil), (*PortClose)(nil), Goodbye{},
+	} {
+		responsePayloadTypes[reflect.TypeOf(payload)] = true
+	}
+}
+
+// PayloadTypeError is returned when the parsed message is not of the expected type
+type PayloadTypeError struct {
+	Expected string
+	Actual   string
+}
+
+func (err PayloadTypeError) Error() string {
+	return fmt.Sprintf("unexpected response payload type %s, expected %s", err.Actual, err.Expected)
+}
+
+// Payload stores the parsed message in payload, which must point to one of the
+// response payload types, rpc errors are returned as they are
+// eg: var header blockquick.BlockHeader; err := edge.Payload(msg, &header)
+func Payload(msg ParsedMessage, payload interface{}) error {
+	target := reflect.ValueOf(payload)
+	if target.Kind() != reflect.Ptr || target.IsNil() || !responsePayloadTypes[target.Type().Elem()] {
+		return fmt.Errorf("%w: %T", ErrInvalidPayloadTarget, payload)
+	}
+	if rpcErr, ok := msg.(Error); ok {
+		return rpcErr
+	}
+	value := reflect.ValueOf(msg)
+	if !value.IsValid() || value.Type() != target.Type().Elem() {
+		return PayloadTypeError{
+			Expected: target.Type().Elem().String(),
+			Actual:   fmt.Sprintf("%T", msg),
+		}
+	}
+	target.Elem().Set(value)
+	return nil
+}
@@ -0,0 +1,87 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/diodechain/diode_client/blockquick"
+)
+
+func TestPayload(t *testing.T) {
+	var msg ParsedMessage = uint64(42)
+	var peak uint64
+	if err := Payload(msg, &peak); err != nil {
+		t.Fatal(err)
+	}
+	if peak != 42 {
+		t.Errorf("expected 42 but got %d", peak)
+	}
+
+	msg = &PortOpen{Ref: "ref"}
+	var portOpen *PortOpen
+	if err := Payload(msg, &portOpen); err != nil {
+		t.Fatal(err)
+	}
+	if portOpen.Ref != "ref" {
+		t.Errorf("expected ref but got %s", portOpen.Ref)
+	}
+}
+
+func TestPayloadWrongType(t *testing.T) {
+	var msg ParsedMessage = uint64(42)
+	var header blockquick.BlockHeader
+	err := Payload(msg, &header)
+	if _, ok := err.(PayloadTypeError); !ok {
+		t.Fatalf("expected PayloadTypeError but got %v", err)
+	}
+
+	msg = Error{Message: "not found"}
+	var account *Account
+	err = Payload(msg, &account)
+	if err == nil || err.Error() != "not found" {
+		t.Errorf("expected rpc error but got %v", err)
+	}
+
+	var unknown int
+	if err = Payload(uint64(42), &unknown); !errors.Is(err, ErrInvalidPayloadTarget) {
+		t.Errorf("expected ErrInvalidPayloadTarget but got %v", err)
+	}
+	if err = Payload(uint64(42), uint64(0)); !errors.Is(err, ErrInvalidPayloadTarget) {
+		t.Errorf("expected ErrInvalidPayloadTarget for a non pointer but got %v", err)
+	}
+}
+
+func TestPayloadRegistry(t *testing.T) {
+	var results []interface{}
+	for _, f := range newTestFixtures(t) {
+		raw, callback := BuildFixture(t, RLPProtocol{}, f)
+		res, err := callback(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", f.Method, err)
+		}
+		results = append(results, res)
+	}
+	deviceID := make([]byte, 20)
+	for _, payload := range [][]interface{}{
+		{"portopen", "tcp:80", "ref", deviceID},
+		{"portsend", "ref", []byte("data")},
+		{"portsend_seq", "ref", uint32(2), []byte("data")},
+		{"portclose", "ref"},
+		{"goodbye", "ticket_expected", "bye"},
+	} {
+		req, err := parseInboundRequest(encodeTestResponse(t, payload...))
+		if err != nil {
+			t.Fatalf("%s: %v", payload[0], err)
+		}
+		results = append(results, req)
+	}
+	for _, res := range results {
+		if !responsePayloadTypes[reflect.TypeOf(res)] {
+			t.Errorf("%T is missing in the response payload types", res)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Flags of the first byte of a port payload
+const (
+	portPayloadRaw        byte = 0x00
+	portPayloadCompressed byte = 0x01
+)
+
+var (
+	ErrInvalidPortPayload = fmt.Errorf("invalid port payload")
+)
+
+// CompressPortPayload gzip compresses data if it has at least threshold bytes,
+// the payload starts with a flag byte that tells whether it is compressed
+func CompressPortPayload(data []byte, threshold int) ([]byte, error) {
+	if len(data) < threshold {
+		raw := make([]byte, 1, len(data)+1)
+		raw[0] = portPayloadRaw
+		return append(raw, data...), nil
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteByte(portPayloadCompressed)
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressPortPayload returns the data of a payload of CompressPortPayload,
+// compressed data may not exceed DefaultMaxMessageSize bytes
+func DecompressPortPayload(raw []byte) ([]byte, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: missing flag", ErrInvalidPortPayload)
+	}
+	switch raw[0] {
+	case portPayloadRaw:
+		return raw[1:], nil
+	case portPayloadCompressed:
+	default:
+		return nil, fmt.Errorf("%w: unknown flag %d", ErrInvalidPortPayload, raw[0])
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(raw[1:]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPortPayload, err)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(reader, DefaultMaxMessageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPortPayload, err)
+	}
+	if len(data) > DefaultMaxMessageSize {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrInvalidPortPayload, DefaultMaxMessageSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,44 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPortPayloadCompression(t *testing.T) {
+	data := &bytes.Buffer{}
+	for i := 0; data.Len() < 10*1024; i++ {
+		fmt.Fprintf(data, "sensor=temperature value=%d\n", 20+i%5)
+	}
+	compressed, err := CompressPortPayload(data.Bytes(), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compressed[0] != portPayloadCompressed || len(compressed) > data.Len()/2 {
+		t.Fatalf("expected compressed payload of at most %d bytes but got %d", data.Len()/2, len(compressed))
+	}
+	decompressed, err := DecompressPortPayload(compressed)
+	if err != nil || !bytes.Equal(decompressed, data.Bytes()) {
+		t.Fatalf("decompressed payload differs %v", err)
+	}
+
+	small := []byte("small")
+	raw, err := CompressPortPayload(small, 1024)
+	if err != nil || raw[0] != portPayloadRaw {
+		t.Fatalf("expected raw payload %v %v", raw, err)
+	}
+	if decompressed, err = DecompressPortPayload(raw); err != nil || !bytes.Equal(decompressed, small) {
+		t.Fatalf("expected %s but got %s %v", small, decompressed, err)
+	}
+
+	for _, invalid := range [][]byte{nil, {0x02}, {portPayloadCompressed, 1, 2, 3}} {
+		if _, err = DecompressPortPayload(invalid); !errors.Is(err, ErrInvalidPortPayload) {
+			t.Errorf("expected ErrInvalidPortPayload for %v but got %v", invalid, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// PortPayloadNonceSize is the nonce size of the port payload encryption
+	PortPayloadNonceSize = 12
+)
+
+var (
+	ErrInvalidCiphertext = fmt.Errorf("invalid port payload ciphertext")
+	errInvalidNonceSize  = fmt.Errorf("port payload nonce must be %d bytes", PortPayloadNonceSize)
+)
+
+// PortPayloadKey derives the key of the port reference from the shared key with
+// HKDF-SHA256, ports that share a key never share a port payload key
+func PortPayloadKey(key [32]byte, ref string) (portKey [32]byte, err error) {
+	kdf := hkdf.New(sha256.New, key[:], nil, []byte(ref))
+	_, err = io.ReadFull(kdf, portKey[:])
+	return
+}
+
+// PortPayloadNonce returns the 96-bit counter nonce of the sequence number
+// Each sequence number must only be used once with the same port payload key
+func PortPayloadNonce(seq uint64) []byte {
+	nonce := make([]byte, PortPayloadNonceSize)
+	binary.BigEndian.PutUint64(nonce[PortPayloadNonceSize-8:], seq)
+	return nonce
+}
+
+func newPortPayloadAEAD(key [32]byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptPortPayload encrypts the port payload with AES-256-GCM
+func EncryptPortPayload(key [32]byte, nonce []byte, plaintext []byte) ([]byte, error) {
+	if len(nonce) != PortPayloadNonceSize {
+		return nil, errInvalidNonceSize
+	}
+	aead, err := newPortPayloadAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, nonce, plaintext, nil), nil
+}
+
+// DecryptPortPayload decrypts and authenticates the port payload with AES-256-GCM
+func DecryptPortPayload(key [32]byte, nonce []byte, ciphertext []byte) ([]byte, error) {
+	if len(nonce) != PortPayloadNonceSize {
+		return nil, errInvalidNonceSize
+	}
+	aead, err := newPortPayloadAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,69 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestPortPayloadEncryption(t *testing.T) {
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, 1000)
+	if _, err := rand.Read(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	key, err := PortPayloadKey(key, "ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := PortPayloadNonce(1)
+	ciphertext, err := EncryptPortPayload(key, nonce, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := DecryptPortPayload(key, nonce, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, decrypted) {
+		t.Fatalf("decrypted payload doesn't match plaintext")
+	}
+	ciphertext[10] ^= 0xff
+	if _, err = DecryptPortPayload(key, nonce, ciphertext); err != ErrInvalidCiphertext {
+		t.Errorf("expected ErrInvalidCiphertext but got %v", err)
+	}
+}
+
+func TestPortPayloadNonce(t *testing.T) {
+	if bytes.Equal(PortPayloadNonce(1), PortPayloadNonce(2)) {
+		t.Errorf("nonce of different sequence numbers should differ")
+	}
+	if !bytes.Equal(PortPayloadNonce(1<<32), []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0}) {
+		t.Errorf("nonce should be the big endian counter, got %x", PortPayloadNonce(1<<32))
+	}
+}
+
+func TestPortPayloadKey(t *testing.T) {
+	var key [32]byte
+	key1, err := PortPayloadKey(key, "ref1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := PortPayloadKey(key, "ref2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key1 == key2 || key1 == key {
+		t.Fatalf("ports should use different keys")
+	}
+	again, err := PortPayloadKey(key, "ref1")
+	if err != nil || again != key1 {
+		t.Fatalf("the port key should be derived deterministically")
+	}
+}
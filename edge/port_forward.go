@@ -0,0 +1,28 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"fmt"
+)
+
+// PortForward forwards the data received on the source port to the destination port,
+// it returns nil when the source port was closed (srcChan was closed), the error of send
+// when the destination port failed and ctx.Err() when the context is done
+func PortForward(ctx context.Context, srcRef uint64, dstRef uint64, srcChan <-chan []byte, send func(ref uint64, data []byte) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case data, ok := <-srcChan:
+			if !ok {
+				return nil
+			}
+			if err := send(dstRef, data); err != nil {
+				return fmt.Errorf("failed to forward port %d to %d: %w", srcRef, dstRef, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestPortForward(t *testing.T) {
+	srcChan := make(chan []byte)
+	var received [][]byte
+	send := func(ref uint64, data []byte) error {
+		if ref != 2 {
+			t.Errorf("expected destination ref 2 but got %d", ref)
+		}
+		received = append(received, data)
+		return nil
+	}
+	done := make(chan error)
+	go func() {
+		done <- PortForward(context.Background(), 1, 2, srcChan, send)
+	}()
+	for i := 0; i < 1000; i++ {
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(i))
+		srcChan <- data
+	}
+	close(srcChan)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1000 {
+		t.Fatalf("expected 1000 messages but got %d", len(received))
+	}
+	for i, data := range received {
+		expected := make([]byte, 8)
+		binary.BigEndian.PutUint64(expected, uint64(i))
+		if !bytes.Equal(data, expected) {
+			t.Fatalf("message %d doesn't match", i)
+		}
+	}
+}
+
+func TestPortForwardClose(t *testing.T) {
+	errClosed := errors.New("port closed")
+	srcChan := make(chan []byte, 1)
+	srcChan <- []byte{1}
+	err := PortForward(context.Background(), 1, 2, srcChan, func(ref uint64, data []byte) error {
+		return errClosed
+	})
+	if !errors.Is(err, errClosed) {
+		t.Errorf("expected destination close error but got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = PortForward(ctx, 1, 2, make(chan []byte), func(ref uint64, data []byte) error {
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled but got %v", err)
+	}
+}
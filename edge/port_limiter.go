@@ -0,0 +1,92 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"sync"
+)
+
+const (
+	// DefaultMaxPortsPerDevice is the default limit of concurrently open ports per device
+	DefaultMaxPortsPerDevice = 256
+	// DefaultMaxTotalPorts is the default limit of concurrently open ports overall
+	DefaultMaxTotalPorts = 4096
+)
+
+// PortLimiter limits the number of concurrently open ports per device and in total
+// A limit of zero or less disables the respective check
+type PortLimiter struct {
+	MaxPortsPerDevice int
+	MaxTotalPorts     int
+
+	mx     sync.Mutex
+	counts map[string]int
+	total  int
+}
+
+// NewPortLimiter returns a port limiter with the given limits
+func NewPortLimiter(maxPortsPerDevice int, maxTotalPorts int) *PortLimiter {
+	return &PortLimiter{
+		MaxPortsPerDevice: maxPortsPerDevice,
+		MaxTotalPorts:     maxTotalPorts,
+		counts:            make(map[string]int),
+	}
+}
+
+// Allow reserves a port for the given device, returns false if any limit is exceeded
+// Every successful Allow() has to be followed by a Release()
+func (pl *PortLimiter) Allow(deviceID string) bool {
+	pl.mx.Lock()
+	defer pl.mx.Unlock()
+	if pl.MaxTotalPorts > 0 && pl.total >= pl.MaxTotalPorts {
+		return false
+	}
+	if pl.MaxPortsPerDevice > 0 && pl.counts[deviceID] >= pl.MaxPortsPerDevice {
+		return false
+	}
+	if pl.counts == nil {
+		pl.counts = make(map[string]int)
+	}
+	pl.counts[deviceID]++
+	pl.total++
+	return true
+}
+
+// Release frees a port of the given device that was reserved with Allow(),
+// the device is forgotten once it has no open ports
+func (pl *PortLimiter) Release(deviceID string) {
+	pl.mx.Lock()
+	defer pl.mx.Unlock()
+	count, ok := pl.counts[deviceID]
+	if !ok {
+		return
+	}
+	if count <= 1 {
+		delete(pl.counts, deviceID)
+	} else {
+		pl.counts[deviceID] = count - 1
+	}
+	pl.total--
+}
+
+// Count returns the number of open ports of the given device
+func (pl *PortLimiter) Count(deviceID string) int {
+	pl.mx.Lock()
+	defer pl.mx.Unlock()
+	return pl.counts[deviceID]
+}
+
+// Total returns the number of open ports of all devices
+func (pl *PortLimiter) Total() int {
+	pl.mx.Lock()
+	defer pl.mx.Unlock()
+	return pl.total
+}
+
+// devices returns the number of devices with open ports
+func (pl *PortLimiter) devices() int {
+	pl.mx.Lock()
+	defer pl.mx.Unlock()
+	return len(pl.counts)
+}
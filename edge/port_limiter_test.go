@@ -0,0 +1,63 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"testing"
+)
+
+func TestPortLimiterPerDevice(t *testing.T) {
+	limiter := NewPortLimiter(3, 0)
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow("device") {
+			t.Fatalf("port %d should be allowed", i)
+		}
+	}
+	if limiter.Allow("device") {
+		t.Fatalf("port should not be allowed after reaching the device limit")
+	}
+	if !limiter.Allow("other") {
+		t.Fatalf("port of other device should be allowed")
+	}
+	limiter.Release("device")
+	if !limiter.Allow("device") {
+		t.Fatalf("port should be allowed after release")
+	}
+	if limiter.Count("device") != 3 {
+		t.Errorf("device count should be 3 but got %d", limiter.Count("device"))
+	}
+}
+
+func TestPortLimiterTotal(t *testing.T) {
+	limiter := NewPortLimiter(0, 2)
+	if !limiter.Allow("a") || !limiter.Allow("b") {
+		t.Fatalf("ports should be allowed")
+	}
+	if limiter.Allow("c") {
+		t.Fatalf("port should not be allowed after reaching the total limit")
+	}
+	limiter.Release("a")
+	if !limiter.Allow("c") {
+		t.Fatalf("port should be allowed after release")
+	}
+	if limiter.Total() != 2 {
+		t.Errorf("total should be 2 but got %d", limiter.Total())
+	}
+}
+
+func TestPortLimiterRelease(t *testing.T) {
+	limiter := NewPortLimiter(1, 1)
+	limiter.Release("unknown")
+	if !limiter.Allow("device") {
+		t.Fatalf("port should be allowed")
+	}
+	limiter.Release("device")
+	limiter.Release("device")
+	if limiter.Total() != 0 {
+		t.Errorf("total should be 0 but got %d", limiter.Total())
+	}
+	if limiter.devices() != 0 {
+		t.Errorf("released device should be forgotten but %d devices are tracked", limiter.devices())
+	}
+}
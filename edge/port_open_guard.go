@@ -0,0 +1,60 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/diodechain/diode_client/contract"
+)
+
+var (
+	ErrDeviceNotAllowlisted = fmt.Errorf("device is not in the fleet allowlist")
+)
+
+// AccountValueQuery returns the storage value of the given account and key,
+// a block number of 0 stands for the latest block
+type AccountValueQuery func(ctx context.Context, blockNumber uint64, account Address, key []byte) ([]byte, error)
+
+// PortOpenGuard checks inbound portopen requests against the fleet allowlist
+type PortOpenGuard struct {
+	query AccountValueQuery
+}
+
+// NewPortOpenGuard returns a port open guard that uses the given query
+// to read the fleet contract storage
+func NewPortOpenGuard(query AccountValueQuery) *PortOpenGuard {
+	return &PortOpenGuard{query: query}
+}
+
+// Allow returns true if the given device is allowlisted in the fleet contract
+func (guard *PortOpenGuard) Allow(ctx context.Context, deviceID []byte, fleetAddr [20]byte) (bool, error) {
+	if len(deviceID) != len(Address{}) {
+		return false, fmt.Errorf("device id must be 20 bytes")
+	}
+	var addr Address
+	copy(addr[:], deviceID)
+	key := contract.DeviceAllowlistKey(addr)
+	raw, err := guard.query(ctx, 0, fleetAddr, key)
+	if err != nil {
+		return false, err
+	}
+	return new(big.Int).SetBytes(raw).Sign() != 0, nil
+}
+
+// Check denies the given portopen request if the requesting device is not allowlisted
+func (guard *PortOpenGuard) Check(ctx context.Context, portOpen *PortOpen, fleetAddr [20]byte) error {
+	allowed, err := guard.Allow(ctx, portOpen.DeviceID[:], fleetAddr)
+	if err == nil && !allowed {
+		err = ErrDeviceNotAllowlisted
+	}
+	if err != nil {
+		portOpen.Ok = false
+		portOpen.Err = err
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/diodechain/diode_client/contract"
+)
+
+func mockAccountValueQuery(device Address, value []byte) AccountValueQuery {
+	return func(ctx context.Context, blockNumber uint64, account Address, key []byte) ([]byte, error) {
+		if blockNumber != 0 {
+			return nil, fmt.Errorf("expected latest block but got %d", blockNumber)
+		}
+		if !bytes.Equal(key, contract.DeviceAllowlistKey(device)) {
+			return nil, fmt.Errorf("unexpected storage key %x", key)
+		}
+		return value, nil
+	}
+}
+
+func TestPortOpenGuardDeny(t *testing.T) {
+	device := Address{1, 2, 3}
+	guard := NewPortOpenGuard(mockAccountValueQuery(device, make([]byte, 32)))
+	portOpen := &PortOpen{DeviceID: device, Ok: true}
+	err := guard.Check(context.Background(), portOpen, Address{9})
+	if err != ErrDeviceNotAllowlisted {
+		t.Fatalf("expected ErrDeviceNotAllowlisted but got %v", err)
+	}
+	if portOpen.Ok {
+		t.Errorf("portopen should be denied")
+	}
+}
+
+func TestPortOpenGuardAllow(t *testing.T) {
+	device := Address{1, 2, 3}
+	value := make([]byte, 32)
+	value[31] = 1
+	guard := NewPortOpenGuard(mockAccountValueQuery(device, value))
+	portOpen := &PortOpen{DeviceID: device, Ok: true}
+	if err := guard.Check(context.Background(), portOpen, Address{9}); err != nil {
+		t.Fatal(err)
+	}
+	if !portOpen.Ok {
+		t.Errorf("portopen should be allowed")
+	}
+}
@@ -0,0 +1,72 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrPortSendQueueClosed = fmt.Errorf("port send queue is closed")
+)
+
+// PortSendQueue is a bounded queue of encoded portsend messages, senders
+// can drop payloads instead of blocking when the connection is congested
+type PortSendQueue struct {
+	requestID func() uint64
+	queue     chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewPortSendQueue returns a queue that holds up to capacity encoded messages,
+// requestID should return a new request id for every message
+func NewPortSendQueue(capacity int, requestID func() uint64) *PortSendQueue {
+	return &PortSendQueue{
+		requestID: requestID,
+		queue:     make(chan []byte, capacity),
+		done:      make(chan struct{}),
+	}
+}
+
+// TrySendPortPayload encodes the portsend message and queues it without blocking,
+// dropped is true if the queue was full and the payload was discarded
+func (psq *PortSendQueue) TrySendPortPayload(ref string, data []byte) (dropped bool, err error) {
+	select {
+	case <-psq.done:
+		err = ErrPortSendQueueClosed
+		return
+	default:
+	}
+	buf := &bytes.Buffer{}
+	if _, err = NewMessage(buf, psq.requestID(), "portsend", ref, data); err != nil {
+		return
+	}
+	select {
+	case psq.queue <- buf.Bytes():
+	default:
+		dropped = true
+	}
+	return
+}
+
+// Messages returns the channel of encoded messages that should be written to the connection
+func (psq *PortSendQueue) Messages() <-chan []byte {
+	return psq.queue
+}
+
+// Done returns a channel that is closed when the queue is closed
+func (psq *PortSendQueue) Done() <-chan struct{} {
+	return psq.done
+}
+
+// Close rejects further payloads with ErrPortSendQueueClosed and stops the
+// readers of Messages that wait on Done
+func (psq *PortSendQueue) Close() {
+	psq.closeOnce.Do(func() {
+		close(psq.done)
+	})
+}
@@ -0,0 +1,64 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"testing"
+)
+
+func TestPortSendQueueTrySend(t *testing.T) {
+	var id uint64
+	queue := NewPortSendQueue(3, func() uint64 {
+		id++
+		return id
+	})
+	for i := 0; i < 3; i++ {
+		dropped, err := queue.TrySendPortPayload("ref1", []byte("data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dropped {
+			t.Fatalf("payload %d should not be dropped", i)
+		}
+	}
+	dropped, err := queue.TrySendPortPayload("ref1", []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dropped {
+		t.Fatalf("payload should be dropped when the queue is full")
+	}
+	msg := <-queue.Messages()
+	if ResponseID(msg) != 1 {
+		t.Errorf("expected request id 1 but got %d", ResponseID(msg))
+	}
+	req, err := parseInboundRequest(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if portSend := req.(*PortSend); portSend.Ref != "ref1" {
+		t.Errorf("expected ref ref1 but got %q", portSend.Ref)
+	}
+	dropped, err = queue.TrySendPortPayload("ref1", []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped {
+		t.Errorf("payload should not be dropped after draining the queue")
+	}
+}
+
+func TestPortSendQueueClose(t *testing.T) {
+	queue := NewPortSendQueue(1, func() uint64 { return 1 })
+	queue.Close()
+	queue.Close()
+	select {
+	case <-queue.Done():
+	default:
+		t.Fatalf("done should be closed")
+	}
+	if _, err := queue.TrySendPortPayload("ref1", []byte("data")); err != ErrPortSendQueueClosed {
+		t.Errorf("expected ErrPortSendQueueClosed but got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/diodechain/diode_client/config"
+)
+
+var (
+	ErrInvalidLeakInterval = fmt.Errorf("leak detector interval must be positive")
+)
+
+// PortInfo describes an open port session
+type PortInfo struct {
+	DeviceID string
+	OpenedAt time.Time
+}
+
+// PortTracker keeps track of the open port sessions, every portopen should
+// be followed by a portclose of the same ref
+type PortTracker struct {
+	mx    sync.Mutex
+	ports map[uint64]PortInfo
+}
+
+// NewPortTracker returns an empty port tracker
+func NewPortTracker() *PortTracker {
+	return &PortTracker{
+		ports: make(map[uint64]PortInfo),
+	}
+}
+
+// Open records that the port ref was opened to deviceID
+func (pt *PortTracker) Open(ref uint64, deviceID string) {
+	pt.mx.Lock()
+	defer pt.mx.Unlock()
+	pt.ports[ref] = PortInfo{
+		DeviceID: deviceID,
+		OpenedAt: time.Now(),
+	}
+}
+
+// Close records that the port ref was closed
+func (pt *PortTracker) Close(ref uint64) {
+	pt.mx.Lock()
+	defer pt.mx.Unlock()
+	delete(pt.ports, ref)
+}
+
+// Count returns the number of open ports
+func (pt *PortTracker) Count() int {
+	pt.mx.Lock()
+	defer pt.mx.Unlock()
+	return len(pt.ports)
+}
+
+// Snapshot returns a copy of the open ports
+func (pt *PortTracker) Snapshot() map[uint64]PortInfo {
+	pt.mx.Lock()
+	defer pt.mx.Unlock()
+	ports := make(map[uint64]PortInfo, len(pt.ports))
+	for ref, info := range pt.ports {
+		ports[ref] = info
+	}
+	return ports
+}
+
+// LeakDetector periodically warns about ports that are open for longer than MaxAge
+type LeakDetector struct {
+	Tracker  *PortTracker
+	MaxAge   time.Duration
+	Interval time.Duration
+	Logger   *config.Logger
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewLeakDetector returns a leak detector for the ports of tracker, it checks
+// every maxAge/2 until Stop is called
+func NewLeakDetector(tracker *PortTracker, maxAge time.Duration, logger *config.Logger) *LeakDetector {
+	return &LeakDetector{
+		Tracker:  tracker,
+		MaxAge:   maxAge,
+		Interval: maxAge / 2,
+		Logger:   logger,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the leak detector in a new goroutine, it returns ErrInvalidLeakInterval
+// if the interval is not positive, e.g. for a maxAge below 2ns
+func (ld *LeakDetector) Start() error {
+	if ld.Interval <= 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidLeakInterval, ld.Interval)
+	}
+	go func() {
+		ticker := time.NewTicker(ld.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				ld.Check(now)
+			case <-ld.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the leak detector goroutine
+func (ld *LeakDetector) Stop() {
+	ld.stopOnce.Do(func() {
+		close(ld.done)
+	})
+}
+
+// Check warns about and returns the refs of the ports that were open for longer than MaxAge at now
+func (ld *LeakDetector) Check(now time.Time) (leaked []uint64) {
+	ports := ld.Tracker.Snapshot()
+	for ref, info := range ports {
+		if now.Sub(info.OpenedAt) > ld.MaxAge {
+			leaked = append(leaked, ref)
+		}
+	}
+	sort.Slice(leaked, func(i, j int) bool { return leaked[i] < leaked[j] })
+	if ld.Logger != nil {
+		for _, ref := range leaked {
+			info := ports[ref]
+			ld.Logger.Warn("Port %d to %s is open for %s, it might have leaked", ref, info.DeviceID, now.Sub(info.OpenedAt))
+		}
+	}
+	return
+}
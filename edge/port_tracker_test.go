@@ -0,0 +1,52 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPortTracker(t *testing.T) {
+	tracker := NewPortTracker()
+	for ref := uint64(1); ref <= 5; ref++ {
+		tracker.Open(ref, fmt.Sprintf("device%d", ref))
+	}
+	for ref := uint64(1); ref <= 3; ref++ {
+		tracker.Close(ref)
+	}
+	if tracker.Count() != 2 {
+		t.Fatalf("expected 2 open ports but got %d", tracker.Count())
+	}
+	snapshot := tracker.Snapshot()
+	if snapshot[4].DeviceID != "device4" || snapshot[5].DeviceID != "device5" {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+
+	detector := NewLeakDetector(tracker, time.Minute, nil)
+	if leaked := detector.Check(time.Now()); len(leaked) != 0 {
+		t.Fatalf("expected no leaked ports but got %v", leaked)
+	}
+	leaked := detector.Check(time.Now().Add(2 * time.Minute))
+	if len(leaked) != 2 || leaked[0] != 4 || leaked[1] != 5 {
+		t.Fatalf("expected ports 4 and 5 to leak but got %v", leaked)
+	}
+}
+
+func TestLeakDetectorStartInterval(t *testing.T) {
+	tracker := NewPortTracker()
+	for _, maxAge := range []time.Duration{0, time.Nanosecond} {
+		detector := NewLeakDetector(tracker, maxAge, nil)
+		if err := detector.Start(); !errors.Is(err, ErrInvalidLeakInterval) {
+			t.Errorf("expected ErrInvalidLeakInterval for max age %v but got %v", maxAge, err)
+		}
+	}
+	detector := NewLeakDetector(tracker, 2*time.Nanosecond, nil)
+	if err := detector.Start(); err != nil {
+		t.Fatal(err)
+	}
+	detector.Stop()
+}
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/diodechain/diode_client/blockquick"
 	"github.com/diodechain/diode_client/config"
@@ -29,6 +31,7 @@ var (
 	portSendPivot     = []byte("portsend")
 	portClosePivot    = []byte("portclose")
 	goodbyePivot      = []byte("goodbye")
+	codeHashKey       = []byte("codeHash")
 	// Maybe remove parse callback and use parse response?
 	blockPivot                 = []byte("getblock")
 	block2Pivot                = []byte("getblock2")
@@ -49,10 +52,24 @@ var (
 	ErrFailedToParseTicket     = fmt.Errorf("failed to parse ticket")
 	ErrResponseHandlerNotFound = fmt.Errorf("couldn't find handler for response")
 	ErrRPCNotSupport           = fmt.Errorf("rpc method not support")
+	ErrWrongNetwork            = fmt.Errorf("server is on the wrong network")
+	ErrTransactionHashMismatch = fmt.Errorf("transaction hash doesn't match")
+	ErrUnknownRequest          = fmt.Errorf("unknown inbound request")
+	ErrCodeHashMismatch        = fmt.Errorf("account code hash doesn't match")
+	ErrCodeHashMissing         = fmt.Errorf("account code hash is not in the state proof")
+	ErrPortOpenDenied          = fmt.Errorf("portopen was denied")
+	ErrInvalidArgType          = fmt.Errorf("invalid argument type")
+	errWrongTransaction        = fmt.Errorf("wrong transaction data")
 )
 
+// ServerNetworkIDKey is the server object extra that advertises the network id
+const ServerNetworkIDKey = "network_id"
+
 // parse response
 func parseResponse(buffer []byte) (interface{}, error) {
+	if err := ValidateFrame(buffer); err != nil {
+		return nil, err
+	}
 	if bytes.Contains(buffer, portOpenPivot) {
 		return parsePortOpenResponse(buffer)
 	} else if bytes.Contains(buffer, portSendPivot) {
@@ -90,9 +107,12 @@ func parseResponse(buffer []byte) (interface{}, error) {
 }
 
 func parseError(buffer []byte) (rpcErr Error, err error) {
+	if ferr := ValidateFrame(buffer); ferr != nil {
+		rpcErr.Message = ferr.Error()
+		return
+	}
 	var response errorResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err = decodeStream.Decode(&response)
+	err = decodeMessage(buffer, &response)
 	if err != nil {
 		rpcErr.Message = err.Error()
 		err = nil
@@ -109,8 +129,10 @@ func parseError(buffer []byte) (rpcErr Error, err error) {
 // parse response of rpc call
 func parseBlockPeakResponse(buffer []byte) (interface{}, error) {
 	var response blockPeakResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -120,62 +142,166 @@ func parseBlockPeakResponse(buffer []byte) (interface{}, error) {
 // TODO: parse block
 func parseBlockResponse(buffer []byte) (interface{}, error) {
 	var response blockResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
-	// response.Payload.Block.Coinbase
-	// response.Payload.Block.Header
-	// response.Payload.Block.Receipts
-	// response.Payload.Block.Transactions
-	return response, nil
+	block := &Block{
+		Coinbase:     response.Payload.Block.Coinbase.Value,
+		Header:       response.Payload.Block.Header.Value,
+		Receipts:     response.Payload.Block.Receipts.Value,
+		Transactions: make([]BlockTransaction, 0, len(response.Payload.Block.Transactions.Value)),
+	}
+	for _, raw := range response.Payload.Block.Transactions.Value {
+		tx, err := parseBlockTransaction(raw)
+		if err != nil {
+			return nil, err
+		}
+		block.Transactions = append(block.Transactions, tx)
+	}
+	return block, nil
+}
+
+// parseBlockTransaction decodes the transaction and verifies its hash
+func parseBlockTransaction(raw []byte) (tx BlockTransaction, err error) {
+	var item transactionItem
+	if err = rlp.DecodeBytes(raw, &item); err != nil {
+		return
+	}
+	if len(item.Hash) != len(tx.Hash) || len(item.From) != len(tx.From) || len(item.To) != len(tx.To) || len(item.Sig) != len(tx.Sig) {
+		err = errWrongTransaction
+		return
+	}
+	copy(tx.Hash[:], item.Hash)
+	copy(tx.From[:], item.From)
+	copy(tx.To[:], item.To)
+	copy(tx.Sig[:], item.Sig)
+	tx.Value = item.Value
+	tx.Nonce = item.Nonce
+	tx.Data = item.Data
+	hash, err := tx.ComputeHash()
+	if err != nil {
+		return
+	}
+	if hash != tx.Hash {
+		err = ErrTransactionHashMismatch
+	}
+	return
 }
 
-// TODO: check error from findItemInItems
 // TODO: use big.Int instead of uint64?
 func parseBlockHeaderResponse(buffer []byte) (interface{}, error) {
 	var response blockHeaderResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 3); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
-	// get value
-	txHash, _ := findItemInItems(response.Payload.Items, "transaction_hash")
-	stateHash, _ := findItemInItems(response.Payload.Items, "state_hash")
-	blockHash, _ := findItemInItems(response.Payload.Items, "block_hash")
-	prevBlock, _ := findItemInItems(response.Payload.Items, "previous_block")
-	nonce, _ := findItemInItems(response.Payload.Items, "nonce")
-	minerSig, _ := findItemInItems(response.Payload.Items, "miner_signature")
-	timestamp, _ := findItemInItems(response.Payload.Items, "timestamp")
-	number, _ := findItemInItems(response.Payload.Items, "number")
+	header, err := decodeBlockHeader(response.Payload.Items, response.Payload.MinerPubkey)
+	if err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// decodeBlockHeader returns the block header of the items and the compressed
+// miner public key, the block hash item must match the hash of the header
+func decodeBlockHeader(headerItems []Item, minerPubkey []byte) (header blockquick.BlockHeader, err error) {
+	fields := [...]string{"transaction_hash", "state_hash", "block_hash", "previous_block", "nonce", "miner_signature", "timestamp", "number"}
+	var items [len(fields)]Item
+	for i, key := range fields {
+		if items[i], err = lookupItem(headerItems, key); err != nil {
+			err = fmt.Errorf("block header missing field %q", key)
+			return
+		}
+	}
+	txHash, stateHash, blockHash, prevBlock := items[0], items[1], items[2], items[3]
+	nonce, minerSig, timestamp, number := items[4], items[5], items[6], items[7]
 	// also can decompress pubkey and marshal to pubkey bytes
-	dminerPubkey := secp256k1.DecompressPubkeyBytes(response.Payload.MinerPubkey)
-	header, err := blockquick.NewHeader(
+	dminerPubkey := secp256k1.DecompressPubkeyBytes(minerPubkey)
+	dtimestamp, err := util.DecodeBytesToUint(timestamp.Value)
+	if err != nil {
+		return
+	}
+	dnumber, err := util.DecodeBytesToUint(number.Value)
+	if err != nil {
+		return
+	}
+	// the difficulty is optional, it's nil if the server doesn't send it
+	var difficulty *big.Int
+	if item, err := lookupItem(headerItems, "difficulty"); err == nil {
+		difficulty = util.DecodeBytesToBigInt(item.Value)
+	}
+	header, err = blockquick.NewHeader(
 		txHash.Value,
 		stateHash.Value,
 		prevBlock.Value,
 		minerSig.Value,
 		dminerPubkey,
-		util.DecodeBytesToUint(timestamp.Value),
-		util.DecodeBytesToUint(number.Value),
+		dtimestamp,
+		dnumber,
 		*util.DecodeBytesToBigInt(nonce.Value),
+		difficulty,
 	)
 	if err != nil {
-		return nil, err
+		return
+	}
+	// the uncle hash is optional, it's zero if the server doesn't send it
+	if uncleHash, lookupErr := lookupItem(headerItems, "uncle_hash"); lookupErr == nil {
+		if len(uncleHash.Value) != len(header.UncleHash) {
+			err = fmt.Errorf("block header uncle_hash must be %d bytes but is %d", len(header.UncleHash), len(uncleHash.Value))
+			return
+		}
+		copy(header.UncleHash[:], uncleHash.Value)
 	}
 	hash := header.Hash()
 	if !bytes.Equal(hash[:], blockHash.Value) {
-		return nil, fmt.Errorf("blockhash != real hash %v %v", blockHash.Value, header)
+		err = fmt.Errorf("blockhash != real hash %v %v", blockHash.Value, header)
 	}
-	return header, nil
+	return
+}
+
+// parseBlockHeaders returns the block headers of the getblockheader2 responses
+// in buffer, every response is prefixed with its two bytes length like on the edge
+// connection. The getblockquick (v1) and getblockquick2 responses only carry the
+// block numbers of the window, the headers are the responses to getblockheader2.
+// decode returns the RLP_V2 message of the message of the protocol version.
+func parseBlockHeaders(buffer []byte, size int, decode func(msg []byte) ([]byte, error)) ([]*blockquick.BlockHeader, error) {
+	r := bytes.NewReader(buffer)
+	var headers []*blockquick.BlockHeader
+	for r.Len() > 0 {
+		if len(headers) >= size {
+			return nil, fmt.Errorf("block headers exceed the window size %d", size)
+		}
+		frame, err := readFrame(r, false)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := decode(frame.Buffer)
+		if err != nil {
+			return nil, err
+		}
+		res, err := parseBlockHeaderResponse(msg)
+		if err != nil {
+			return nil, err
+		}
+		header := res.(blockquick.BlockHeader)
+		headers = append(headers, &header)
+	}
+	return headers, nil
 }
 
 func parseBlockquickResponse(buffer []byte) (interface{}, error) {
 	var response blockquickResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -187,8 +313,10 @@ func parseBlockquickResponse(buffer []byte) (interface{}, error) {
 func parseDeviceTicketResponse(buffer []byte) (interface{}, error) {
 	if bytes.Contains(buffer, ticketThanksPivot) {
 		var response ticketThanksResponse
-		decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-		err := decodeStream.Decode(&response)
+		if err := validatePayloadLength(buffer, 3); err != nil {
+			return nil, err
+		}
+		err := decodeMessage(buffer, &response)
 		if err != nil {
 			return nil, err
 		}
@@ -197,12 +325,15 @@ func parseDeviceTicketResponse(buffer []byte) (interface{}, error) {
 		return ticket, nil
 	} else if bytes.Contains(buffer, ticketTooLowPivot) {
 		var response ticketTooLowResponse
-		decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-		err := decodeStream.Decode(&response)
+		if err := validatePayloadLength(buffer, 7); err != nil {
+			return nil, err
+		}
+		err := decodeMessage(buffer, &response)
 		if err != nil {
 			return nil, err
 		}
-		err = ErrTicketTooLow
+		// a too low ticket can be resubmitted with the counters of the server
+		err = AsRetryable(ErrTicketTooLow, true)
 		ticket := DeviceTicket{
 			BlockHash:        response.Payload.BlockHash,
 			TotalConnections: response.Payload.TotalConnections,
@@ -214,12 +345,14 @@ func parseDeviceTicketResponse(buffer []byte) (interface{}, error) {
 		return ticket, nil
 	} else if bytes.Contains(buffer, ticketTooOldPivot) {
 		var response ticketTooOldResponse
-		decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-		err := decodeStream.Decode(&response)
+		if err := validatePayloadLength(buffer, 3); err != nil {
+			return nil, err
+		}
+		err := decodeMessage(buffer, &response)
 		if err != nil {
 			return nil, err
 		}
-		err = ErrTicketTooOld
+		err = AsRetryable(ErrTicketTooOld, false)
 		ticket := DeviceTicket{
 			Err: err,
 		}
@@ -230,8 +363,10 @@ func parseDeviceTicketResponse(buffer []byte) (interface{}, error) {
 
 func parseDeviceObjectResponse(buffer []byte) (interface{}, error) {
 	var response objectResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		// TODO: Fix this to return proper nil/not found result when the response object is just ""
 		// Currently it just crashes in that case with "rlp: expected input list for struct { Location string; ServerID []uint8; PeakBlock uint64; FleetAddr []uint8; TotalConnections uint64; TotalBytes uint64; LocalAddr []uint8; DeviceSig []uint8; ServerSig []uint8 }, decoding into (edge.objectResponse).Payload.Ticket"
@@ -255,18 +390,57 @@ func parseDeviceObjectResponse(buffer []byte) (interface{}, error) {
 	return deviceObj, nil
 }
 
+// ParseAndVerifyDeviceObject parses the device ticket of a getobject response and checks
+// that the ticket of the given block hash was signed by the server with serverPubkey
+func ParseAndVerifyDeviceObject(buffer []byte, blockHash []byte, serverPubkey []byte) (*DeviceTicket, error) {
+	res, err := parseDeviceObjectResponse(buffer)
+	if err != nil {
+		return nil, err
+	}
+	ticket := res.(*DeviceTicket)
+	ticket.BlockHash = blockHash
+	pubkey, err := ticket.RecoverServerPubKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(serverPubkey) == 33 {
+		serverPubkey = secp256k1.DecompressPubkeyBytes(serverPubkey)
+	}
+	if addr, err := util.PubkeyToAddress(pubkey); err != nil || !bytes.Equal(pubkey, serverPubkey) || addr != ticket.ServerID {
+		return nil, ErrInvalidServerSig
+	}
+	return ticket, nil
+}
+
+// ParseAndValidateDeviceTicket parses the device ticket of a getobject response and
+// returns ErrTicketTooOld if the ticket block number is more than maxAge blocks behind currentPeak
+func ParseAndValidateDeviceTicket(buffer []byte, currentPeak uint64, maxAge uint64) (*DeviceTicket, error) {
+	res, err := parseDeviceObjectResponse(buffer)
+	if err != nil {
+		return nil, err
+	}
+	ticket := res.(*DeviceTicket)
+	if ticket.BlockNumber < currentPeak && currentPeak-ticket.BlockNumber > maxAge {
+		return nil, ErrTicketTooOld
+	}
+	return ticket, nil
+}
+
 // TODO: decode merkle tree from message
 func parseAccountResponse(buffer []byte) (interface{}, error) {
 	var response accountResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 3); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
-	storageRoot, _ := findItemInItems(response.Payload.Items, "storageRoot")
-	nonce, _ := findItemInItems(response.Payload.Items, "nonce")
-	code, _ := findItemInItems(response.Payload.Items, "code")
-	balance, _ := findItemInItems(response.Payload.Items, "balance")
+	storageRoot, _ := lookupItem(response.Payload.Items[:], "storageRoot")
+	nonce, _ := lookupItem(response.Payload.Items[:], "nonce")
+	code, _ := lookupItem(response.Payload.Items[:], "code")
+	balance, _ := lookupItem(response.Payload.Items[:], "balance")
+	accountHash, _ := lookupItem(response.Payload.Items[:], "accountHash")
 	dnonce := util.DecodeBytesToInt(nonce.Value)
 	dbalance := util.DecodeBytesToBigInt(balance.Value)
 	stateTree, err := NewMerkleTree(response.Payload.MerkleProof)
@@ -278,15 +452,43 @@ func parseAccountResponse(buffer []byte) (interface{}, error) {
 		Nonce:       int64(dnonce),
 		Code:        code.Value,
 		Balance:     dbalance,
+		AccountHash: accountHash.Value,
 		stateTree:   stateTree,
 	}
+	if err = account.verifyCodeHash(); err != nil {
+		return nil, err
+	}
 	return account, nil
 }
 
+// parseAccountNonce returns the nonce of a getaccount response without decoding
+// the merkle proof, the nonce is not verified against the state roots
+func parseAccountNonce(buffer []byte) (uint64, error) {
+	var response accountNonceResponse
+	if err := validatePayloadLength(buffer, 3); err != nil {
+		return 0, err
+	}
+	err := decodeMessage(buffer, &response)
+	if err != nil {
+		return 0, err
+	}
+	nonce, err := lookupItem(response.Payload.Items[:], "nonce")
+	if err != nil {
+		return 0, err
+	}
+	return util.DecodeBytesToUint(nonce.Value)
+}
+
+func parseAccountNonceResponse(buffer []byte) (interface{}, error) {
+	return parseAccountNonce(buffer)
+}
+
 func parseAccountRootsResponse(buffer []byte) (interface{}, error) {
 	var response accountRootsResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -298,8 +500,10 @@ func parseAccountRootsResponse(buffer []byte) (interface{}, error) {
 
 func parseAccountValueResponse(buffer []byte) (interface{}, error) {
 	var response accountValueResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -313,10 +517,98 @@ func parseAccountValueResponse(buffer []byte) (interface{}, error) {
 	return accountValue, nil
 }
 
+// parseAccountValueResponseOfKey returns the parser of a getaccountvalue response
+// that keeps the storage key of the request
+func parseAccountValueResponseOfKey(key []byte) func(buffer []byte) (interface{}, error) {
+	return func(buffer []byte) (interface{}, error) {
+		res, err := parseAccountValueResponse(buffer)
+		if err != nil {
+			return nil, err
+		}
+		accountValue := res.(*AccountValue)
+		accountValue.StorageKey = key
+		return accountValue, nil
+	}
+}
+
+func parseAccountValueRangeResponse(buffer []byte) (interface{}, error) {
+	var response accountValueRangeResponse
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]AccountValueAtBlock, len(response.Payload.Values))
+	for i, value := range response.Payload.Values {
+		accountTree, err := NewMerkleTree(value.MerkleProof)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = AccountValueAtBlock{
+			BlockNumber: value.BlockNumber,
+			Value:       &AccountValue{accountTree: accountTree},
+		}
+	}
+	return values, nil
+}
+
+func parseAccountRootsRangeResponse(buffer []byte) (interface{}, error) {
+	var response accountRootsRangeResponse
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]AccountRootsAtBlock, len(response.Payload.Roots))
+	for i, root := range response.Payload.Roots {
+		roots[i] = AccountRootsAtBlock{
+			BlockNumber: root.BlockNumber,
+			Roots:       &AccountRoots{AccountRoots: root.AccountRoots},
+		}
+	}
+	return roots, nil
+}
+
+// parseLogsResponse returns the []EventLog of a getlogs response
+func parseLogsResponse(buffer []byte) (interface{}, error) {
+	var response logsResponse
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
+	if err != nil {
+		return nil, err
+	}
+	logs := make([]EventLog, len(response.Payload.Logs))
+	for i, log := range response.Payload.Logs {
+		if len(log.Address) != 20 || len(log.TxHash) != 32 {
+			return nil, fmt.Errorf("getlogs entry %d has invalid address or transaction hash", i)
+		}
+		copy(logs[i].Address[:], log.Address)
+		copy(logs[i].TxHash[:], log.TxHash)
+		logs[i].Topics = make([][32]byte, len(log.Topics))
+		for j, topic := range log.Topics {
+			if len(topic) != 32 {
+				return nil, fmt.Errorf("getlogs entry %d topic must be 32 bytes but is %d", i, len(topic))
+			}
+			copy(logs[i].Topics[j][:], topic)
+		}
+		logs[i].Data = log.Data
+		logs[i].BlockNumber = log.BlockNumber
+	}
+	return logs, nil
+}
+
 func parsePortSendResponse(buffer []byte) (interface{}, error) {
 	var response portSendResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -326,10 +618,44 @@ func parsePortSendResponse(buffer []byte) (interface{}, error) {
 	return portSend, nil
 }
 
+// parseSetFleetResponse returns true if the server accepted the fleet change
+func parseSetFleetResponse(buffer []byte) (interface{}, error) {
+	var response setFleetResponse
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Payload.Result == "ok", nil
+}
+
+// parsePortSendAckResponse returns the ref and sequence echoed by the server
+func parsePortSendAckResponse(buffer []byte) (interface{}, error) {
+	var response portSendAckResponse
+	if err := validatePayloadLength(buffer, 3); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
+	if err != nil {
+		return nil, err
+	}
+	portSend := &PortSend{
+		Ref:    response.Payload.Ref,
+		Seq:    response.Payload.Seq,
+		HasSeq: true,
+		Ok:     true,
+	}
+	return portSend, nil
+}
+
 func parsePortOpenResponse(buffer []byte) (interface{}, error) {
 	var response portOpenResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 3); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -337,6 +663,29 @@ func parsePortOpenResponse(buffer []byte) (interface{}, error) {
 		Ref: response.Payload.Ref,
 		Ok:  (response.Payload.Result == "ok"),
 	}
+	if !portOpen.Ok {
+		// the denied portopen is returned with the error to inspect the result
+		portOpen.Err = fmt.Errorf("%w: %s", ErrPortOpenDenied, response.Payload.Result)
+		return portOpen, portOpen.Err
+	}
+	return portOpen, nil
+}
+
+// ParsePortOpenReply parses the response of a device to an inbound portopen request
+func ParsePortOpenReply(buffer []byte) (*PortOpen, error) {
+	var reply portOpenReply
+	err := decodeMessage(buffer, &reply)
+	if err != nil {
+		return nil, err
+	}
+	portOpen := &PortOpen{
+		RequestID: reply.RequestID,
+		Ref:       reply.Payload.Ref,
+		Ok:        (reply.Payload.Result == "ok"),
+	}
+	if !portOpen.Ok {
+		portOpen.Err = fmt.Errorf("portopen %s: %s", reply.Payload.Result, strings.Join(reply.Payload.Reason, " "))
+	}
 	return portOpen, nil
 }
 
@@ -346,8 +695,10 @@ func parseServerObjResponse(buffer []byte) (interface{}, error) {
 
 func doParseServerObjResponse(buffer []byte) (obj *ServerObj, err error) {
 	var response serverObjectResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	if err = decodeStream.Decode(&response); err != nil {
+	if err = validatePayloadLength(buffer, 2); err != nil {
+		return
+	}
+	if err = decodeMessage(buffer, &response); err != nil {
 		return
 	}
 	data := response.Payload.ServerObject
@@ -389,6 +740,9 @@ func doParseServerObjResponse(buffer []byte) (obj *ServerObj, err error) {
 			obj.Extra[string(slice[0].([]byte))] = value
 			tuples[i] = [2]bert.Term{slice[0].([]byte), value}
 		}
+		if networkID, ok := obj.Extra[ServerNetworkIDKey]; ok && networkID.IsUint64() {
+			obj.NetworkID = networkID.Uint64()
+		}
 
 		bertdata, err = bert.Encode([5]bert.Term{
 			obj.Host,
@@ -429,8 +783,10 @@ func parseBigUint(data []byte) (num big.Int) {
 // TODO: check error from jsonparser
 func parseStateRootsResponse(buffer []byte) (interface{}, error) {
 	var response stateRootsResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -442,29 +798,80 @@ func parseStateRootsResponse(buffer []byte) (interface{}, error) {
 
 func parseTransactionResponse(buffer []byte) (interface{}, error) {
 	var response transactionResponse
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&response)
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
 	if err != nil {
 		return nil, err
 	}
 	return response.Payload.Result, nil
 }
 
+// parseSendTransactionResponse returns the *SendTransactionResult of the
+// sendtransaction response, the result string of servers that don't send the
+// transaction hash is returned as is
+func parseSendTransactionResponse(buffer []byte) (interface{}, error) {
+	if validatePayloadLength(buffer, 2) == nil {
+		return parseTransactionResponse(buffer)
+	}
+	var response sendTransactionResponse
+	if err := validatePayloadLength(buffer, 3); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Payload.TxHash) != 32 {
+		return nil, fmt.Errorf("sendtransaction hash must be 32 bytes but is %d", len(response.Payload.TxHash))
+	}
+	result := &SendTransactionResult{Status: response.Payload.Status}
+	copy(result.TxHash[:], response.Payload.TxHash)
+	return result, nil
+}
+
+func parseHealthCheckResponse(buffer []byte) (interface{}, error) {
+	var response healthCheckResponse
+	if err := validatePayloadLength(buffer, 5); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &response)
+	if err != nil {
+		return nil, err
+	}
+	status := &HealthStatus{
+		CPUPercent:      float64(response.Payload.CPUUsage) / 100,
+		MemPercent:      float64(response.Payload.MemUsage) / 100,
+		OpenConnections: int(response.Payload.OpenConnections),
+		UptimeSeconds:   response.Payload.UptimeSeconds,
+	}
+	return status, nil
+}
+
 // parse inbound request
 func parseInboundPortOpenRequest(buffer []byte) (interface{}, error) {
 	var inboundRequest portOpenInboundRequest
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&inboundRequest)
+	if err := validatePayloadLength(buffer, 4); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &inboundRequest)
 	if err != nil {
 		return nil, err
 	}
 
 	portOpen := &PortOpen{
-		RequestID: inboundRequest.RequestID,
-		Ref:       inboundRequest.Payload.Ref,
-		Ok:        true,
+		RequestID:   inboundRequest.RequestID,
+		Ref:         inboundRequest.Payload.Ref,
+		RawDeviceID: inboundRequest.Payload.DeviceID,
+		Ok:          true,
+	}
+	if portOpen.DeviceID, err = portOpen.DeviceAddrBytes(); err != nil {
+		// the request is answered with the error
+		portOpen.Ok = false
+		portOpen.Err = err
+		return portOpen, nil
 	}
-	copy(portOpen.DeviceID[:], inboundRequest.Payload.DeviceID)
 	port := inboundRequest.Payload.Port
 
 	// Version 1 (before udp support)
@@ -505,8 +912,12 @@ func parseInboundPortOpenRequest(buffer []byte) (interface{}, error) {
 
 func parseInboundPortSendRequest(buffer []byte) (interface{}, error) {
 	var inboundRequest portSendInboundRequest
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&inboundRequest)
+	if err := validatePayloadLength(buffer, 3); err != nil {
+		if fieldErr, ok := err.(ErrUnexpectedFieldCount); !ok || fieldErr.Actual != 4 {
+			return nil, err
+		}
+	}
+	err := decodeMessage(buffer, &inboundRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -515,19 +926,48 @@ func parseInboundPortSendRequest(buffer []byte) (interface{}, error) {
 		Data: inboundRequest.Payload.Data,
 		Ok:   true,
 	}
+	if len(inboundRequest.Payload.Seq) > 0 {
+		portSend.Seq = inboundRequest.Payload.Seq[0]
+		portSend.HasSeq = true
+	}
+	return portSend, nil
+}
+
+func parseInboundPortSendSeqRequest(buffer []byte) (interface{}, error) {
+	var inboundRequest portSendSeqInboundRequest
+	if err := validatePayloadLength(buffer, 4); err != nil {
+		return nil, err
+	}
+	err := decodeMessage(buffer, &inboundRequest)
+	if err != nil {
+		return nil, err
+	}
+	portSend := &SequencedPortSend{
+		Seq:  inboundRequest.Payload.Seq,
+		Ref:  inboundRequest.Payload.Ref,
+		Data: inboundRequest.Payload.Data,
+	}
 	return portSend, nil
 }
 
 func parseInboundPortCloseRequest(buffer []byte) (interface{}, error) {
 	var inboundRequest portCloseInboundRequest
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&inboundRequest)
+	if err := validatePayloadLength(buffer, 2); err != nil {
+		if fieldErr, ok := err.(ErrUnexpectedFieldCount); !ok || fieldErr.Actual != 3 {
+			return nil, err
+		}
+	}
+	err := decodeMessage(buffer, &inboundRequest)
 	if err != nil {
 		return nil, err
 	}
 	portClose := &PortClose{
-		Ref: inboundRequest.Payload.Ref,
-		Ok:  true,
+		Ref:    inboundRequest.Payload.Ref,
+		Reason: ReasonNormal,
+		Ok:     true,
+	}
+	if len(inboundRequest.Payload.Reason) > 0 {
+		portClose.Reason = inboundRequest.Payload.Reason[0]
 	}
 	return portClose, nil
 }
@@ -535,11 +975,14 @@ func parseInboundPortCloseRequest(buffer []byte) (interface{}, error) {
 // TODO: should test it
 func parseInboundGoodbyeRequest(buffer []byte) (interface{}, error) {
 	var inboundRequest goodbyeInboundRequest
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	err := decodeStream.Decode(&inboundRequest)
 	goodbye := Goodbye{
 		Reason: "unknown reason",
 	}
+	if err := validatePayloadLength(buffer, 3); err != nil {
+		goodbye.Reason = err.Error()
+		return goodbye, nil
+	}
+	err := decodeMessage(buffer, &inboundRequest)
 	if err != nil {
 		goodbye.Reason = err.Error()
 		return goodbye, nil
@@ -550,13 +993,23 @@ func parseInboundGoodbyeRequest(buffer []byte) (interface{}, error) {
 }
 
 func parseInboundRequest(buffer []byte) (req interface{}, err error) {
-	if bytes.Contains(buffer, portOpenPivot) {
+	if err = ValidateFrame(buffer); err != nil {
+		return
+	}
+	var request inboundRequestMethod
+	if err = decodeMessage(buffer, &request); err != nil {
+		return
+	}
+	switch request.Payload.Method {
+	case "portopen":
 		return parseInboundPortOpenRequest(buffer)
-	} else if bytes.Contains(buffer, portSendPivot) {
+	case "portsend_seq":
+		return parseInboundPortSendSeqRequest(buffer)
+	case "portsend":
 		return parseInboundPortSendRequest(buffer)
-	} else if bytes.Contains(buffer, portClosePivot) {
+	case "portclose":
 		return parseInboundPortCloseRequest(buffer)
-	} else if bytes.Contains(buffer, goodbyePivot) {
+	case "goodbye":
 		return parseInboundGoodbyeRequest(buffer)
 	}
 	return
@@ -572,8 +1025,7 @@ func IsErrorType(rawData []byte) bool {
 
 func ResponseID(buffer []byte) uint64 {
 	var response responseID
-	decodeStream := rlp.NewStream(bytes.NewReader(buffer), 0)
-	decodeStream.Decode(&response)
+	decodeMessage(buffer, &response)
 	return response.RequestID
 }
 
@@ -588,6 +1040,9 @@ func NewMerkleTree(rawTree []interface{}) (mt MerkleTree, err error) {
 	if err != nil {
 		return
 	}
+	if err = checkDuplicateKeys(leaves); err != nil {
+		return
+	}
 	mt.RootHash = rootHash
 	mt.Modulo = modulo
 	mt.Leaves = leaves
@@ -599,15 +1054,262 @@ func NewErrorResponse(err error) (rpcErr Error) {
 	return
 }
 
+// NewStructuredErrorResponse encodes the error response of the given method and
+// returns the message together with its decoded view
+func NewStructuredErrorResponse(requestID uint64, method string, err error) (msg Message, errMsg ErrorMessage, rerr error) {
+	response := errorResponse{
+		RequestID: requestID,
+		Payload:   []string{"error", method, err.Error()},
+	}
+	buf := &bytes.Buffer{}
+	if rerr = rlp.Encode(buf, response); rerr != nil {
+		return
+	}
+	msg = Message{Len: buf.Len(), Buffer: buf.Bytes()}
+	errMsg, rerr = parseErrorMessage(msg.Buffer)
+	if rerr == nil && (errMsg.Method != method || errMsg.Description != err.Error()) {
+		rerr = fmt.Errorf("error response doesn't match after encoding: %+v", errMsg)
+	}
+	return
+}
+
+func parseErrorMessage(buffer []byte) (errMsg ErrorMessage, err error) {
+	if err = ValidateFrame(buffer); err != nil {
+		return
+	}
+	var response errorResponse
+	if err = decodeMessage(buffer, &response); err != nil {
+		return
+	}
+	if len(response.Payload) != 3 {
+		err = fmt.Errorf("error response should have 3 fields but has %d", len(response.Payload))
+		return
+	}
+	errMsg.Method = response.Payload[1]
+	errMsg.Description = response.Payload[2]
+	return
+}
+
+// ValidatePortOpenArgs checks the arguments of a portopen request
+func ValidatePortOpenArgs(deviceID []byte, port uint64, mode string) error {
+	if len(deviceID) != 20 {
+		return fmt.Errorf("portopen device id must be 20 bytes but is %d bytes", len(deviceID))
+	}
+	if port == 0 || port >= 65536 {
+		return fmt.Errorf("portopen port must be between 1 and 65535 but is %d", port)
+	}
+	if len(mode) == 0 || len(mode) > 3 {
+		return fmt.Errorf("portopen mode must be a combination of 'r', 'w' and 's' but is '%s'", mode)
+	}
+	for i, c := range mode {
+		if !strings.ContainsRune("rws", c) || strings.ContainsRune(mode[:i], c) {
+			return fmt.Errorf("portopen mode must be a combination of 'r', 'w' and 's' but is '%s'", mode)
+		}
+	}
+	return nil
+}
+
+// NormalizePortMode drops the repeated letters of the portopen mode, e.g. "rrw"
+// becomes "rw", so that modes that used to be accepted pass ValidatePortOpenArgs
+func NormalizePortMode(mode string) string {
+	var normalized strings.Builder
+	for _, c := range mode {
+		if !strings.ContainsRune(normalized.String(), c) {
+			normalized.WriteRune(c)
+		}
+	}
+	return normalized.String()
+}
+
+// parsePortName returns the port number of the portopen port name,
+// either the 1-2 bytes binary port (version 1) or '<protocol>:<port>' (version 2)
+func parsePortName(portName string) (port uint64, err error) {
+	if len(portName) == 0 {
+		return 0, fmt.Errorf("portopen port name is empty")
+	}
+	if len(portName) <= 2 {
+		for _, b := range []byte(portName) {
+			port = port*256 + uint64(b)
+		}
+		return
+	}
+	idx := strings.Index(portName, ":")
+	if idx < 0 {
+		return 0, fmt.Errorf("not supported port format: %v", portName)
+	}
+	return strconv.ParseUint(portName[idx+1:], 10, 64)
+}
+
+// argKind is the type of a request argument
+type argKind struct {
+	name  string
+	check func(arg interface{}) bool
+}
+
+var (
+	uint64Arg = argKind{"uint64", func(arg interface{}) bool { _, ok := arg.(uint64); return ok }}
+	uint32Arg = argKind{"uint32", func(arg interface{}) bool { _, ok := arg.(uint32); return ok }}
+	stringArg = argKind{"string", func(arg interface{}) bool { _, ok := arg.(string); return ok }}
+	bytesArg  = argKind{"[]byte", func(arg interface{}) bool { _, ok := arg.([]byte); return ok }}
+)
+
+// validateArgKinds returns the validator of the arguments of method, the first
+// required arguments must be given and the others are optional
+func validateArgKinds(method string, required int, kinds ...argKind) func(args []interface{}) error {
+	return func(args []interface{}) error {
+		if len(args) < required || len(args) > len(kinds) {
+			if required == len(kinds) {
+				return fmt.Errorf("%w: %s expects %d arguments but got %d", ErrInvalidArgType, method, required, len(args))
+			}
+			return fmt.Errorf("%w: %s expects %d to %d arguments but got %d", ErrInvalidArgType, method, required, len(kinds), len(args))
+		}
+		for i, arg := range args {
+			if !kinds[i].check(arg) {
+				return fmt.Errorf("%w: %s argument %d must be %s but is %T", ErrInvalidArgType, method, i, kinds[i].name, arg)
+			}
+		}
+		return nil
+	}
+}
+
+// messageArgValidators are the validators of the request arguments of each method
+var messageArgValidators = map[string]func(args []interface{}) error{
+	"hello":                 validateArgKinds("hello", 1, uint64Arg),
+	"goodbye":               validateArgKinds("goodbye", 1, stringArg, stringArg),
+	"portopen":              validatePortOpenMessage,
+	"portsend":              validateArgKinds("portsend", 2, stringArg, bytesArg),
+	"portsend_seq":          validateArgKinds("portsend_seq", 3, stringArg, uint32Arg, bytesArg),
+	"portsend_ack":          validateArgKinds("portsend_ack", 3, stringArg, uint32Arg, bytesArg),
+	"portclose":             validateArgKinds("portclose", 1, stringArg, stringArg),
+	"getblock":              validateArgKinds("getblock", 1, uint64Arg),
+	"getblockpeak":          validateArgKinds("getblockpeak", 0),
+	"getblockheader2":       validateArgKinds("getblockheader2", 1, uint64Arg),
+	"getblockquick":         validateArgKinds("getblockquick", 2, uint64Arg, uint64Arg),
+	"getblockquick2":        validateArgKinds("getblockquick2", 2, uint64Arg, uint64Arg),
+	"getblockquick_since":   validateBlockquickSinceMessage,
+	"getaccount":            validateArgKinds("getaccount", 2, uint64Arg, bytesArg),
+	"getaccountnonce":       validateArgKinds("getaccountnonce", 2, uint64Arg, bytesArg),
+	"getaccountroots":       validateArgKinds("getaccountroots", 2, uint64Arg, bytesArg),
+	"getaccountvalue":       validateArgKinds("getaccountvalue", 3, uint64Arg, bytesArg, bytesArg),
+	"getaccountvaluerange":  validateArgKinds("getaccountvaluerange", 4, uint64Arg, uint64Arg, bytesArg, bytesArg),
+	"getaccountroots_range": validateArgKinds("getaccountroots_range", 3, uint64Arg, uint64Arg, bytesArg),
+	"getlogs":               validateGetLogsMessage,
+	"ticket":                validateArgKinds("ticket", 6, uint64Arg, bytesArg, uint64Arg, uint64Arg, bytesArg, bytesArg),
+	"setfleet":              validateSetFleetMessage,
+	"getobject":             validateArgKinds("getobject", 1, bytesArg),
+	"getnode":               validateArgKinds("getnode", 1, bytesArg),
+	"getstateroots":         validateArgKinds("getstateroots", 1, uint64Arg),
+	"sendtransaction":       validateArgKinds("sendtransaction", 1, bytesArg),
+	"healthcheck":           validateArgKinds("healthcheck", 0),
+}
+
+// validateBlockquickSinceMessage checks the lastValid uint64, windowSize uint64
+// and sinceHash [32]byte arguments of getblockquick_since
+func validateBlockquickSinceMessage(args []interface{}) error {
+	_, err := newBlockquickSinceRequest(0, args)
+	return err
+}
+
+func validatePortOpenMessage(args []interface{}) error {
+	if len(args) != 3 {
+		return fmt.Errorf("%w: portopen expects 3 arguments but got %d", ErrInvalidArgType, len(args))
+	}
+	deviceID, ok := args[0].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: portopen device id must be []byte but is %T", ErrInvalidArgType, args[0])
+	}
+	portName, ok := args[1].(string)
+	if !ok {
+		return fmt.Errorf("%w: portopen port must be string but is %T", ErrInvalidArgType, args[1])
+	}
+	mode, ok := args[2].(string)
+	if !ok {
+		return fmt.Errorf("%w: portopen mode must be string but is %T", ErrInvalidArgType, args[2])
+	}
+	port, err := parsePortName(portName)
+	if err != nil {
+		return err
+	}
+	return ValidatePortOpenArgs(deviceID, port, mode)
+}
+
+func validateSetFleetMessage(args []interface{}) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: setfleet expects 1 argument but got %d", ErrInvalidArgType, len(args))
+	}
+	fleetAddr, ok := args[0].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: setfleet fleet address must be []byte but is %T", ErrInvalidArgType, args[0])
+	}
+	if len(fleetAddr) != 20 {
+		return fmt.Errorf("setfleet fleet address must be 20 bytes but is %d", len(fleetAddr))
+	}
+	return nil
+}
+
+// validateGetLogsMessage checks the fromBlock uint64, toBlock uint64, address []byte
+// and topics [][]byte arguments of getlogs
+func validateGetLogsMessage(args []interface{}) error {
+	if len(args) != 4 {
+		return fmt.Errorf("%w: getlogs expects 4 arguments but got %d", ErrInvalidArgType, len(args))
+	}
+	fromBlock, ok := args[0].(uint64)
+	if !ok {
+		return fmt.Errorf("%w: getlogs from block must be uint64 but is %T", ErrInvalidArgType, args[0])
+	}
+	toBlock, ok := args[1].(uint64)
+	if !ok {
+		return fmt.Errorf("%w: getlogs to block must be uint64 but is %T", ErrInvalidArgType, args[1])
+	}
+	if fromBlock > toBlock {
+		return fmt.Errorf("getlogs from block %d is after to block %d", fromBlock, toBlock)
+	}
+	address, ok := args[2].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: getlogs address must be []byte but is %T", ErrInvalidArgType, args[2])
+	}
+	if len(address) != 20 {
+		return fmt.Errorf("getlogs address must be 20 bytes but is %d", len(address))
+	}
+	topics, ok := args[3].([][]byte)
+	if !ok {
+		return fmt.Errorf("%w: getlogs topics must be [][]byte but is %T", ErrInvalidArgType, args[3])
+	}
+	for _, topic := range topics {
+		if len(topic) != 32 {
+			return fmt.Errorf("getlogs topic must be 32 bytes but is %d", len(topic))
+		}
+	}
+	return nil
+}
+
 func NewMessage(writer io.Writer, requestID uint64, method string, args ...interface{}) (func(buffer []byte) (interface{}, error), error) {
-	request := generalRequest{}
-	request.RequestID = requestID
-	request.Payload = make([]interface{}, len(args)+1)
-	request.Payload[0] = []byte(method)
-	for i, arg := range args {
-		request.Payload[i+1] = arg
+	if validate, ok := messageArgValidators[method]; ok {
+		if err := validate(args); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if method == "getblockquick_since" {
+		var request blockquickSinceRequest
+		if request, err = newBlockquickSinceRequest(requestID, args); err != nil {
+			return nil, err
+		}
+		err = rlp.Encode(writer, request)
+	} else {
+		request := generalRequest{}
+		request.RequestID = requestID
+		request.Payload = make([]interface{}, len(args)+1)
+		request.Payload[0] = []byte(method)
+		if method == "getaccountnonce" {
+			// the nonce is read from the getaccount response
+			request.Payload[0] = []byte("getaccount")
+		}
+		for i, arg := range args {
+			request.Payload[i+1] = arg
+		}
+		err = rlp.Encode(writer, request)
 	}
-	err := rlp.Encode(writer, request)
 	if err != nil {
 		return nil, err
 	}
@@ -617,26 +1319,45 @@ func NewMessage(writer io.Writer, requestID uint64, method string, args ...inter
 		return nil, nil
 	case "portclose":
 		return nil, nil
+	case "goodbye":
+		return nil, nil
 	case "getblock":
 		return parseBlockResponse, nil
 	case "getblockpeak":
 		return parseBlockPeakResponse, nil
 	case "getblockheader2":
 		return parseBlockHeaderResponse, nil
-	case "getblockquick2":
+	case "getblockquick", "getblockquick2", "getblockquick_since":
 		return parseBlockquickResponse, nil
 	case "getaccount":
 		return parseAccountResponse, nil
+	case "getaccountnonce":
+		return parseAccountNonceResponse, nil
 	case "getaccountroots":
 		return parseAccountRootsResponse, nil
 	case "getaccountvalue":
+		if len(args) == 3 {
+			if key, ok := args[2].([]byte); ok {
+				return parseAccountValueResponseOfKey(cloneBytes(util.PaddingBytesPrefix(key, 0, 32))), nil
+			}
+		}
 		return parseAccountValueResponse, nil
+	case "getaccountvaluerange":
+		return parseAccountValueRangeResponse, nil
+	case "getaccountroots_range":
+		return parseAccountRootsRangeResponse, nil
+	case "getlogs":
+		return parseLogsResponse, nil
 	case "ticket":
 		return parseDeviceTicketResponse, nil
 	case "portopen":
 		return parsePortOpenResponse, nil
-	case "portsend":
+	case "portsend", "portsend_seq":
 		return parsePortSendResponse, nil
+	case "portsend_ack":
+		return parsePortSendAckResponse, nil
+	case "setfleet":
+		return parseSetFleetResponse, nil
 	case "getobject":
 		return parseDeviceObjectResponse, nil
 	case "getnode":
@@ -644,7 +1365,9 @@ func NewMessage(writer io.Writer, requestID uint64, method string, args ...inter
 	case "getstateroots":
 		return parseStateRootsResponse, nil
 	case "sendtransaction":
-		return parseTransactionResponse, nil
+		return parseSendTransactionResponse, nil
+	case "healthcheck":
+		return parseHealthCheckResponse, nil
 	default:
 		return nil, ErrRPCNotSupport
 	}
@@ -664,6 +1387,21 @@ func NewResponseMessage(writer io.Writer, requestID uint64, responseType string,
 		request.Payload[0] = responseType
 	case "portsend":
 	case "portclose":
+	case "portsend_ack":
+		request.Payload[0] = responseType
+	case "getblockpeak", "getstateroots", "getaccountroots", "getblockheader2", "getaccount",
+		"getaccountvalue", "getobject", "getnode", "ticket", "getblock":
+		// the arguments are the response fields
+		request.Payload[0] = responseType
+	case "getblockquick2":
+		request.Payload[0] = responseType
+		if len(args) > 0 {
+			blockNumbers, err := blockquickNumbers(args[0])
+			if err != nil {
+				return nil, err
+			}
+			request.Payload[1] = blockNumbers
+		}
 	default:
 		return nil, ErrRPCNotSupport
 	}
@@ -673,3 +1411,59 @@ func NewResponseMessage(writer io.Writer, requestID uint64, responseType string,
 	}
 	return nil, nil
 }
+
+// newBlockquickSinceRequest returns the getblockquick_since request of the arguments
+// lastValid uint64, windowSize uint64 and sinceHash [32]byte
+func newBlockquickSinceRequest(requestID uint64, args []interface{}) (request blockquickSinceRequest, err error) {
+	if len(args) != 3 {
+		err = fmt.Errorf("%w: getblockquick_since expects 3 arguments but got %d", ErrInvalidArgType, len(args))
+		return
+	}
+	var ok bool
+	if request.Payload.LastValid, ok = args[0].(uint64); !ok {
+		err = fmt.Errorf("%w: getblockquick_since last valid must be uint64 but is %T", ErrInvalidArgType, args[0])
+		return
+	}
+	if request.Payload.WindowSize, ok = args[1].(uint64); !ok {
+		err = fmt.Errorf("%w: getblockquick_since window size must be uint64 but is %T", ErrInvalidArgType, args[1])
+		return
+	}
+	if request.Payload.SinceHash, ok = args[2].([32]byte); !ok {
+		err = fmt.Errorf("%w: getblockquick_since hash must be [32]byte but is %T", ErrInvalidArgType, args[2])
+		return
+	}
+	request.RequestID = requestID
+	request.Payload.Method = "getblockquick_since"
+	return
+}
+
+// blockquickNumbers returns the block numbers of a getblockquick2 response argument
+func blockquickNumbers(arg interface{}) ([]uint64, error) {
+	switch headers := arg.(type) {
+	case []uint64:
+		return headers, nil
+	case []blockquick.BlockHeader:
+		blockNumbers := make([]uint64, len(headers))
+		for i, header := range headers {
+			blockNumbers[i] = header.Number()
+		}
+		return blockNumbers, nil
+	case []*blockquick.BlockHeader:
+		blockNumbers := make([]uint64, len(headers))
+		for i, header := range headers {
+			blockNumbers[i] = header.Number()
+		}
+		return blockNumbers, nil
+	case [][]*blockquick.BlockHeader:
+		// the block lists are concatenated in order
+		var blockNumbers []uint64
+		for _, list := range headers {
+			for _, header := range list {
+				blockNumbers = append(blockNumbers, header.Number())
+			}
+		}
+		return blockNumbers, nil
+	default:
+		return nil, fmt.Errorf("%w: getblockquick2 response expects block headers but got %T", ErrInvalidArgType, arg)
+	}
+}
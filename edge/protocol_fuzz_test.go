@@ -0,0 +1,96 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// fuzzArgs turns the fuzz data into arguments of mixed types, each byte of
+// kinds selects the type of one argument
+func fuzzArgs(kinds []byte, data []byte, number uint64) []interface{} {
+	args := make([]interface{}, 0, len(kinds))
+	for _, kind := range kinds {
+		switch kind % 6 {
+		case 0:
+			args = append(args, data)
+		case 1:
+			args = append(args, string(data))
+		case 2:
+			args = append(args, number)
+		case 3:
+			var hash [32]byte
+			copy(hash[:], data)
+			args = append(args, hash)
+		case 4:
+			args = append(args, []uint64{number})
+		default:
+			args = append(args, nil)
+		}
+	}
+	return args
+}
+
+func FuzzNewMessage(f *testing.F) {
+	deviceID := make([]byte, 20)
+	f.Add("portopen", []byte{0, 1, 1}, deviceID, uint64(0))
+	f.Add("portopen", []byte{0, 1, 1}, []byte("tcp:80"), uint64(0))
+	f.Add("portsend", []byte{1, 0}, []byte("data"), uint64(0))
+	f.Add("portclose", []byte{1}, []byte("ref"), uint64(0))
+	f.Add("getblockpeak", []byte{}, []byte{}, uint64(0))
+	f.Add("getblockheader2", []byte{2}, []byte{}, uint64(6406857))
+	f.Add("getblockquick2", []byte{2, 2}, []byte{}, uint64(100))
+	f.Add("getblockquick_since", []byte{2, 2, 3}, []byte{1, 2, 3}, uint64(100))
+	f.Add("getaccount", []byte{2, 0}, deviceID, uint64(100))
+	f.Add("getaccountvaluerange", []byte{2, 2, 0, 0}, deviceID, uint64(100))
+	f.Add("setfleet", []byte{0}, deviceID, uint64(0))
+	f.Fuzz(func(t *testing.T, method string, kinds []byte, data []byte, number uint64) {
+		if len(kinds) > 8 {
+			kinds = kinds[:8]
+		}
+		buf := &bytes.Buffer{}
+		parse, err := NewMessage(buf, 1, method, fuzzArgs(kinds, data, number)...)
+		if err != nil && parse != nil {
+			t.Errorf("NewMessage(%s) returned a parser with error %v", method, err)
+		}
+	})
+}
+
+func TestNewMessageInvalidArgType(t *testing.T) {
+	tests := []struct {
+		method string
+		args   []interface{}
+	}{
+		{"getblockquick_since", []interface{}{"100", uint64(10), [32]byte{}}},
+		{"getblockquick", []interface{}{uint64(100), 10}},
+		{"getblockquick", []interface{}{uint64(100)}},
+		{"portopen", []interface{}{"device", "tcp:80", "rw"}},
+		{"setfleet", []interface{}{uint64(1)}},
+		{"getaccountvalue", []interface{}{uint64(100), make([]byte, 20), "key"}},
+		{"portsend_seq", []interface{}{"ref", uint64(1), []byte("data")}},
+		{"getblockpeak", []interface{}{uint64(100)}},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		if _, err := NewMessage(buf, 1, tt.method, tt.args...); !errors.Is(err, ErrInvalidArgType) {
+			t.Errorf("NewMessage(%s, %v) expected ErrInvalidArgType but got %v", tt.method, tt.args, err)
+		}
+	}
+}
+
+func TestNewMessageArgValidators(t *testing.T) {
+	methods := append([]string{"hello", "goodbye", "portclose"}, rlpResponseMethods...)
+	for _, method := range methods {
+		validate, ok := messageArgValidators[method]
+		if !ok {
+			t.Errorf("%s: missing argument validator", method)
+			continue
+		}
+		if err := validate([]interface{}{struct{}{}}); !errors.Is(err, ErrInvalidArgType) {
+			t.Errorf("%s: expected ErrInvalidArgType but got %v", method, err)
+		}
+	}
+}
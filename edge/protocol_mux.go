@@ -0,0 +1,86 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/diodechain/diode_client/blockquick"
+)
+
+var (
+	ErrUnsupportedProtocolVersion = fmt.Errorf("unsupported protocol version")
+)
+
+// ProtocolVersion is an edge protocol implementation of the given version
+type ProtocolVersion struct {
+	Version  uint64
+	Protocol EdgeProtocol
+}
+
+// ProtocolMultiplexer delegates to the protocol version that was negotiated with the server
+type ProtocolMultiplexer struct {
+	mx       sync.RWMutex
+	versions []ProtocolVersion
+	selected ProtocolVersion
+}
+
+// NewProtocolMultiplexer returns a multiplexer of the given versions, the lowest
+// version is used until Select is called
+func NewProtocolMultiplexer(versions ...ProtocolVersion) (*ProtocolMultiplexer, error) {
+	if len(versions) == 0 {
+		return nil, ErrUnsupportedProtocolVersion
+	}
+	sorted := make([]ProtocolVersion, len(versions))
+	copy(sorted, versions)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &ProtocolMultiplexer{
+		versions: sorted,
+		selected: sorted[0],
+	}, nil
+}
+
+// Select uses the highest protocol version that the server version supports
+func (pm *ProtocolMultiplexer) Select(serverVersion uint64) error {
+	pm.mx.Lock()
+	defer pm.mx.Unlock()
+	for i := len(pm.versions) - 1; i >= 0; i-- {
+		if pm.versions[i].Version <= serverVersion {
+			pm.selected = pm.versions[i]
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: server version %d", ErrUnsupportedProtocolVersion, serverVersion)
+}
+
+// Version returns the selected protocol version
+func (pm *ProtocolMultiplexer) Version() uint64 {
+	pm.mx.RLock()
+	defer pm.mx.RUnlock()
+	return pm.selected.Version
+}
+
+func (pm *ProtocolMultiplexer) protocol() EdgeProtocol {
+	pm.mx.RLock()
+	defer pm.mx.RUnlock()
+	return pm.selected.Protocol
+}
+
+// NewMessage encodes the request with the selected protocol
+func (pm *ProtocolMultiplexer) NewMessage(writer io.Writer, requestID uint64, method string, args ...interface{}) (func(buffer []byte) (interface{}, error), error) {
+	return pm.protocol().NewMessage(writer, requestID, method, args...)
+}
+
+// Parse parses the message with the selected protocol
+func (pm *ProtocolMultiplexer) Parse(buffer []byte) (interface{}, error) {
+	return pm.protocol().Parse(buffer)
+}
+
+// ParseBlockHeaders parses the block headers of the window with the selected protocol
+func (pm *ProtocolMultiplexer) ParseBlockHeaders(raw []byte, size int) ([]*blockquick.BlockHeader, error) {
+	return pm.protocol().ParseBlockHeaders(raw, size)
+}
@@ -0,0 +1,71 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/diodechain/diode_client/blockquick"
+)
+
+type testProtocol struct {
+	methods []string
+}
+
+func (tp *testProtocol) NewMessage(writer io.Writer, requestID uint64, method string, args ...interface{}) (func(buffer []byte) (interface{}, error), error) {
+	tp.methods = append(tp.methods, method)
+	_, err := writer.Write([]byte(method))
+	return tp.Parse, err
+}
+
+func (tp *testProtocol) Parse(buffer []byte) (interface{}, error) {
+	return string(buffer), nil
+}
+
+func (tp *testProtocol) ParseBlockHeaders(raw []byte, size int) ([]*blockquick.BlockHeader, error) {
+	return nil, ErrRPCNotSupport
+}
+
+func TestProtocolMultiplexer(t *testing.T) {
+	v3 := &testProtocol{}
+	mux, err := NewProtocolMultiplexer(ProtocolVersion{3, v3}, ProtocolVersion{2, RLPProtocol{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mux.Version() != 2 {
+		t.Fatalf("expected version 2 before hello but got %d", mux.Version())
+	}
+	buf := &bytes.Buffer{}
+	if _, err = mux.NewMessage(buf, 1, "getblockpeak"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := mux.Parse(encodeTestResponse(t, "portclose", "ref"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := res.(*PortClose); !ok || len(v3.methods) != 0 {
+		t.Fatalf("v2 should parse the message but got %T", res)
+	}
+
+	// a v3 server said hello
+	if err = mux.Select(3); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if _, err = mux.NewMessage(buf, 2, "getblockpeak"); err != nil {
+		t.Fatal(err)
+	}
+	if len(v3.methods) != 1 || buf.String() != "getblockpeak" {
+		t.Fatalf("v3 should encode the message but got %v", v3.methods)
+	}
+	if err = mux.Select(4); err != nil || mux.Version() != 3 {
+		t.Fatalf("v4 server should use v3 but got %d %v", mux.Version(), err)
+	}
+	if err = mux.Select(1); !errors.Is(err, ErrUnsupportedProtocolVersion) {
+		t.Fatalf("expected ErrUnsupportedProtocolVersion but got %v", err)
+	}
+}
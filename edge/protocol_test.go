@@ -0,0 +1,882 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/diodechain/diode_client/blockquick"
+	"github.com/diodechain/diode_client/crypto"
+	"github.com/diodechain/diode_client/crypto/secp256k1"
+	"github.com/diodechain/diode_client/rlp"
+	"github.com/diodechain/diode_client/util"
+	bert "github.com/diodechain/gobert"
+)
+
+func TestNewResponseMessageBlockquick(t *testing.T) {
+	headers := make([]blockquick.BlockHeader, 10)
+	blockNumbers := make([]uint64, len(headers))
+	for i := range blockNumbers {
+		blockNumbers[i] = uint64(100 + i)
+	}
+	tests := []interface{}{blockNumbers, headers}
+	for _, arg := range tests {
+		buf := &bytes.Buffer{}
+		_, err := NewResponseMessage(buf, 1, "response", "getblockquick2", arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := parseBlockquickResponse(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sequence, ok := res.([]uint64)
+		if !ok {
+			t.Fatalf("expected []uint64 but got %T", res)
+		}
+		if len(sequence) != 10 {
+			t.Fatalf("expected 10 block numbers but got %d", len(sequence))
+		}
+	}
+}
+
+func TestNewResponseMessageBlockquickLists(t *testing.T) {
+	lists := make([][]*blockquick.BlockHeader, 2)
+	for i := range lists {
+		lists[i] = make([]*blockquick.BlockHeader, 5)
+		for j := range lists[i] {
+			lists[i][j] = newTestBlockHeader(t)
+		}
+	}
+	buf := &bytes.Buffer{}
+	if _, err := NewResponseMessage(buf, 1, "response", "getblockquick2", lists); err != nil {
+		t.Fatal(err)
+	}
+	res, err := parseBlockquickResponse(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sequence := res.([]uint64); len(sequence) != 10 || sequence[9] != testHeaderNumber {
+		t.Fatalf("expected 10 block numbers but got %v", sequence)
+	}
+}
+
+// responseFields returns the fields of the encoded response after the response type
+func responseFields(t *testing.T, buffer []byte) []interface{} {
+	var response struct {
+		RequestID uint64
+		Payload   []rlp.RawValue
+	}
+	if err := rlp.DecodeBytes(buffer, &response); err != nil {
+		t.Fatal(err)
+	}
+	fields := make([]interface{}, len(response.Payload)-1)
+	for i, field := range response.Payload[1:] {
+		fields[i] = field
+	}
+	return fields
+}
+
+func TestNewResponseMessageGetters(t *testing.T) {
+	tx := BlockTransaction{Value: big.NewInt(1), Nonce: 7}
+	hash, err := tx.ComputeHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Hash = hash
+	tests := []struct {
+		method string
+		args   []interface{}
+		parse  func(buffer []byte) (interface{}, error)
+		check  func(res interface{}) bool
+	}{
+		{"getblockheader2", []interface{}{newTestBlockHeaderItems(t), secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey)}, parseBlockHeaderResponse, func(res interface{}) bool {
+			header := res.(blockquick.BlockHeader)
+			return header.Number() == testHeaderNumber
+		}},
+		{"getaccount", responseFields(t, newTestAccountResponse(t)), parseAccountResponse, func(res interface{}) bool {
+			return res.(*Account).Balance.Uint64() == 100
+		}},
+		{"getaccountvalue", []interface{}{[]interface{}{[]byte{}, []byte{0}, []interface{}{make([]byte, 32), []byte{1}}}}, parseAccountValueResponse, func(res interface{}) bool {
+			tree := res.(*AccountValue).AccountTree()
+			return tree.VerifyLeaf(make([]byte, 32))
+		}},
+		{"getobject", responseFields(t, encodeTestObjectResponse(t, 100)), parseDeviceObjectResponse, func(res interface{}) bool {
+			return res.(*DeviceTicket).BlockNumber == 100
+		}},
+		{"getnode", responseFields(t, encodeTestServerObjResponse(t, 15)), parseServerObjResponse, func(res interface{}) bool {
+			return string(res.(*ServerObj).Host) == "127.0.0.1"
+		}},
+		{"ticket", []interface{}{"thanks!", []byte{1}}, parseDeviceTicketResponse, func(res interface{}) bool {
+			return res.(DeviceTicket).Err == nil
+		}},
+		{"getblock", responseFields(t, encodeTestBlockResponse(t, tx)), parseBlockResponse, func(res interface{}) bool {
+			block := res.(*Block)
+			return len(block.Transactions) == 1 && block.Transactions[0].Nonce == 7
+		}},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		if _, err := NewResponseMessage(buf, 1, "response", tt.method, tt.args...); err != nil {
+			t.Fatalf("%s: %v", tt.method, err)
+		}
+		res, err := tt.parse(buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.method, err)
+		}
+		if !tt.check(res) {
+			t.Errorf("%s: unexpected response %+v", tt.method, res)
+		}
+	}
+}
+
+func TestNewResponseMessageBlockPeak(t *testing.T) {
+	buf := &bytes.Buffer{}
+	_, err := NewResponseMessage(buf, 1, "response", "getblockpeak", uint64(1234))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := parseBlockPeakResponse(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.(uint64) != 1234 {
+		t.Errorf("expected block peak 1234 but got %v", res)
+	}
+	if ResponseID(buf.Bytes()) != 1 {
+		t.Errorf("expected request id 1 but got %d", ResponseID(buf.Bytes()))
+	}
+}
+
+func TestNewResponseMessageNotSupport(t *testing.T) {
+	buf := &bytes.Buffer{}
+	_, err := NewResponseMessage(buf, 1, "response", "getblockquick2", "wrong")
+	if err == nil {
+		t.Errorf("expected error for wrong getblockquick2 argument")
+	}
+	_, err = NewResponseMessage(buf, 1, "response", "unknown")
+	if err != ErrRPCNotSupport {
+		t.Errorf("expected ErrRPCNotSupport but got %v", err)
+	}
+}
+
+func TestValidatePortOpenArgs(t *testing.T) {
+	deviceID := make([]byte, 20)
+	if err := ValidatePortOpenArgs(deviceID, 80, "rw"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		deviceID []byte
+		port     uint64
+		mode     string
+	}{
+		{nil, 80, "rw"},
+		{make([]byte, 19), 80, "rw"},
+		{make([]byte, 32), 80, "rw"},
+		{deviceID, 0, "rw"},
+		{deviceID, 65536, "rw"},
+		{deviceID, 80, ""},
+		{deviceID, 80, "x"},
+		{deviceID, 80, "rr"},
+		{deviceID, 80, "rwsr"},
+	}
+	for _, v := range tests {
+		if err := ValidatePortOpenArgs(v.deviceID, v.port, v.mode); err == nil {
+			t.Errorf("ValidatePortOpenArgs(%x, %d, %q) should fail", v.deviceID, v.port, v.mode)
+		}
+	}
+}
+
+func TestNormalizePortMode(t *testing.T) {
+	deviceID := make([]byte, 20)
+	for mode, want := range map[string]string{"rw": "rw", "rr": "r", "rwr": "rw", "sws": "sw", "rrwwss": "rws"} {
+		if got := NormalizePortMode(mode); got != want {
+			t.Errorf("NormalizePortMode(%q) = %q, want %q", mode, got, want)
+		}
+		if err := ValidatePortOpenArgs(deviceID, 80, NormalizePortMode(mode)); err != nil {
+			t.Errorf("normalized mode of %q should be valid: %v", mode, err)
+		}
+	}
+}
+
+func TestNewMessagePortOpen(t *testing.T) {
+	deviceID := make([]byte, 20)
+	tests := []struct {
+		args  []interface{}
+		valid bool
+	}{
+		{[]interface{}{deviceID, "tcp:80", "rw"}, true},
+		{[]interface{}{deviceID, string([]byte{0, 80}), "rw"}, true},
+		{[]interface{}{make([]byte, 32), "tcp:80", "rw"}, false},
+		{[]interface{}{deviceID, "tcp:70000", "rw"}, false},
+		{[]interface{}{deviceID, "tcp:80", "x"}, false},
+		{[]interface{}{deviceID, 80, "rw"}, false},
+		{[]interface{}{deviceID, "tcp:80"}, false},
+	}
+	for _, v := range tests {
+		buf := &bytes.Buffer{}
+		_, err := NewMessage(buf, 1, "portopen", v.args...)
+		if v.valid && err != nil {
+			t.Errorf("NewMessage(portopen, %v) failed: %v", v.args, err)
+		}
+		if !v.valid {
+			if err == nil {
+				t.Errorf("NewMessage(portopen, %v) should fail", v.args)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("NewMessage(portopen, %v) should not encode anything", v.args)
+			}
+		}
+	}
+}
+
+func TestParseHealthCheckResponse(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if _, err := NewMessage(buf, 1, "healthcheck"); err != nil {
+		t.Fatal(err)
+	}
+	response := []interface{}{uint64(1), []interface{}{"response", uint64(4250), uint64(7525), uint64(12), uint64(86400)}}
+	buffer, err := rlp.EncodeToBytes(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := parseHealthCheckResponse(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, ok := res.(*HealthStatus)
+	if !ok {
+		t.Fatalf("expected *HealthStatus but got %T", res)
+	}
+	if status.CPUPercent != 42.5 || status.MemPercent != 75.25 || status.OpenConnections != 12 || status.UptimeSeconds != 86400 {
+		t.Errorf("unexpected health status %+v", status)
+	}
+	if status.Load() != 75.25 {
+		t.Errorf("expected load 75.25 but got %v", status.Load())
+	}
+}
+
+func TestParseAccountRootsRangeResponse(t *testing.T) {
+	blocks := make([]interface{}, 3)
+	for i := range blocks {
+		blocks[i] = []interface{}{uint64(100 + i), newTestRoots(string(rune('a' + i)))}
+	}
+	buf := &bytes.Buffer{}
+	parse, err := NewMessage(buf, 1, "getaccountroots_range", uint64(100), uint64(102), make([]byte, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer := encodeTestResponse(t, "response", blocks)
+	res, err := parse(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountRoots, ok := res.([]AccountRootsAtBlock)
+	if !ok {
+		t.Fatalf("expected []AccountRootsAtBlock but got %T", res)
+	}
+	if len(accountRoots) != 3 {
+		t.Fatalf("expected 3 account roots but got %d", len(accountRoots))
+	}
+	roots := make(map[string]bool)
+	for i, accountRoot := range accountRoots {
+		if accountRoot.BlockNumber != uint64(100+i) || len(accountRoot.Roots.AccountRoots) != 16 {
+			t.Errorf("unexpected account roots %+v", accountRoot)
+		}
+		roots[string(accountRoot.Roots.StorageRoot())] = true
+	}
+	if len(roots) != 3 {
+		t.Errorf("expected 3 distinct storage roots but got %d", len(roots))
+	}
+}
+
+func TestParseAccountValueResponseStorageKey(t *testing.T) {
+	key := util.PaddingBytesPrefix([]byte{0x01}, 0, 32)
+	value := util.PaddingBytesPrefix([]byte{0x2a}, 0, 32)
+	parse, err := NewMessage(&bytes.Buffer{}, 1, "getaccountvalue", uint64(100), make([]byte, 20), []byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof := []interface{}{[]byte{}, []byte{0}, []interface{}{key, value}}
+	res, err := parse(encodeTestResponse(t, "response", proof))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountValue := res.(*AccountValue)
+	if !bytes.Equal(accountValue.StorageKey, key) {
+		t.Fatalf("expected storage key %x but got %x", key, accountValue.StorageKey)
+	}
+	if !accountValue.VerifyKey([]byte{0x01}) {
+		t.Fatalf("requested key should be verified")
+	}
+	if accountValue.VerifyKey([]byte{0x02}) {
+		t.Fatalf("other key should not be verified")
+	}
+}
+
+func TestParseAccountValueRangeResponse(t *testing.T) {
+	key := make([]byte, 32)
+	values := make([]interface{}, 5)
+	for i := range values {
+		value := make([]byte, 32)
+		value[31] = byte(i + 1)
+		proof := []interface{}{[]byte{}, []byte{0}, []interface{}{key, value}}
+		values[i] = []interface{}{uint64(100 + i), proof}
+	}
+	buf := &bytes.Buffer{}
+	parse, err := NewMessage(buf, 1, "getaccountvaluerange", uint64(100), uint64(104), make([]byte, 20), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer := encodeTestResponse(t, "response", values)
+	res, err := parse(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountValues, ok := res.([]AccountValueAtBlock)
+	if !ok {
+		t.Fatalf("expected []AccountValueAtBlock but got %T", res)
+	}
+	if len(accountValues) != 5 {
+		t.Fatalf("expected 5 account values but got %d", len(accountValues))
+	}
+	roots := make(map[string]bool)
+	for i, accountValue := range accountValues {
+		if accountValue.BlockNumber != uint64(100+i) {
+			t.Errorf("expected block %d but got %d", 100+i, accountValue.BlockNumber)
+		}
+		roots[string(accountValue.Value.AccountRoot())] = true
+	}
+	if len(roots) != 5 {
+		t.Errorf("expected 5 distinct root hashes but got %d", len(roots))
+	}
+}
+
+func TestNewStructuredErrorResponse(t *testing.T) {
+	msg, errMsg, err := NewStructuredErrorResponse(7, "portopen", fmt.Errorf("port not found"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errMsg.Method != "portopen" || errMsg.Description != "port not found" {
+		t.Errorf("unexpected error message %+v", errMsg)
+	}
+	if !msg.IsError() || msg.ResponseID() != 7 {
+		t.Errorf("message should be error response of request 7")
+	}
+	rpcErr, err := msg.ReadAsError()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rpcErr.Message != "port not found" {
+		t.Errorf("expected error 'port not found' but got '%s'", rpcErr.Message)
+	}
+}
+
+func TestPortOpenReject(t *testing.T) {
+	portOpen := &PortOpen{RequestID: 3, Ref: "ref", Ok: true}
+	buffer, err := portOpen.Reject("device not allowlisted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := ParsePortOpenReply(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Ok {
+		t.Errorf("rejected portopen should not be ok")
+	}
+	if reply.RequestID != 3 || reply.Ref != "ref" {
+		t.Errorf("unexpected portopen reply %+v", reply)
+	}
+	if reply.Err == nil || reply.Err.Error() != "portopen reject: device not allowlisted" {
+		t.Errorf("unexpected portopen error %v", reply.Err)
+	}
+	buf := &bytes.Buffer{}
+	if _, err = NewResponseMessage(buf, 4, "response", "portopen", "ref", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err = ParsePortOpenReply(buf.Bytes()); err != nil || !reply.Ok {
+		t.Errorf("accepted portopen should be ok: %v", err)
+	}
+}
+
+func encodeTestServerObjResponse(t *testing.T, networkID uint64) []byte {
+	serverKey, err := crypto.HexToECDSA("0101010101010101010101010101010101010101010101010101010101010101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := []byte("127.0.0.1")
+	version := []byte("v1")
+	value := *new(big.Int).SetUint64(networkID)
+	bertdata, err := bert.Encode([5]bert.Term{
+		host,
+		uint64(41046),
+		uint64(41045),
+		version,
+		bert.List{Items: []bert.Term{[2]bert.Term{[]byte(ServerNetworkIDKey), value}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := secp256k1.Sign(crypto.Sha256(bertdata), serverKey.D.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response serverObjectResponse
+	response.RequestID = 1
+	response.Payload.Type = "response"
+	response.Payload.ServerObject = []interface{}{
+		[]byte("server"),
+		host,
+		uint64(41046),
+		uint64(41045),
+		version,
+		[]interface{}{[]interface{}{[]byte(ServerNetworkIDKey), networkID}},
+		sig,
+	}
+	buffer, err := rlp.EncodeToBytes(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buffer
+}
+
+func TestServerObjNetworkID(t *testing.T) {
+	obj, err := doParseServerObjResponse(encodeTestServerObjResponse(t, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.NetworkID != 2 {
+		t.Fatalf("expected network id 2 but got %d", obj.NetworkID)
+	}
+	if err = obj.CheckNetworkID(1); !errors.Is(err, ErrWrongNetwork) {
+		t.Fatalf("expected ErrWrongNetwork but got %v", err)
+	}
+	if err = obj.CheckNetworkID(2); err != nil {
+		t.Fatal(err)
+	}
+	if err = obj.CheckNetworkID(0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerObjURL(t *testing.T) {
+	tests := []struct {
+		host      string
+		edgeURL   string
+		serverURL string
+	}{
+		{"edge.diode.io", "edge.diode.io:41046", "edge.diode.io:51054"},
+		{"127.0.0.1", "127.0.0.1:41046", "127.0.0.1:51054"},
+		{"::1", "[::1]:41046", "[::1]:51054"},
+		{"edge.diode.io:80", "", ""},
+		{"-edge.diode.io", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		obj := &ServerObj{Host: []byte(tt.host), EdgePort: 41046, ServerPort: 51054}
+		if url := obj.EdgeURL(); url != tt.edgeURL {
+			t.Errorf("EdgeURL() of %q = %q, expected %q", tt.host, url, tt.edgeURL)
+		}
+		if url := obj.ServerURL(); url != tt.serverURL {
+			t.Errorf("ServerURL() of %q = %q, expected %q", tt.host, url, tt.serverURL)
+		}
+	}
+}
+
+func encodeTestBlockTransaction(t *testing.T, tx BlockTransaction) rlp.RawValue {
+	raw, err := rlp.EncodeToBytes(transactionItem{
+		Hash:  tx.Hash[:],
+		From:  tx.From[:],
+		To:    tx.To[:],
+		Value: tx.Value,
+		Nonce: tx.Nonce,
+		Data:  tx.Data,
+		Sig:   tx.Sig[:],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func encodeTestBlockResponse(t *testing.T, txs ...BlockTransaction) []byte {
+	var response blockResponse
+	response.RequestID = 1
+	response.Payload.Type = "response"
+	response.Payload.Block.Coinbase.Key = "coinbase"
+	response.Payload.Block.Header.Key = "header"
+	response.Payload.Block.Receipts.Key = "receipts"
+	response.Payload.Block.Transactions.Key = "transactions"
+	for _, tx := range txs {
+		response.Payload.Block.Transactions.Value = append(response.Payload.Block.Transactions.Value, encodeTestBlockTransaction(t, tx))
+	}
+	buffer, err := rlp.EncodeToBytes(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buffer
+}
+
+func TestParseBlockResponseTransactions(t *testing.T) {
+	txs := []BlockTransaction{
+		{From: [20]byte{1}, To: [20]byte{2}, Value: big.NewInt(1000), Nonce: 1, Sig: [65]byte{1}},
+		{From: [20]byte{2}, To: [20]byte{3}, Value: big.NewInt(0), Nonce: 7, Data: []byte("call"), Sig: [65]byte{2}},
+	}
+	for i := range txs {
+		hash, err := txs[i].ComputeHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs[i].Hash = hash
+	}
+	res, err := parseBlockResponse(encodeTestBlockResponse(t, txs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := res.(*Block)
+	if len(block.Transactions) != len(txs) {
+		t.Fatalf("expected %d transactions but got %d", len(txs), len(block.Transactions))
+	}
+	for i, tx := range block.Transactions {
+		if tx.Hash != txs[i].Hash || tx.Nonce != txs[i].Nonce || tx.Value.Cmp(txs[i].Value) != 0 || !bytes.Equal(tx.Data, txs[i].Data) {
+			t.Errorf("transaction %d doesn't match: %+v", i, tx)
+		}
+	}
+
+	txs[1].Nonce++
+	if _, err = parseBlockResponse(encodeTestBlockResponse(t, txs...)); err != ErrTransactionHashMismatch {
+		t.Fatalf("expected ErrTransactionHashMismatch but got %v", err)
+	}
+}
+
+func TestNewMessageBlockquickSince(t *testing.T) {
+	sinceHash := [32]byte{1, 2, 3}
+	sinceHash[31] = 0xff
+	buf := &bytes.Buffer{}
+	parse, err := NewMessage(buf, 5, "getblockquick_since", uint64(100), uint64(10), sinceHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parse == nil {
+		t.Fatalf("getblockquick_since should return the blockquick parser")
+	}
+	var request struct {
+		RequestID uint64
+		Payload   []rlp.RawValue
+	}
+	if err = rlp.DecodeBytes(buf.Bytes(), &request); err != nil {
+		t.Fatal(err)
+	}
+	if request.RequestID != 5 || len(request.Payload) != 4 {
+		t.Fatalf("unexpected request %+v", request)
+	}
+	var method string
+	var lastValid, windowSize uint64
+	var hash []byte
+	for i, val := range []interface{}{&method, &lastValid, &windowSize, &hash} {
+		if err = rlp.DecodeBytes(request.Payload[i], val); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if method != "getblockquick_since" || lastValid != 100 || windowSize != 10 || !bytes.Equal(hash, sinceHash[:]) {
+		t.Fatalf("unexpected payload %s %d %d %x", method, lastValid, windowSize, hash)
+	}
+	if _, err = NewMessage(buf, 6, "getblockquick_since", uint64(100), uint64(10), sinceHash[:]); err == nil {
+		t.Fatalf("getblockquick_since should reject a hash slice")
+	}
+}
+
+func TestParseBlockHeaderResponseMissingField(t *testing.T) {
+	items := newTestBlockHeaderItems(t)
+	for i, item := range items {
+		if item.Key == "nonce" {
+			items[i].Key = "nonce2"
+		}
+	}
+	buffer := encodeTestResponse(t, "response", items, secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey))
+	if _, err := parseBlockHeaderResponse(buffer); err == nil || !strings.Contains(err.Error(), `"nonce"`) {
+		t.Fatalf("expected missing nonce error but got %v", err)
+	}
+}
+
+func TestFindItemInItemsCI(t *testing.T) {
+	items := []Item{{Key: "number", Value: []byte{1}}, {Key: "block_hash", Value: []byte{2}}}
+	if _, err := findItemInItems([2]Item{items[0], items[1]}, "BLOCK_HASH"); err == nil {
+		t.Fatalf("exact lookup should not find BLOCK_HASH")
+	}
+	item, err := findItemInItemsCI(items, "BLOCK_HASH")
+	if err != nil || !bytes.Equal(item.Value, []byte{2}) {
+		t.Fatalf("case insensitive lookup of BLOCK_HASH returned %v %v", item, err)
+	}
+	// case folding doesn't remove the underscore
+	if _, err = findItemInItemsCI(items, "BlockHash"); err == nil {
+		t.Fatalf("case insensitive lookup should not find BlockHash")
+	}
+	if item, err = lookupItem(items, "Number"); err != nil || !bytes.Equal(item.Value, []byte{1}) {
+		t.Fatalf("lookup of Number returned %v %v", item, err)
+	}
+}
+
+func TestParseBlockHeaderResponseMixedCase(t *testing.T) {
+	items := newTestBlockHeaderItems(t)
+	for i := range items {
+		items[i].Key = strings.ToUpper(items[i].Key)
+	}
+	buffer := encodeTestResponse(t, "response", items, secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey))
+	res, err := parseBlockHeaderResponse(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := res.(blockquick.BlockHeader); header.Number() != testHeaderNumber {
+		t.Fatalf("expected block %d but got %d", testHeaderNumber, header.Number())
+	}
+}
+
+func TestNewMessageSetFleet(t *testing.T) {
+	fleetAddr := Address{1, 2, 3}
+	buf := &bytes.Buffer{}
+	parse, err := NewMessage(buf, 1, "setfleet", fleetAddr[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var request struct {
+		RequestID uint64
+		Payload   struct {
+			Method    string
+			FleetAddr []byte
+		}
+	}
+	if err = rlp.DecodeBytes(buf.Bytes(), &request); err != nil {
+		t.Fatal(err)
+	}
+	if request.Payload.Method != "setfleet" || !bytes.Equal(request.Payload.FleetAddr, fleetAddr[:]) {
+		t.Fatalf("unexpected setfleet request %+v", request)
+	}
+
+	res := encodeTestResponse(t, "response", "ok")
+	ok, err := parse(res)
+	if err != nil || ok != true {
+		t.Fatalf("expected ok response but got %v %v", ok, err)
+	}
+
+	buf.Reset()
+	if _, err = NewMessage(buf, 1, "setfleet", fleetAddr[:19]); err == nil || buf.Len() != 0 {
+		t.Fatalf("setfleet with 19 bytes address should fail")
+	}
+}
+
+func TestParsePortOpenResponseDenied(t *testing.T) {
+	buffer := encodeTestResponse(t, "response", "not_allowed", "ref1")
+	res, err := parsePortOpenResponse(buffer)
+	if !errors.Is(err, ErrPortOpenDenied) || !strings.Contains(err.Error(), "not_allowed") {
+		t.Fatalf("expected ErrPortOpenDenied but got %v", err)
+	}
+	portOpen, ok := res.(*PortOpen)
+	if !ok || portOpen.Ok || portOpen.Ref != "ref1" || portOpen.Err != err {
+		t.Fatalf("expected denied portopen but got %+v", res)
+	}
+
+	buffer = encodeTestResponse(t, "response", "ok", "ref1")
+	if res, err = parsePortOpenResponse(buffer); err != nil || !res.(*PortOpen).Ok {
+		t.Fatalf("expected ok portopen but got %+v %v", res, err)
+	}
+}
+
+func TestParseBlockHeaderResponseUncleHash(t *testing.T) {
+	items := newTestBlockHeaderItems(t)
+	buffer := encodeTestResponse(t, "response", items, secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey))
+	res, err := parseBlockHeaderResponse(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := res.(blockquick.BlockHeader); header.UncleHash != [32]byte{} {
+		t.Fatalf("expected zero uncle hash but got %x", header.UncleHash)
+	}
+
+	uncleHash := crypto.Sha256([]byte("uncle"))
+	items = append(items, Item{Key: "uncle_hash", Value: uncleHash})
+	buffer = encodeTestResponse(t, "response", items, secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey))
+	if res, err = parseBlockHeaderResponse(buffer); err != nil {
+		t.Fatal(err)
+	}
+	if header := res.(blockquick.BlockHeader); !bytes.Equal(header.UncleHash[:], uncleHash) {
+		t.Fatalf("expected uncle hash %x but got %x", uncleHash, header.UncleHash)
+	}
+
+	items[len(items)-1].Value = uncleHash[:10]
+	buffer = encodeTestResponse(t, "response", items, secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey))
+	if _, err = parseBlockHeaderResponse(buffer); err == nil {
+		t.Fatalf("expected error for short uncle hash")
+	}
+}
+
+func TestNewMessageGetLogs(t *testing.T) {
+	address := Address{1, 2, 3}
+	topics := [][]byte{bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)}
+	buf := &bytes.Buffer{}
+	parse, err := NewMessage(buf, 1, "getlogs", uint64(100), uint64(200), address[:], topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var request struct {
+		RequestID uint64
+		Payload   struct {
+			Method    string
+			FromBlock uint64
+			ToBlock   uint64
+			Address   []byte
+			Topics    [][]byte
+		}
+	}
+	if err = rlp.DecodeBytes(buf.Bytes(), &request); err != nil {
+		t.Fatal(err)
+	}
+	if request.Payload.Method != "getlogs" || request.Payload.FromBlock != 100 || request.Payload.ToBlock != 200 || len(request.Payload.Topics) != 2 {
+		t.Fatalf("unexpected getlogs request %+v", request)
+	}
+
+	logs := make([]interface{}, 3)
+	for i := range logs {
+		logTopics := make([]interface{}, i+1)
+		for j := range logTopics {
+			logTopics[j] = bytes.Repeat([]byte{byte(j + 1)}, 32)
+		}
+		logs[i] = []interface{}{address[:], logTopics, []byte("data"), uint64(100 + i), bytes.Repeat([]byte{byte(i)}, 32)}
+	}
+	res, err := parse(encodeTestResponse(t, "response", logs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventLogs, ok := res.([]EventLog)
+	if !ok || len(eventLogs) != 3 {
+		t.Fatalf("expected 3 event logs but got %v", res)
+	}
+	for i, eventLog := range eventLogs {
+		if len(eventLog.Topics) != i+1 || eventLog.Topics[0][0] != 1 || eventLog.BlockNumber != uint64(100+i) || eventLog.Address != address {
+			t.Errorf("unexpected event log %+v", eventLog)
+		}
+	}
+
+	buf.Reset()
+	if _, err = NewMessage(buf, 1, "getlogs", uint64(100), uint64(200), address[:], []string{"topic"}); !errors.Is(err, ErrInvalidArgType) {
+		t.Fatalf("expected ErrInvalidArgType but got %v", err)
+	}
+}
+
+func TestParseAccountNonce(t *testing.T) {
+	buf := &bytes.Buffer{}
+	address := Address{1}
+	parse, err := NewMessage(buf, 1, "getaccountnonce", uint64(100), address[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var request struct {
+		RequestID uint64
+		Payload   struct {
+			Method      string
+			BlockNumber uint64
+			Address     []byte
+		}
+	}
+	if err = rlp.DecodeBytes(buf.Bytes(), &request); err != nil {
+		t.Fatal(err)
+	}
+	if request.Payload.Method != "getaccount" || request.Payload.BlockNumber != 100 {
+		t.Fatalf("unexpected getaccountnonce request %+v", request)
+	}
+	buffer := newTestAccountResponse(t)
+	res, err := parse(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := parseAccountResponse(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce, ok := res.(uint64); !ok || int64(nonce) != account.(*Account).Nonce {
+		t.Fatalf("expected nonce %d but got %v", account.(*Account).Nonce, res)
+	}
+	fast := testing.AllocsPerRun(100, func() { parseAccountNonce(buffer) })
+	full := testing.AllocsPerRun(100, func() { parseAccountResponse(buffer) })
+	if fast >= full {
+		t.Fatalf("expected fewer allocations than %v but got %v", full, fast)
+	}
+}
+
+func TestParseBlockHeaderResponseDifficulty(t *testing.T) {
+	items := newTestBlockHeaderItems(t)
+	buffer := encodeTestResponse(t, "response", items, secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey))
+	res, err := parseBlockHeaderResponse(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := res.(blockquick.BlockHeader); header.Difficulty != nil {
+		t.Fatalf("expected no difficulty but got %v", header.Difficulty)
+	}
+
+	items = append(items, Item{Key: "difficulty", Value: []byte{0x02, 0x00, 0x00}})
+	buffer = encodeTestResponse(t, "response", items, secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey))
+	if res, err = parseBlockHeaderResponse(buffer); err != nil {
+		t.Fatal(err)
+	}
+	if header := res.(blockquick.BlockHeader); header.Difficulty == nil || header.Difficulty.Uint64() != 0x020000 {
+		t.Fatalf("expected difficulty 0x020000 but got %v", header.Difficulty)
+	}
+}
+
+func TestRLPDeterminism(t *testing.T) {
+	addr := make([]byte, 20)
+	hash := make([]byte, 32)
+	tests := []struct {
+		method string
+		args   []interface{}
+	}{
+		{"hello", []interface{}{uint64(1000)}},
+		{"portclose", []interface{}{"ref"}},
+		{"goodbye", []interface{}{"reason"}},
+		{"getblock", []interface{}{uint64(100)}},
+		{"getblockpeak", nil},
+		{"getblockheader2", []interface{}{uint64(100)}},
+		{"getblockquick", []interface{}{uint64(100), uint64(10)}},
+		{"getblockquick2", []interface{}{uint64(100), uint64(10)}},
+		{"getblockquick_since", []interface{}{uint64(100), uint64(10), [32]byte{1}}},
+		{"getaccount", []interface{}{uint64(100), addr}},
+		{"getaccountnonce", []interface{}{uint64(100), addr}},
+		{"getaccountroots", []interface{}{uint64(100), addr}},
+		{"getaccountvalue", []interface{}{uint64(100), addr, hash}},
+		{"getaccountvaluerange", []interface{}{uint64(100), uint64(102), addr, hash}},
+		{"getaccountroots_range", []interface{}{uint64(100), uint64(102), addr}},
+		{"getlogs", []interface{}{uint64(100), uint64(102), addr, [][]byte{hash, hash}}},
+		{"ticket", []interface{}{uint64(100), addr, uint64(1), uint64(1024), []byte("local"), make([]byte, 65)}},
+		{"portopen", []interface{}{addr, "tcp:80", "rw"}},
+		{"portsend", []interface{}{"ref", []byte("data")}},
+		{"portsend_seq", []interface{}{"ref", uint32(1), []byte("data")}},
+		{"portsend_ack", []interface{}{"ref", uint32(1), []byte("data")}},
+		{"setfleet", []interface{}{addr}},
+		{"getobject", []interface{}{addr}},
+		{"getnode", []interface{}{addr}},
+		{"getstateroots", []interface{}{uint64(100)}},
+		{"sendtransaction", []interface{}{[]byte{0xf8, 0x01}}},
+		{"healthcheck", nil},
+	}
+	for _, tt := range tests {
+		var first []byte
+		for i := 0; i < 100; i++ {
+			buf := &bytes.Buffer{}
+			if _, err := NewMessage(buf, 1, tt.method, tt.args...); err != nil {
+				t.Fatalf("%s: %v", tt.method, err)
+			}
+			if first == nil {
+				first = buf.Bytes()
+			} else if !bytes.Equal(first, buf.Bytes()) {
+				t.Fatalf("%s: encoding %d differs from the first encoding", tt.method, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"io"
+	"runtime/trace"
+)
+
+// newTracedMessage is NewMessage within a trace task of the request, the task
+// ends once the message is encoded so that requests without response don't keep
+// it open. The response is parsed in a child task of the request task
+func newTracedMessage(writer io.Writer, requestID uint64, method string, args ...interface{}) (func(buffer []byte) (interface{}, error), error) {
+	ctx, task := trace.NewTask(context.Background(), "rlpv2."+method)
+	var parse func(buffer []byte) (interface{}, error)
+	var err error
+	trace.WithRegion(ctx, "rlpv2.encode."+method, func() {
+		parse, err = NewMessage(writer, requestID, method, args...)
+	})
+	task.End()
+	if err != nil || parse == nil {
+		return parse, err
+	}
+	return func(buffer []byte) (res interface{}, err error) {
+		responseCtx, responseTask := trace.NewTask(ctx, "rlpv2.response."+method)
+		defer responseTask.End()
+		trace.WithRegion(responseCtx, "rlpv2.parse."+method, func() {
+			res, err = parse(buffer)
+		})
+		return
+	}, nil
+}
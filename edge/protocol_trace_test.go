@@ -0,0 +1,37 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"runtime/trace"
+	"testing"
+
+	"github.com/diodechain/diode_client/crypto/secp256k1"
+)
+
+func TestRLPProtocolTracing(t *testing.T) {
+	if trace.IsEnabled() {
+		t.Skip("tracing is already enabled")
+	}
+	items := newTestBlockHeaderItems(t)
+	response := encodeTestResponse(t, "response", items, secp256k1.CompressPubkeyBytes(testHeaderMinerPubkey))
+	out := &bytes.Buffer{}
+	if err := trace.Start(out); err != nil {
+		t.Fatal(err)
+	}
+	parse, err := NewRLPProtocol(WithTracing(true)).NewMessage(&bytes.Buffer{}, 1, "getblockheader2", uint64(testHeaderNumber))
+	if err == nil {
+		_, err = parse(response)
+	}
+	trace.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, region := range []string{"rlpv2.getblockheader2", "rlpv2.encode.getblockheader2", "rlpv2.response.getblockheader2", "rlpv2.parse.getblockheader2"} {
+		if !bytes.Contains(out.Bytes(), []byte(region)) {
+			t.Errorf("trace is missing task or region %s", region)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/diodechain/diode_client/blockquick"
+	"github.com/diodechain/diode_client/rlp"
+	"github.com/diodechain/diode_client/util"
+)
+
+const (
+	// RLP_V2 is the protocol version of RLPProtocol
+	RLP_V2 uint64 = 2
+	// RLP_V3 is the protocol version of RLPV3Protocol
+	RLP_V3 uint64 = 3
+)
+
+var (
+	errInvalidRequestID = fmt.Errorf("invalid uvarint request id")
+)
+
+// RLPV3Protocol is the RLP_V3 edge protocol, the messages start with the uvarint
+// request id followed by the rlp encoded payload of the RLP_V2 message
+type RLPV3Protocol struct {
+	RLPProtocol
+}
+
+// NewMessage encodes the request, see RLPProtocol.NewMessage
+func (p RLPV3Protocol) NewMessage(writer io.Writer, requestID uint64, method string, args ...interface{}) (func(buffer []byte) (interface{}, error), error) {
+	buf := &bytes.Buffer{}
+	parse, err := p.RLPProtocol.NewMessage(buf, requestID, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := encodeV3Message(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(msg); err != nil {
+		return nil, err
+	}
+	if parse == nil {
+		return nil, nil
+	}
+	return func(buffer []byte) (interface{}, error) {
+		msg, err := decodeV3Message(buffer)
+		if err != nil {
+			return nil, err
+		}
+		return parse(msg)
+	}, nil
+}
+
+// Parse parses the response, error or inbound request in buffer
+func (p RLPV3Protocol) Parse(buffer []byte) (interface{}, error) {
+	msg, err := decodeV3Message(buffer)
+	if err != nil {
+		return nil, err
+	}
+	return p.RLPProtocol.Parse(msg)
+}
+
+// ParseBlockHeaders parses the block headers of the window, see EdgeProtocol
+func (p RLPV3Protocol) ParseBlockHeaders(raw []byte, size int) ([]*blockquick.BlockHeader, error) {
+	maxSize := p.MaxMessageSize()
+	return parseBlockHeaders(raw, size, func(msg []byte) ([]byte, error) {
+		msg, err := decodeV3Message(msg)
+		if err != nil {
+			return nil, err
+		}
+		return msg, validateMessageSize(msg, maxSize)
+	})
+}
+
+// encodeV3Message returns the RLP_V3 message of the RLP_V2 message
+func encodeV3Message(msg []byte) ([]byte, error) {
+	content, _, err := rlp.SplitList(msg)
+	if err != nil {
+		return nil, err
+	}
+	rawID, payload, err := rlp.SplitString(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(rawID) > 8 {
+		return nil, errInvalidRequestID
+	}
+	var requestID [8]byte
+	copy(requestID[8-len(rawID):], rawID)
+	buf := &bytes.Buffer{}
+	if err = util.WriteUvarint(buf, binary.BigEndian.Uint64(requestID[:])); err != nil {
+		return nil, err
+	}
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+// decodeV3Message returns the RLP_V2 message of the RLP_V3 message
+func decodeV3Message(msg []byte) ([]byte, error) {
+	r := bytes.NewReader(msg)
+	requestID, err := util.ReadUvarint(r)
+	if err != nil {
+		return nil, errInvalidRequestID
+	}
+	return rlp.EncodeToBytes([]interface{}{requestID, rlp.RawValue(msg[len(msg)-r.Len():])})
+}
@@ -0,0 +1,65 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/diodechain/diode_client/rlp"
+)
+
+func TestRLPV3Message(t *testing.T) {
+	p := RLPV3Protocol{}
+	for _, requestID := range []uint64{0, 127, 128, math.MaxUint64} {
+		v2 := &bytes.Buffer{}
+		if _, err := p.RLPProtocol.NewMessage(v2, requestID, "getblockpeak"); err != nil {
+			t.Fatal(err)
+		}
+		v3 := &bytes.Buffer{}
+		if _, err := p.NewMessage(v3, requestID, "getblockpeak"); err != nil {
+			t.Fatal(err)
+		}
+		content, _, err := rlp.SplitList(v2.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, payload, err := rlp.SplitString(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasSuffix(v3.Bytes(), payload) {
+			t.Fatalf("%d: the v3 payload should match the v2 payload", requestID)
+		}
+		if requestID < 128 && v3.Len() != len(payload)+1 {
+			t.Fatalf("%d: small request ids should be encoded in one byte", requestID)
+		}
+		msg, err := decodeV3Message(v3.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(msg, v2.Bytes()) {
+			t.Fatalf("%d: the decoded v3 message should match the v2 message", requestID)
+		}
+	}
+}
+
+func TestRLPV3Parse(t *testing.T) {
+	p := RLPV3Protocol{}
+	msg, err := encodeV3Message(encodeTestResponse(t, "portclose", "ref"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := p.Parse(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if portClose, ok := res.(*PortClose); !ok || portClose.Ref != "ref" {
+		t.Fatalf("expected portclose of ref but got %+v", res)
+	}
+	if _, err = p.Parse([]byte{0x80}); err != errInvalidRequestID {
+		t.Fatalf("expected errInvalidRequestID but got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrReorderBufferFull = fmt.Errorf("too many out of order frames")
+)
+
+// ReorderBuffer holds out of order portsend_seq frames of one ref and delivers
+// their data in sequence order
+type ReorderBuffer struct {
+	mx       sync.Mutex
+	next     uint32
+	capacity int
+	pending  map[uint32][]byte
+	out      chan<- []byte
+}
+
+// NewReorderBuffer returns a buffer that expects the sequence number next first and
+// holds up to capacity out of order frames, data is written to out in order
+func NewReorderBuffer(next uint32, capacity int, out chan<- []byte) *ReorderBuffer {
+	return &ReorderBuffer{
+		next:     next,
+		capacity: capacity,
+		pending:  make(map[uint32][]byte),
+		out:      out,
+	}
+}
+
+// Push adds the frame and delivers all frames that are in sequence now, frames
+// that were already delivered are ignored. Push blocks while out is full.
+func (rb *ReorderBuffer) Push(seq uint32, data []byte) error {
+	rb.mx.Lock()
+	defer rb.mx.Unlock()
+	// the sequence number wraps around, so compare the distance to next
+	if int32(seq-rb.next) < 0 {
+		return nil
+	}
+	if seq != rb.next {
+		if _, ok := rb.pending[seq]; ok {
+			return nil
+		}
+		if len(rb.pending) >= rb.capacity {
+			return ErrReorderBufferFull
+		}
+		rb.pending[seq] = data
+		return nil
+	}
+	rb.out <- data
+	rb.next++
+	for {
+		data, ok := rb.pending[rb.next]
+		if !ok {
+			return nil
+		}
+		delete(rb.pending, rb.next)
+		rb.out <- data
+		rb.next++
+	}
+}
+
+// Pending returns the number of frames waiting for a missing sequence number
+func (rb *ReorderBuffer) Pending() int {
+	rb.mx.Lock()
+	defer rb.mx.Unlock()
+	return len(rb.pending)
+}
@@ -0,0 +1,62 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReorderBuffer(t *testing.T) {
+	out := make(chan []byte, 3)
+	rb := NewReorderBuffer(1, 4, out)
+	for _, seq := range []uint32{3, 1, 2} {
+		if err := rb.Push(seq, []byte{byte(seq)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for want := byte(1); want <= 3; want++ {
+		if got := <-out; !bytes.Equal(got, []byte{want}) {
+			t.Fatalf("expected frame %d but got %v", want, got)
+		}
+	}
+	if rb.Pending() != 0 {
+		t.Fatalf("expected no pending frames but got %d", rb.Pending())
+	}
+	// duplicates are ignored
+	if err := rb.Push(2, []byte{2}); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 0 {
+		t.Fatalf("duplicate frame should not be delivered")
+	}
+}
+
+func TestReorderBufferFull(t *testing.T) {
+	rb := NewReorderBuffer(0, 1, make(chan []byte, 1))
+	if err := rb.Push(2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := rb.Push(3, nil); err != ErrReorderBufferFull {
+		t.Fatalf("expected ErrReorderBufferFull but got %v", err)
+	}
+}
+
+func TestParseInboundPortSendSeqRequest(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if _, err := NewMessage(buf, 1, "portsend_seq", "ref7", uint32(3), []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	req, err := parseInboundRequest(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portSend, ok := req.(*SequencedPortSend)
+	if !ok {
+		t.Fatalf("expected *SequencedPortSend but got %T", req)
+	}
+	if portSend.Ref != "ref7" || portSend.Seq != 3 || string(portSend.Data) != "data" {
+		t.Fatalf("unexpected frame %+v", portSend)
+	}
+}
@@ -7,3 +7,13 @@ type generalRequest struct {
 	RequestID uint64
 	Payload   []interface{}
 }
+
+type blockquickSinceRequest struct {
+	RequestID uint64
+	Payload   struct {
+		Method     string
+		LastValid  uint64
+		WindowSize uint64
+		SinceHash  [32]byte
+	}
+}
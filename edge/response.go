@@ -4,7 +4,11 @@
 package edge
 
 import (
+	"math/big"
 	"reflect"
+	"strings"
+
+	"github.com/diodechain/diode_client/rlp"
 )
 
 // Response struct
@@ -52,17 +56,37 @@ type blockResponse struct {
 			}
 			Transactions struct {
 				Key   string
-				Value []interface{}
+				Value []rlp.RawValue
 			}
 		}
 	}
 }
 
+type transactionItem struct {
+	Hash  []byte
+	From  []byte
+	To    []byte
+	Value *big.Int
+	Nonce uint64
+	Data  []byte
+	Sig   []byte
+}
+
+// transactionHashItem is the part of the transaction that is hashed
+type transactionHashItem struct {
+	Nonce uint64
+	From  [20]byte
+	To    [20]byte
+	Value *big.Int
+	Data  []byte
+	Sig   [65]byte
+}
+
 type blockHeaderResponse struct {
 	RequestID uint64
 	Payload   struct {
 		Type        string
-		Items       [8]Item
+		Items       []Item
 		MinerPubkey []byte
 	}
 }
@@ -112,11 +136,21 @@ type accountResponse struct {
 	RequestID uint64
 	Payload   struct {
 		Type        string
-		Items       [4]Item
+		Items       []Item
 		MerkleProof []interface{}
 	}
 }
 
+// accountNonceResponse is the accountResponse with the merkle proof left encoded
+type accountNonceResponse struct {
+	RequestID uint64
+	Payload   struct {
+		Type        string
+		Items       []Item
+		MerkleProof rlp.RawValue
+	}
+}
+
 type accountRootsResponse struct {
 	RequestID uint64
 	Payload   struct {
@@ -133,6 +167,42 @@ type accountValueResponse struct {
 	}
 }
 
+type accountValueRangeResponse struct {
+	RequestID uint64
+	Payload   struct {
+		Type   string
+		Values []struct {
+			BlockNumber uint64
+			MerkleProof []interface{}
+		}
+	}
+}
+
+type accountRootsRangeResponse struct {
+	RequestID uint64
+	Payload   struct {
+		Type  string
+		Roots []struct {
+			BlockNumber  uint64
+			AccountRoots [][]byte
+		}
+	}
+}
+
+type logsResponse struct {
+	RequestID uint64
+	Payload   struct {
+		Type string
+		Logs []struct {
+			Address     []byte
+			Topics      [][]byte
+			Data        []byte
+			BlockNumber uint64
+			TxHash      []byte
+		}
+	}
+}
+
 type portSendResponse struct {
 	RequestID uint64
 	Payload   struct {
@@ -141,6 +211,24 @@ type portSendResponse struct {
 	}
 }
 
+type setFleetResponse struct {
+	RequestID uint64
+	Payload   struct {
+		Type   string
+		Result string
+	}
+}
+
+// portSendAckResponse is the server acknowledgement of a portsend_ack
+type portSendAckResponse struct {
+	RequestID uint64
+	Payload   struct {
+		Type string
+		Ref  string
+		Seq  uint32
+	}
+}
+
 type portOpenResponse struct {
 	RequestID uint64
 	Payload   struct {
@@ -150,6 +238,17 @@ type portOpenResponse struct {
 	}
 }
 
+// portOpenReply is the response of a device to an inbound portopen request
+type portOpenReply struct {
+	RequestID uint64
+	Payload   struct {
+		Type   string
+		Ref    string
+		Result string
+		Reason []string `rlp:"tail"`
+	}
+}
+
 type objectResponse struct {
 	RequestID uint64
 	Payload   struct {
@@ -192,6 +291,27 @@ type transactionResponse struct {
 	}
 }
 
+type sendTransactionResponse struct {
+	RequestID uint64
+	Payload   struct {
+		Type   string
+		TxHash []byte
+		Status uint8
+	}
+}
+
+// cpu and memory usage are encoded in hundredths of a percent
+type healthCheckResponse struct {
+	RequestID uint64
+	Payload   struct {
+		Type            string
+		CPUUsage        uint64
+		MemUsage        uint64
+		OpenConnections uint64
+		UptimeSeconds   uint64
+	}
+}
+
 // type portSendResponse struct {}
 // type portCloseResponse struct {}
 
@@ -218,3 +338,24 @@ func findItemInItems(items interface{}, key string) (item Item, err error) {
 	err = errKeyNotFoundInItems
 	return
 }
+
+// findItemInItemsCI returns the item whose key equals key ignoring the case
+func findItemInItemsCI(items []Item, key string) (Item, error) {
+	for _, item := range items {
+		if strings.EqualFold(item.Key, key) {
+			return item, nil
+		}
+	}
+	return Item{}, errKeyNotFoundInItems
+}
+
+// lookupItem returns the item with the exact key and falls back to
+// a case insensitive comparison
+func lookupItem(items []Item, key string) (Item, error) {
+	for _, item := range items {
+		if item.Key == key {
+			return item, nil
+		}
+	}
+	return findItemInItemsCI(items, key)
+}
@@ -0,0 +1,97 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"sync"
+)
+
+// ResponseCallback is invoked with the parsed response or the error of a request
+type ResponseCallback func(res interface{}, err error)
+
+type dispatchEntry struct {
+	parse    func(buffer []byte) (interface{}, error)
+	callback ResponseCallback
+}
+
+// ResponseDispatcher dispatches responses to the registered callbacks by request id
+type ResponseDispatcher struct {
+	mx      sync.Mutex
+	entries map[uint64]dispatchEntry
+	filter  *MonotonicResponseFilter
+}
+
+// NewResponseDispatcher returns an empty response dispatcher
+func NewResponseDispatcher() *ResponseDispatcher {
+	return &ResponseDispatcher{entries: make(map[uint64]dispatchEntry)}
+}
+
+// SetFilter records the issued request ids and the rejected responses in filter,
+// it should be set before the first request is registered
+func (rd *ResponseDispatcher) SetFilter(filter *MonotonicResponseFilter) {
+	rd.mx.Lock()
+	defer rd.mx.Unlock()
+	rd.filter = filter
+}
+
+// Register adds the callback for the given request id, parse is the
+// response parser returned by NewMessage
+func (rd *ResponseDispatcher) Register(requestID uint64, parse func(buffer []byte) (interface{}, error), callback ResponseCallback) {
+	rd.mx.Lock()
+	defer rd.mx.Unlock()
+	rd.entries[requestID] = dispatchEntry{parse: parse, callback: callback}
+	if rd.filter != nil {
+		rd.filter.issue(requestID)
+	}
+}
+
+// Cancel removes the callback of the given request id
+func (rd *ResponseDispatcher) Cancel(requestID uint64) {
+	rd.mx.Lock()
+	defer rd.mx.Unlock()
+	delete(rd.entries, requestID)
+	if rd.filter != nil {
+		rd.filter.complete(requestID)
+	}
+}
+
+// Len returns the number of pending callbacks
+func (rd *ResponseDispatcher) Len() int {
+	rd.mx.Lock()
+	defer rd.mx.Unlock()
+	return len(rd.entries)
+}
+
+// Dispatch parses the response and invokes the registered callback, it returns
+// false and rejects the response if the request id was never issued or was
+// already answered or cancelled
+func (rd *ResponseDispatcher) Dispatch(buffer []byte) bool {
+	requestID := ResponseID(buffer)
+	rd.mx.Lock()
+	entry, ok := rd.entries[requestID]
+	if ok {
+		delete(rd.entries, requestID)
+		if rd.filter != nil {
+			rd.filter.complete(requestID)
+		}
+	} else if rd.filter != nil {
+		rd.filter.reject(requestID)
+	}
+	rd.mx.Unlock()
+	if !ok {
+		return false
+	}
+	msg := Message{Buffer: buffer}
+	if msg.IsError() {
+		rpcErr, _ := parseError(buffer)
+		entry.callback(nil, rpcErr)
+		return true
+	}
+	if entry.parse == nil {
+		entry.callback(nil, nil)
+		return true
+	}
+	entry.callback(entry.parse(buffer))
+	return true
+}
@@ -0,0 +1,93 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestResponseDispatcher(t *testing.T) {
+	dispatcher := NewResponseDispatcher()
+	calls := make([]int, 10)
+	results := make([]uint64, 10)
+	var mx sync.Mutex
+	for i := 0; i < 10; i++ {
+		i := i
+		dispatcher.Register(uint64(i+1), parseBlockPeakResponse, func(res interface{}, err error) {
+			if err != nil {
+				t.Errorf("unexpected error %v", err)
+				return
+			}
+			mx.Lock()
+			defer mx.Unlock()
+			calls[i]++
+			results[i], _ = res.(uint64)
+		})
+	}
+	var wg sync.WaitGroup
+	for _, i := range []int{7, 2, 9, 0, 5, 1, 8, 3, 6, 4} {
+		buf := &bytes.Buffer{}
+		if _, err := NewResponseMessage(buf, uint64(i+1), "response", "getblockpeak", uint64(1000+i)); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(buffer []byte) {
+			defer wg.Done()
+			if !dispatcher.Dispatch(buffer) {
+				t.Errorf("response %d was not dispatched", ResponseID(buffer))
+			}
+		}(buf.Bytes())
+	}
+	wg.Wait()
+	for i := 0; i < 10; i++ {
+		if calls[i] != 1 {
+			t.Errorf("callback %d should be invoked once but was invoked %d times", i, calls[i])
+		}
+		if results[i] != uint64(1000+i) {
+			t.Errorf("callback %d expected block %d but got %d", i, 1000+i, results[i])
+		}
+	}
+	if dispatcher.Len() != 0 {
+		t.Errorf("dispatcher should be empty but has %d callbacks", dispatcher.Len())
+	}
+}
+
+func TestResponseDispatcherCancel(t *testing.T) {
+	dispatcher := NewResponseDispatcher()
+	dispatcher.Register(1, parseBlockPeakResponse, func(res interface{}, err error) {
+		t.Errorf("cancelled callback should not be invoked")
+	})
+	dispatcher.Cancel(1)
+	buf := &bytes.Buffer{}
+	if _, err := NewResponseMessage(buf, 1, "response", "getblockpeak", uint64(1)); err != nil {
+		t.Fatal(err)
+	}
+	if dispatcher.Dispatch(buf.Bytes()) {
+		t.Errorf("cancelled response should not be dispatched")
+	}
+}
+
+func TestResponseDispatcherErrorInPayload(t *testing.T) {
+	dispatcher := NewResponseDispatcher()
+	var portOpen *PortOpen
+	dispatcher.Register(1, parsePortOpenResponse, func(res interface{}, err error) {
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		portOpen, _ = res.(*PortOpen)
+	})
+	// the ref contains the error pivot but the response type is "response"
+	buf := &bytes.Buffer{}
+	if _, err := NewResponseMessage(buf, 1, "response", "portopen", "ok", "my-error-ref"); err != nil {
+		t.Fatal(err)
+	}
+	if !dispatcher.Dispatch(buf.Bytes()) {
+		t.Fatalf("response was not dispatched")
+	}
+	if portOpen == nil || !portOpen.Ok || portOpen.Ref != "my-error-ref" {
+		t.Fatalf("expected ok portopen but got %+v", portOpen)
+	}
+}
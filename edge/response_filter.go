@@ -0,0 +1,86 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"sync"
+)
+
+// MonotonicResponseFilter tracks the in-flight request ids of a ResponseDispatcher
+// and counts the responses it rejects because their request id has no pending
+// request. The request ids are issued in increasing order, so a rejected id up to
+// the highest issued id was already answered or cancelled and any higher id was
+// never issued
+type MonotonicResponseFilter struct {
+	mx       sync.Mutex
+	lastID   uint64
+	inFlight map[uint64]struct{}
+	replayed uint64
+	unknown  uint64
+}
+
+// NewMonotonicResponseFilter returns a filter without issued request ids
+func NewMonotonicResponseFilter() *MonotonicResponseFilter {
+	return &MonotonicResponseFilter{inFlight: make(map[uint64]struct{})}
+}
+
+// issue records the request id of a registered request
+func (f *MonotonicResponseFilter) issue(requestID uint64) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if requestID > f.lastID {
+		f.lastID = requestID
+	}
+	f.inFlight[requestID] = struct{}{}
+}
+
+// complete removes the request id of an answered or cancelled request
+func (f *MonotonicResponseFilter) complete(requestID uint64) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	delete(f.inFlight, requestID)
+}
+
+// reject records the response to a request id without pending request
+func (f *MonotonicResponseFilter) reject(requestID uint64) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if requestID > 0 && requestID <= f.lastID {
+		f.replayed++
+	} else {
+		f.unknown++
+	}
+}
+
+// CheckResponse returns true if the request id is in flight, it returns false
+// if the request id was never issued or was already answered or cancelled
+func (f *MonotonicResponseFilter) CheckResponse(requestID uint64) bool {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	_, ok := f.inFlight[requestID]
+	return ok
+}
+
+// Replayed returns the number of rejected responses to already answered or
+// cancelled requests
+func (f *MonotonicResponseFilter) Replayed() uint64 {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.replayed
+}
+
+// Unknown returns the number of rejected responses to request ids that were
+// never issued
+func (f *MonotonicResponseFilter) Unknown() uint64 {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.unknown
+}
+
+// Rejected returns the number of rejected responses
+func (f *MonotonicResponseFilter) Rejected() uint64 {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.replayed + f.unknown
+}
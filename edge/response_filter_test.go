@@ -0,0 +1,73 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMonotonicResponseFilter(t *testing.T) {
+	dispatcher := NewResponseDispatcher()
+	filter := NewMonotonicResponseFilter()
+	dispatcher.SetFilter(filter)
+	calls := make(map[uint64]int)
+	for requestID := uint64(1); requestID <= 3; requestID++ {
+		requestID := requestID
+		dispatcher.Register(requestID, parseBlockPeakResponse, func(res interface{}, err error) {
+			calls[requestID]++
+		})
+	}
+	dispatch := func(requestID uint64) bool {
+		buf := &bytes.Buffer{}
+		if _, err := NewResponseMessage(buf, requestID, "response", "getblockpeak", uint64(1)); err != nil {
+			t.Fatal(err)
+		}
+		return dispatcher.Dispatch(buf.Bytes())
+	}
+	for requestID := uint64(1); requestID <= 3; requestID++ {
+		if !filter.CheckResponse(requestID) {
+			t.Errorf("request %d should be in flight", requestID)
+		}
+	}
+	if !dispatch(2) {
+		t.Fatalf("response to request 2 should be dispatched")
+	}
+	// duplicate response
+	if filter.CheckResponse(2) {
+		t.Errorf("answered request 2 should not be in flight")
+	}
+	if dispatch(2) {
+		t.Errorf("duplicate response to request 2 should be rejected")
+	}
+	// response after cancel
+	dispatcher.Cancel(3)
+	if filter.CheckResponse(3) {
+		t.Errorf("cancelled request 3 should not be in flight")
+	}
+	if dispatch(3) {
+		t.Errorf("response to cancelled request 3 should be rejected")
+	}
+	// never issued request ids
+	for _, requestID := range []uint64{0, 7} {
+		if dispatch(requestID) {
+			t.Errorf("response to unknown request %d should be rejected", requestID)
+		}
+	}
+	if calls[2] != 1 || calls[3] != 0 {
+		t.Errorf("unexpected callback invocations %v", calls)
+	}
+	if filter.Replayed() != 2 || filter.Unknown() != 2 || filter.Rejected() != 4 {
+		t.Errorf("expected 2 replayed and 2 unknown responses but got %d and %d", filter.Replayed(), filter.Unknown())
+	}
+	if !filter.CheckResponse(1) || filter.CheckResponse(7) {
+		t.Errorf("request 1 is in flight and request 7 was never issued")
+	}
+	if !dispatch(1) {
+		t.Errorf("response to request 1 should be dispatched")
+	}
+	if filter.CheckResponse(1) {
+		t.Errorf("answered request 1 should not be in flight")
+	}
+}
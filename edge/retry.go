@@ -0,0 +1,80 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryableError signals whether the wrapped rpc error is safe to retry
+type RetryableError struct {
+	Err       error
+	Retryable bool
+}
+
+// AsRetryable wraps the error into a RetryableError
+func AsRetryable(err error, retryable bool) *RetryableError {
+	return &RetryableError{Err: err, Retryable: retryable}
+}
+
+func (err *RetryableError) Error() string {
+	return err.Err.Error()
+}
+
+// Unwrap returns the wrapped error, so errors.Is matches the original error
+func (err *RetryableError) Unwrap() error {
+	return err.Err
+}
+
+// IsRetryable returns true if the error or one of the errors it wraps is a retryable RetryableError
+func IsRetryable(err error) bool {
+	var retryable *RetryableError
+	return errors.As(err, &retryable) && retryable.Retryable
+}
+
+// RetryPolicy configures RetryWithBackoff, the backoff starts at MinBackoff
+// and is multiplied by Factor after every attempt up to MaxBackoff
+type RetryPolicy struct {
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	Factor      float64
+}
+
+// DefaultRetryPolicy is the retry policy used for rpc calls
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	MinBackoff:  100 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+	Factor:      2,
+}
+
+// RetryWithBackoff calls fn until it succeeds, returns an error that is not retryable,
+// the attempts of the policy are used up or the context is done
+func RetryWithBackoff(ctx context.Context, fn func() error, policy RetryPolicy) (err error) {
+	backoff := policy.MinBackoff
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || !IsRetryable(err) {
+			return
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if policy.Factor > 1 {
+			backoff = time.Duration(float64(backoff) * policy.Factor)
+		}
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testRetryPolicy = RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, Factor: 2}
+
+func TestRetryWithBackoff(t *testing.T) {
+	attempts := 0
+	err := RetryWithBackoff(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return AsRetryable(ErrTicketTooLow, true)
+		}
+		return nil
+	}, testRetryPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts but got %d", attempts)
+	}
+}
+
+func TestRetryWithBackoffNotRetryable(t *testing.T) {
+	attempts := 0
+	err := RetryWithBackoff(context.Background(), func() error {
+		attempts++
+		return AsRetryable(ErrTicketTooOld, false)
+	}, testRetryPolicy)
+	if !errors.Is(err, ErrTicketTooOld) {
+		t.Errorf("expected ErrTicketTooOld but got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt but got %d", attempts)
+	}
+	attempts = 0
+	err = RetryWithBackoff(context.Background(), func() error {
+		attempts++
+		return AsRetryable(ErrTicketTooLow, true)
+	}, testRetryPolicy)
+	if !errors.Is(err, ErrTicketTooLow) || attempts != testRetryPolicy.MaxAttempts {
+		t.Errorf("expected ErrTicketTooLow after %d attempts but got %v after %d", testRetryPolicy.MaxAttempts, err, attempts)
+	}
+}
+
+func TestParseDeviceTicketRetryable(t *testing.T) {
+	tests := []struct {
+		payload   []interface{}
+		err       error
+		retryable bool
+	}{
+		{[]interface{}{"response", "too_low", make([]byte, 32), uint64(1), uint64(1), []byte{}, make([]byte, 65)}, ErrTicketTooLow, true},
+		{[]interface{}{"response", "too_old", []byte{10}}, ErrTicketTooOld, false},
+	}
+	for _, test := range tests {
+		buffer := encodeTestResponse(t, test.payload...)
+		res, err := parseDeviceTicketResponse(buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ticket := res.(DeviceTicket)
+		if !errors.Is(ticket.Err, test.err) || IsRetryable(ticket.Err) != test.retryable {
+			t.Errorf("expected %v with retryable %v but got %v", test.err, test.retryable, ticket.Err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"fmt"
+
+	"github.com/diodechain/diode_client/rlp"
+)
+
+// DefaultMaxMessageSize is the largest message in bytes that the parsers decode
+const DefaultMaxMessageSize = 4 * 1024 * 1024
+
+var (
+	ErrNotRLPFrame     = fmt.Errorf("message is not a rlp frame")
+	ErrMessageTooLarge = fmt.Errorf("message is larger than the maximum message size")
+)
+
+// ErrUnexpectedFieldCount is returned when a rlp list has an unexpected number of elements
+type ErrUnexpectedFieldCount struct {
+	Expected int
+	Actual   int
+}
+
+func (err ErrUnexpectedFieldCount) Error() string {
+	return fmt.Sprintf("unexpected rlp field count %d, expected %d", err.Actual, err.Expected)
+}
+
+// ValidateRLPListLength checks the element count of the rlp list in buffer without decoding it
+func ValidateRLPListLength(buffer []byte, expectedLength int) error {
+	content, _, err := rlp.SplitList(buffer)
+	if err != nil {
+		return err
+	}
+	count, err := rlp.CountValues(content)
+	if err != nil {
+		return err
+	}
+	if count != expectedLength {
+		return ErrUnexpectedFieldCount{Expected: expectedLength, Actual: count}
+	}
+	return nil
+}
+
+// validateMessageSize returns ErrMessageTooLarge if buffer is larger than maxSize bytes
+func validateMessageSize(buffer []byte, maxSize int) error {
+	if len(buffer) > maxSize {
+		return fmt.Errorf("%w: %d bytes", ErrMessageTooLarge, len(buffer))
+	}
+	return nil
+}
+
+// ValidateFrame checks that buffer starts with a rlp list prefix, edge messages
+// are always [requestID, payload] lists
+func ValidateFrame(buffer []byte) error {
+	if len(buffer) == 0 || buffer[0] < 0xc0 {
+		return ErrNotRLPFrame
+	}
+	return nil
+}
+
+// validatePayloadLength checks the element count of the payload of a [requestID, payload] message
+func validatePayloadLength(buffer []byte, expectedLength int) error {
+	if err := ValidateFrame(buffer); err != nil {
+		return err
+	}
+	if err := ValidateRLPListLength(buffer, 2); err != nil {
+		return err
+	}
+	content, _, err := rlp.SplitList(buffer)
+	if err != nil {
+		return err
+	}
+	_, _, payload, err := rlp.Split(content)
+	if err != nil {
+		return err
+	}
+	return ValidateRLPListLength(payload, expectedLength)
+}
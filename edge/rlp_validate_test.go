@@ -0,0 +1,106 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/diodechain/diode_client/rlp"
+)
+
+func TestValidateRLPListLength(t *testing.T) {
+	buffer, err := rlp.EncodeToBytes([]interface{}{uint64(1), "two", []byte{3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ValidateRLPListLength(buffer, 3); err != nil {
+		t.Fatal(err)
+	}
+	err = ValidateRLPListLength(buffer, 2)
+	if fieldErr, ok := err.(ErrUnexpectedFieldCount); !ok || fieldErr.Actual != 3 || fieldErr.Expected != 2 {
+		t.Errorf("expected ErrUnexpectedFieldCount{2, 3} but got %v", err)
+	}
+	if err = ValidateRLPListLength([]byte{0x01}, 1); err == nil {
+		t.Errorf("expected error for non list input")
+	}
+}
+
+func TestParseUnexpectedFieldCount(t *testing.T) {
+	// portopen response expects 3 payload fields: type, result, ref
+	buffer := encodeTestResponse(t, "response", "ok")
+	_, err := parsePortOpenResponse(buffer)
+	if fieldErr, ok := err.(ErrUnexpectedFieldCount); !ok || fieldErr.Actual != 2 || fieldErr.Expected != 3 {
+		t.Errorf("expected ErrUnexpectedFieldCount{3, 2} but got %v", err)
+	}
+}
+
+func TestParseMessageSizeLimit(t *testing.T) {
+	var response blockResponse
+	response.RequestID = 1
+	response.Payload.Type = "response"
+	response.Payload.Block.Coinbase.Value = make([]byte, 5*1024*1024)
+	buffer, err := rlp.EncodeToBytes(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = parseBlockResponse(buffer); !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("expected ErrMessageTooLarge but got %v", err)
+	}
+	response.Payload.Block.Coinbase.Value = make([]byte, 1024)
+	if buffer, err = rlp.EncodeToBytes(response); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = parseBlockResponse(buffer); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProtocolMaxMessageSize(t *testing.T) {
+	if size := (RLPProtocol{}).MaxMessageSize(); size != DefaultMaxMessageSize {
+		t.Fatalf("expected default max message size %d but got %d", DefaultMaxMessageSize, size)
+	}
+	protocol := NewRLPProtocol(WithMaxMessageSize(64))
+	parse, err := protocol.NewMessage(&bytes.Buffer{}, 1, "getblockpeak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := parse(encodeTestResponse(t, "response", uint64(42))); err != nil || res != uint64(42) {
+		t.Fatalf("expected block peak 42 but got %v %v", res, err)
+	}
+	large := encodeTestResponse(t, "response", make([]byte, 64))
+	if _, err = parse(large); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge from the parser but got %v", err)
+	}
+	if _, err = protocol.Parse(large); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge from Parse but got %v", err)
+	}
+	if _, _, _, err = protocol.Decode(large); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge from Decode but got %v", err)
+	}
+}
+
+func TestValidateFrame(t *testing.T) {
+	http := []byte("HTTP/1.1 200 OK")
+	if err := ValidateFrame(http); err != ErrNotRLPFrame {
+		t.Errorf("expected ErrNotRLPFrame but got %v", err)
+	}
+	if err := ValidateFrame(nil); err != ErrNotRLPFrame {
+		t.Errorf("expected ErrNotRLPFrame for empty buffer but got %v", err)
+	}
+	if _, err := parseResponse(http); err != ErrNotRLPFrame {
+		t.Errorf("expected ErrNotRLPFrame from parseResponse but got %v", err)
+	}
+	if _, err := parsePortOpenResponse(http); err != ErrNotRLPFrame {
+		t.Errorf("expected ErrNotRLPFrame from parsePortOpenResponse but got %v", err)
+	}
+	if _, err := parseInboundRequest(http); err != ErrNotRLPFrame {
+		t.Errorf("expected ErrNotRLPFrame from parseInboundRequest but got %v", err)
+	}
+	buffer := encodeTestResponse(t, "response", uint64(42))
+	if err := ValidateFrame(buffer); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,107 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var (
+	ErrRouterClosed = fmt.Errorf("router is closed")
+)
+
+// Router routes the responses of a shared connection to the waiting
+// requests by request id
+type Router struct {
+	conn       io.ReadWriteCloser
+	dispatcher *ResponseDispatcher
+	writeMx    sync.Mutex
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	err       error
+}
+
+// NewRouter returns a router on conn and starts reading its responses
+func NewRouter(conn io.ReadWriteCloser) *Router {
+	r := &Router{
+		conn:       conn,
+		dispatcher: NewResponseDispatcher(),
+		closed:     make(chan struct{}),
+	}
+	go r.readLoop()
+	return r
+}
+
+// SendAndWait sends the raw message and blocks until the response with
+// requestID arrived or ctx expired, decode parses the response
+func (r *Router) SendAndWait(ctx context.Context, raw []byte, requestID uint64, decode func([]byte) (interface{}, error)) (interface{}, error) {
+	result := make(chan sessionResult, 1)
+	r.dispatcher.Register(requestID, decode, func(res interface{}, err error) {
+		result <- sessionResult{res: res, err: err}
+	})
+	if err := r.write(raw); err != nil {
+		r.dispatcher.Cancel(requestID)
+		return nil, err
+	}
+	select {
+	case res := <-result:
+		return res.res, res.err
+	case <-ctx.Done():
+		r.dispatcher.Cancel(requestID)
+		return nil, ctx.Err()
+	case <-r.closed:
+		r.dispatcher.Cancel(requestID)
+		return nil, r.err
+	}
+}
+
+// Close closes the connection, waiting requests return ErrRouterClosed
+func (r *Router) Close() error {
+	return r.closeWithError(ErrRouterClosed)
+}
+
+func (r *Router) closeWithError(err error) (cerr error) {
+	r.closeOnce.Do(func() {
+		r.err = err
+		close(r.closed)
+		cerr = r.conn.Close()
+	})
+	return
+}
+
+// write sends the message with its two bytes length prefix
+func (r *Router) write(msg []byte) error {
+	frame, err := encodeFrame(msg, false)
+	if err != nil {
+		return err
+	}
+	r.writeMx.Lock()
+	defer r.writeMx.Unlock()
+	select {
+	case <-r.closed:
+		return r.err
+	default:
+	}
+	_, err = r.conn.Write(frame)
+	return err
+}
+
+// readLoop reads the responses and dispatches each of them in its own goroutine,
+// inbound requests are dropped
+func (r *Router) readLoop() {
+	for {
+		msg, err := readFrame(r.conn, false)
+		if err != nil {
+			r.closeWithError(err)
+			return
+		}
+		if msg.IsResponse() {
+			go r.dispatcher.Dispatch(msg.Buffer)
+		}
+	}
+}
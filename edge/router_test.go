@@ -0,0 +1,175 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diodechain/diode_client/rlp"
+)
+
+// testReverseServer collects n getblockpeak requests and answers them in
+// reverse order with the request id as block peak
+func testReverseServer(t *testing.T, conn net.Conn, n int) {
+	var requestIDs []uint64
+	lenByt := make([]byte, 2)
+	for len(requestIDs) < n {
+		if _, err := io.ReadFull(conn, lenByt); err != nil {
+			return
+		}
+		buffer := make([]byte, binary.BigEndian.Uint16(lenByt))
+		if _, err := io.ReadFull(conn, buffer); err != nil {
+			return
+		}
+		requestIDs = append(requestIDs, ResponseID(buffer))
+	}
+	for i := len(requestIDs) - 1; i >= 0; i-- {
+		res, err := rlp.EncodeToBytes([]interface{}{requestIDs[i], []interface{}{"response", requestIDs[i]}})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		frame := make([]byte, 2)
+		binary.BigEndian.PutUint16(frame, uint16(len(res)))
+		if _, err = conn.Write(append(frame, res...)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRouterConcurrentRequests(t *testing.T) {
+	const n = 50
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	clientConn, serverConn := net.Pipe()
+	go testReverseServer(t, serverConn, n)
+	router := NewRouter(clientConn)
+	defer router.Close()
+
+	var wg sync.WaitGroup
+	for i := uint64(1); i <= n; i++ {
+		wg.Add(1)
+		go func(requestID uint64) {
+			defer wg.Done()
+			buf := &bytes.Buffer{}
+			parse, err := NewMessage(buf, requestID, "getblockpeak")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res, err := router.SendAndWait(ctx, buf.Bytes(), requestID, parse)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if res.(uint64) != requestID {
+				t.Errorf("request %d got the response of %d", requestID, res)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestRouterTimeout(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	go io.Copy(ioutil.Discard, serverConn)
+	router := NewRouter(clientConn)
+	defer router.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	buf := &bytes.Buffer{}
+	parse, err := NewMessage(buf, 1, "getblockpeak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = router.SendAndWait(ctx, buf.Bytes(), 1, parse); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded but got %v", err)
+	}
+	if router.dispatcher.Len() != 0 {
+		t.Fatalf("expected the callback to be removed")
+	}
+
+	router.Close()
+	if _, err = router.SendAndWait(context.Background(), buf.Bytes(), 2, parse); err != ErrRouterClosed {
+		t.Fatalf("expected ErrRouterClosed but got %v", err)
+	}
+}
+
+// testAckServer acknowledges the portsend_ack requests with their ref and sequence
+func testAckServer(t *testing.T, conn net.Conn) {
+	lenByt := make([]byte, 2)
+	for {
+		if _, err := io.ReadFull(conn, lenByt); err != nil {
+			return
+		}
+		buffer := make([]byte, binary.BigEndian.Uint16(lenByt))
+		if _, err := io.ReadFull(conn, buffer); err != nil {
+			return
+		}
+		var request struct {
+			RequestID uint64
+			Payload   struct {
+				Method string
+				Ref    string
+				Seq    uint32
+				Data   []byte
+			}
+		}
+		if err := rlp.DecodeBytes(buffer, &request); err != nil {
+			t.Error(err)
+			return
+		}
+		res := &bytes.Buffer{}
+		if _, err := NewResponseMessage(res, request.RequestID, "response", "portsend_ack", request.Payload.Ref, request.Payload.Seq); err != nil {
+			t.Error(err)
+			return
+		}
+		frame := make([]byte, 2)
+		binary.BigEndian.PutUint16(frame, uint16(res.Len()))
+		if _, err := conn.Write(append(frame, res.Bytes()...)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRouterPortSendAck(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	clientConn, serverConn := net.Pipe()
+	go testAckServer(t, serverConn)
+	router := NewRouter(clientConn)
+	defer router.Close()
+
+	var acks []uint32
+	for seq := uint32(1); seq <= 5; seq++ {
+		buf := &bytes.Buffer{}
+		parse, err := NewMessage(buf, uint64(seq), "portsend_ack", "ref1", seq, []byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := router.SendAndWait(ctx, buf.Bytes(), uint64(seq), parse)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ack := res.(*PortSend)
+		if ack.Ref != "ref1" || !ack.HasSeq || !ack.Ok {
+			t.Fatalf("unexpected ack %+v", ack)
+		}
+		acks = append(acks, ack.Seq)
+	}
+	for i, seq := range acks {
+		if seq != uint32(i+1) {
+			t.Fatalf("expected ack sequences 1 to 5 but got %v", acks)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"sync"
+)
+
+// DefaultSeenMessagesWindow is the default number of sequence numbers remembered per ref
+const DefaultSeenMessagesWindow = 64
+
+type seenWindow struct {
+	seen  map[uint32]struct{}
+	order []uint32
+	next  int
+}
+
+// SeenMessages filters duplicate port data frames, it remembers the last
+// window sequence numbers of each ref
+type SeenMessages struct {
+	window int
+	mx     sync.Mutex
+	refs   map[string]*seenWindow
+}
+
+// NewSeenMessages returns a filter that remembers window sequence numbers per ref
+func NewSeenMessages(window int) *SeenMessages {
+	if window <= 0 {
+		window = DefaultSeenMessagesWindow
+	}
+	return &SeenMessages{
+		window: window,
+		refs:   make(map[string]*seenWindow),
+	}
+}
+
+// Add returns true if the sequence number is new for the ref and false if it was already seen
+func (sm *SeenMessages) Add(ref string, seqNum uint32) bool {
+	sm.mx.Lock()
+	defer sm.mx.Unlock()
+	win, ok := sm.refs[ref]
+	if !ok {
+		win = &seenWindow{
+			seen:  make(map[uint32]struct{}, sm.window),
+			order: make([]uint32, 0, sm.window),
+		}
+		sm.refs[ref] = win
+	}
+	if _, ok := win.seen[seqNum]; ok {
+		return false
+	}
+	if len(win.order) < sm.window {
+		win.order = append(win.order, seqNum)
+	} else {
+		delete(win.seen, win.order[win.next])
+		win.order[win.next] = seqNum
+		win.next = (win.next + 1) % sm.window
+	}
+	win.seen[seqNum] = struct{}{}
+	return true
+}
+
+// Remove forgets all sequence numbers of the ref, it should be called on portclose
+func (sm *SeenMessages) Remove(ref string) {
+	sm.mx.Lock()
+	defer sm.mx.Unlock()
+	delete(sm.refs, ref)
+}
@@ -0,0 +1,59 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import "testing"
+
+func TestSeenMessages(t *testing.T) {
+	seen := NewSeenMessages(100)
+	for i := uint32(0); i < 100; i++ {
+		if !seen.Add("ref1", i) {
+			t.Fatalf("message %d should be new", i)
+		}
+	}
+	for i := uint32(50); i <= 60; i++ {
+		if seen.Add("ref1", i) {
+			t.Errorf("message %d should be seen", i)
+		}
+	}
+	if !seen.Add("ref2", 50) {
+		t.Errorf("message of other ref should be new")
+	}
+}
+
+func TestSeenMessagesWindow(t *testing.T) {
+	seen := NewSeenMessages(10)
+	for i := uint32(0); i < 20; i++ {
+		seen.Add("ref1", i)
+	}
+	if !seen.Add("ref1", 5) {
+		t.Errorf("message 5 should have left the window")
+	}
+	if seen.Add("ref1", 15) {
+		t.Errorf("message 15 should still be in the window")
+	}
+	seen.Remove("ref1")
+	if !seen.Add("ref1", 15) {
+		t.Errorf("message 15 should be new after remove")
+	}
+}
+
+func TestParseInboundPortSendSeq(t *testing.T) {
+	buffer := encodeTestResponse(t, "portsend", "ref", []byte("data"), uint32(42))
+	res, err := parseInboundPortSendRequest(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portSend := res.(*PortSend)
+	if !portSend.HasSeq || portSend.Seq != 42 {
+		t.Errorf("expected sequence number 42 but got %+v", portSend)
+	}
+	buffer = encodeTestResponse(t, "portsend", "ref", []byte("data"))
+	if res, err = parseInboundPortSendRequest(buffer); err != nil {
+		t.Fatal(err)
+	}
+	if res.(*PortSend).HasSeq {
+		t.Errorf("portsend without sequence number should not have HasSeq")
+	}
+}
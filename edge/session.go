@@ -0,0 +1,246 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MaxPendingPortData is the number of unacknowledged bytes of a session port
+// after which Track waits for acknowledgements
+const MaxPendingPortData = 1 << 20
+
+var (
+	ErrSessionPortNotOpen = fmt.Errorf("port is not open in the session")
+)
+
+// SessionTransport is the connection of a session to an edge server,
+// PortSend returns once the server received the data
+type SessionTransport interface {
+	PortOpen(ctx context.Context, deviceID Address, port string, mode string) (*PortOpen, error)
+	PortSend(ctx context.Context, ref string, data []byte) error
+	PortClose(ref string) error
+	Close() error
+}
+
+// SessionDialer connects to the given edge server
+type SessionDialer func(ctx context.Context, server *ServerObj) (SessionTransport, error)
+
+// SessionPort is a port that was opened within a session
+type SessionPort struct {
+	Ref      string
+	DeviceID Address
+	Port     string
+	Mode     string
+	// PreviousRef is the reference of the port before the session was migrated
+	PreviousRef string
+	// pending is the portsend data that was not yet acknowledged
+	pending      [][]byte
+	pendingBytes int
+}
+
+// Session tracks the open ports on an edge server connection
+type Session struct {
+	Server    *ServerObj
+	Transport SessionTransport
+
+	dial  SessionDialer
+	mx    sync.Mutex
+	ports []*SessionPort
+	// freed is closed when pending data was acknowledged or a port was closed
+	freed chan struct{}
+}
+
+// NewSession connects to the given edge server and returns the session
+func NewSession(ctx context.Context, server *ServerObj, dial SessionDialer) (*Session, error) {
+	transport, err := dial(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	return AttachSession(server, transport, dial), nil
+}
+
+// AttachSession returns a session on the connected transport of the given edge server
+func AttachSession(server *ServerObj, transport SessionTransport, dial SessionDialer) *Session {
+	return &Session{
+		Server:    server,
+		Transport: transport,
+		dial:      dial,
+		freed:     make(chan struct{}),
+	}
+}
+
+// OpenPort opens a port to the device and tracks it in the session
+func (s *Session) OpenPort(ctx context.Context, deviceID Address, port string, mode string) (*SessionPort, error) {
+	portOpen, err := s.Transport.PortOpen(ctx, deviceID, port, mode)
+	if err != nil {
+		return nil, err
+	}
+	if portOpen == nil || !portOpen.Ok {
+		return nil, fmt.Errorf("failed to open port %s of device %x", port, deviceID)
+	}
+	return s.TrackPort(portOpen.Ref, deviceID, port, mode), nil
+}
+
+// TrackPort tracks a port that was opened on the transport of the session
+func (s *Session) TrackPort(ref string, deviceID Address, port string, mode string) *SessionPort {
+	sessionPort := &SessionPort{
+		Ref:      ref,
+		DeviceID: deviceID,
+		Port:     port,
+		Mode:     mode,
+	}
+	s.mx.Lock()
+	s.ports = append(s.ports, sessionPort)
+	s.mx.Unlock()
+	return sessionPort
+}
+
+// notifyFreed wakes up the calls of Track that wait for acknowledgements
+func (s *Session) notifyFreed() {
+	close(s.freed)
+	s.freed = make(chan struct{})
+}
+
+func (s *Session) port(ref string) *SessionPort {
+	for _, port := range s.ports {
+		if port.Ref == ref {
+			return port
+		}
+	}
+	return nil
+}
+
+// Send sends the data to the port, the data is kept until the transport
+// returns that it was received
+func (s *Session) Send(ctx context.Context, ref string, data []byte) error {
+	if err := s.Track(ctx, ref, data); err != nil {
+		return err
+	}
+	if err := s.Transport.PortSend(ctx, ref, data); err != nil {
+		return err
+	}
+	s.Ack(ref, len(data))
+	return nil
+}
+
+// Track keeps a copy of the data that was sent to the port until it's
+// acknowledged, it waits until the data fits into MaxPendingPortData
+// unacknowledged bytes or ctx is done
+func (s *Session) Track(ctx context.Context, ref string, data []byte) error {
+	for {
+		s.mx.Lock()
+		port := s.port(ref)
+		if port == nil {
+			s.mx.Unlock()
+			return ErrSessionPortNotOpen
+		}
+		if port.pendingBytes == 0 || port.pendingBytes+len(data) <= MaxPendingPortData {
+			port.pending = append(port.pending, append([]byte(nil), data...))
+			port.pendingBytes += len(data)
+			s.mx.Unlock()
+			return nil
+		}
+		freed := s.freed
+		s.mx.Unlock()
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Ack drops the first n pending bytes of the port, the data of a port is
+// received in order so n is the byte count of the received portsend data
+func (s *Session) Ack(ref string, n int) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	port := s.port(ref)
+	if port == nil {
+		return
+	}
+	defer s.notifyFreed()
+	for n > 0 && len(port.pending) > 0 {
+		data := port.pending[0]
+		if n < len(data) {
+			port.pending[0] = data[n:]
+			port.pendingBytes -= n
+			return
+		}
+		port.pending = port.pending[1:]
+		port.pendingBytes -= len(data)
+		n -= len(data)
+	}
+}
+
+// ClosePort removes the port from the session
+func (s *Session) ClosePort(ref string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for i, port := range s.ports {
+		if port.Ref == ref {
+			s.ports = append(s.ports[:i], s.ports[i+1:]...)
+			s.notifyFreed()
+			return
+		}
+	}
+}
+
+// Ports returns the open ports of the session
+func (s *Session) Ports() []*SessionPort {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	ports := make([]*SessionPort, len(s.ports))
+	copy(ports, s.ports)
+	return ports
+}
+
+// Close closes the open ports and the connection of the session
+func (s *Session) Close() error {
+	s.mx.Lock()
+	ports := s.ports
+	s.ports = nil
+	s.notifyFreed()
+	s.mx.Unlock()
+	for _, port := range ports {
+		s.Transport.PortClose(port.Ref)
+	}
+	return s.Transport.Close()
+}
+
+// MigrateSession connects to the new server, moves all ports of the old session
+// to it and re-sends the data that was not acknowledged, the new session is
+// closed if a port can't be migrated
+func MigrateSession(ctx context.Context, oldSession *Session, newServer *ServerObj) (*Session, error) {
+	session, err := NewSession(ctx, newServer, oldSession.dial)
+	if err != nil {
+		return nil, err
+	}
+	// the ports are moved out of the old session, so data that is tracked
+	// afterwards isn't lost between the copy and the migration
+	oldSession.mx.Lock()
+	oldPorts := oldSession.ports
+	oldSession.ports = nil
+	oldSession.notifyFreed()
+	oldSession.mx.Unlock()
+
+	for _, oldPort := range oldPorts {
+		port, err := session.OpenPort(ctx, oldPort.DeviceID, oldPort.Port, oldPort.Mode)
+		if err != nil {
+			session.Close()
+			return nil, fmt.Errorf("failed to migrate port %s: %v", oldPort.Ref, err)
+		}
+		port.PreviousRef = oldPort.Ref
+		for _, data := range oldPort.pending {
+			if err = session.Send(ctx, port.Ref, data); err != nil {
+				session.Close()
+				return nil, fmt.Errorf("failed to migrate data of port %s: %v", oldPort.Ref, err)
+			}
+		}
+	}
+	return session, nil
+}
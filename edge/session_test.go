@@ -0,0 +1,208 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package edge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type mockSessionTransport struct {
+	name       string
+	closed     bool
+	shutdown   bool
+	maxPorts   int
+	opened     []string
+	closedRefs []string
+	sent       map[string][][]byte
+}
+
+func (transport *mockSessionTransport) PortOpen(ctx context.Context, deviceID Address, port string, mode string) (*PortOpen, error) {
+	if transport.closed {
+		return nil, fmt.Errorf("server disconnected")
+	}
+	if transport.maxPorts > 0 && len(transport.opened) >= transport.maxPorts {
+		return nil, fmt.Errorf("too many open ports")
+	}
+	ref := fmt.Sprintf("%s:%d", transport.name, len(transport.opened))
+	transport.opened = append(transport.opened, port)
+	return &PortOpen{Ref: ref, Ok: true}, nil
+}
+
+func (transport *mockSessionTransport) PortSend(ctx context.Context, ref string, data []byte) error {
+	if transport.closed {
+		return fmt.Errorf("server disconnected")
+	}
+	transport.sent[ref] = append(transport.sent[ref], data)
+	return nil
+}
+
+func (transport *mockSessionTransport) PortClose(ref string) error {
+	transport.closedRefs = append(transport.closedRefs, ref)
+	return nil
+}
+
+func (transport *mockSessionTransport) Close() error {
+	transport.shutdown = true
+	return nil
+}
+
+func TestMigrateSession(t *testing.T) {
+	transports := map[string]*mockSessionTransport{}
+	dial := func(ctx context.Context, server *ServerObj) (SessionTransport, error) {
+		transport := &mockSessionTransport{name: string(server.Host), sent: map[string][][]byte{}}
+		transports[transport.name] = transport
+		return transport, nil
+	}
+	ctx := context.Background()
+	session, err := NewSession(ctx, &ServerObj{Host: []byte("old")}, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	for _, port := range []string{"tcp:80", "tcp:443", "udp:53"} {
+		sessionPort, err := session.OpenPort(ctx, Address{1}, port, "rw")
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, sessionPort.Ref)
+	}
+	if err = session.Send(ctx, refs[0], []byte("acked")); err != nil {
+		t.Fatal(err)
+	}
+	// the first 5 bytes of the asynchronously sent data were acknowledged,
+	// the sender reuses its buffer like io.Copy
+	buf := make([]byte, 4)
+	for _, data := range []string{"pend", "ing", "data"} {
+		n := copy(buf, data)
+		if err = session.Track(ctx, refs[1], buf[:n]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session.Ack(refs[1], 5)
+
+	transports["old"].closed = true
+	if err = session.Send(ctx, refs[2], []byte("lost")); err == nil {
+		t.Fatalf("send to a disconnected server should fail")
+	}
+	migrated, err := MigrateSession(ctx, session, &ServerObj{Host: []byte("new")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(session.Ports()) != 0 {
+		t.Errorf("the ports should be moved out of the old session")
+	}
+	ports := migrated.Ports()
+	if len(ports) != 3 || len(transports["new"].opened) != 3 {
+		t.Fatalf("expected 3 re-opened ports but got %d", len(ports))
+	}
+	for i, port := range ports {
+		if port.PreviousRef != refs[i] {
+			t.Errorf("expected previous ref %s but got %s", refs[i], port.PreviousRef)
+		}
+	}
+	sent := transports["new"].sent
+	if len(sent[ports[0].Ref]) != 0 {
+		t.Errorf("acknowledged data should not be re-sent")
+	}
+	if len(sent[ports[1].Ref]) != 2 || string(sent[ports[1].Ref][0]) != "ng" || string(sent[ports[1].Ref][1]) != "data" {
+		t.Errorf("unacknowledged data should be re-sent, got %q", sent[ports[1].Ref])
+	}
+	if len(sent[ports[2].Ref]) != 1 || string(sent[ports[2].Ref][0]) != "lost" {
+		t.Errorf("data of a failed send should be re-sent, got %q", sent[ports[2].Ref])
+	}
+	full, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	for _, port := range ports {
+		if err = migrated.Track(full, port.Ref, make([]byte, MaxPendingPortData)); err != nil {
+			t.Errorf("re-sent data should be acknowledged: %v", err)
+		}
+	}
+}
+
+func TestMigrateSessionFailure(t *testing.T) {
+	transports := map[string]*mockSessionTransport{}
+	dial := func(ctx context.Context, server *ServerObj) (SessionTransport, error) {
+		// the new server only accepts 2 ports
+		transport := &mockSessionTransport{name: string(server.Host), maxPorts: 2, sent: map[string][][]byte{}}
+		transports[transport.name] = transport
+		return transport, nil
+	}
+	ctx := context.Background()
+	session, err := NewSession(ctx, &ServerObj{Host: []byte("old")}, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transports["old"].maxPorts = 0
+	for _, port := range []string{"tcp:80", "tcp:443", "udp:53"} {
+		if _, err = session.OpenPort(ctx, Address{1}, port, "rw"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = MigrateSession(ctx, session, &ServerObj{Host: []byte("new")}); err == nil {
+		t.Fatalf("migration to a server without free ports should fail")
+	}
+	transport := transports["new"]
+	if len(transport.closedRefs) != 2 || !transport.shutdown {
+		t.Errorf("the re-opened ports %v and the new session should be closed", transport.closedRefs)
+	}
+}
+
+func TestSessionTrackWaitsForAck(t *testing.T) {
+	dial := func(ctx context.Context, server *ServerObj) (SessionTransport, error) {
+		return &mockSessionTransport{sent: map[string][][]byte{}}, nil
+	}
+	ctx := context.Background()
+	session, err := NewSession(ctx, &ServerObj{}, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := session.OpenPort(ctx, Address{1}, "tcp:80", "rw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, MaxPendingPortData/2)
+	for i := 0; i < 2; i++ {
+		if err = session.Track(ctx, port.Ref, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err = session.Track(timeout, port.Ref, []byte{1}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("track should wait until ctx is done but got %v", err)
+	}
+
+	tracked := make(chan error, 1)
+	go func() {
+		tracked <- session.Track(ctx, port.Ref, []byte{1, 2})
+	}()
+	// a partial ack doesn't free enough bytes
+	session.Ack(port.Ref, 1)
+	select {
+	case err = <-tracked:
+		t.Fatalf("track should wait for the acknowledgement of 2 bytes but got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	session.Ack(port.Ref, 1)
+	select {
+	case err = <-tracked:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("acknowledged data should free the pending limit")
+	}
+
+	go func() {
+		tracked <- session.Track(ctx, port.Ref, []byte{1})
+	}()
+	session.ClosePort(port.Ref)
+	if err = <-tracked; !errors.Is(err, ErrSessionPortNotOpen) {
+		t.Fatalf("closing the port should stop waiting but got %v", err)
+	}
+}
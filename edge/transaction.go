@@ -109,7 +109,9 @@ func (tx *Transaction) From() (util.Address, error) {
 	if err != nil {
 		return util.EmptyAddress, err
 	}
-	tx.from = util.PubkeyToAddress(pubKey)
+	if tx.from, err = util.PubkeyToAddress(pubKey); err != nil {
+		return util.EmptyAddress, err
+	}
 	return tx.from, nil
 }
 
@@ -55,3 +55,36 @@ func TestEIP155(t *testing.T) {
 		t.Errorf("Signed transaction result was not correct")
 	}
 }
+
+func TestSendTransactionResponse(t *testing.T) {
+	priv, err := util.DecodeString("0x4646464646464646464646464646464646464646464646464646464646464646")
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKey := crypto.ToECDSAUnsafe(priv)
+	tx := NewTransaction(1, 1, 21000, Address{1}, 10, nil, 41043)
+	if err = tx.Sign(privKey); err != nil {
+		t.Fatal(err)
+	}
+	encodedTx, err := tx.ToRLP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txHash, err := tx.TransactionHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := TestFixture{Method: "sendtransaction", Args: []interface{}{encodedTx}, Response: []interface{}{txHash, uint8(1)}}
+	raw, parse := BuildFixture(t, RLPProtocol{}, f)
+	res, err := parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, ok := res.(*SendTransactionResult)
+	if !ok || !result.Ok() || !bytes.Equal(result.TxHash[:], txHash) {
+		t.Fatalf("unexpected sendtransaction result %+v", res)
+	}
+	if _, err = NewMessage(&bytes.Buffer{}, 1, "sendtransaction", tx); err == nil {
+		t.Fatalf("sendtransaction should only accept the encoded transaction")
+	}
+}
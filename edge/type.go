@@ -5,13 +5,24 @@ package edge
 
 import (
 	"bytes"
+	"fmt"
 	"math/big"
+	"net"
+	"regexp"
+	"strconv"
 
 	"github.com/diodechain/diode_client/crypto"
+	"github.com/diodechain/diode_client/rlp"
 	"github.com/diodechain/diode_client/util"
 	bert "github.com/diodechain/gobert"
 )
 
+var (
+	ErrInvalidDeviceID = fmt.Errorf("device id is not an address")
+
+	hostnamePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.)*[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+)
+
 // Address represents an Ethereum address
 type Address = util.Address
 
@@ -31,6 +42,29 @@ type Error struct {
 	Message string
 }
 
+// ErrorMessage is the decoded view of an error response
+type ErrorMessage struct {
+	Method      string
+	Description string
+}
+
+// HealthStatus is the resource usage reported by an edge server
+type HealthStatus struct {
+	CPUPercent      float64
+	MemPercent      float64
+	OpenConnections int
+	UptimeSeconds   uint64
+}
+
+// Load returns the higher of cpu and memory usage, servers with
+// a lower load should be preferred
+func (hs *HealthStatus) Load() float64 {
+	if hs.CPUPercent > hs.MemPercent {
+		return hs.CPUPercent
+	}
+	return hs.MemPercent
+}
+
 type PortOpen struct {
 	RequestID     uint64
 	Ref           string
@@ -38,21 +72,67 @@ type PortOpen struct {
 	PortNumber    int
 	SrcPortNumber int
 	DeviceID      Address
-	Ok            bool
-	Err           error
+	// RawDeviceID is the device id as sent by the server
+	RawDeviceID []byte
+	Ok          bool
+	Err         error
+}
+
+// DeviceAddrBytes returns the raw device id as address, it fails if the length is not 20 bytes
+func (portOpen *PortOpen) DeviceAddrBytes() (addr [20]byte, err error) {
+	if len(portOpen.RawDeviceID) != len(addr) {
+		err = fmt.Errorf("%w: %d bytes", ErrInvalidDeviceID, len(portOpen.RawDeviceID))
+		return
+	}
+	copy(addr[:], portOpen.RawDeviceID)
+	return
+}
+
+// DeviceAddrString returns the hex encoded raw device id
+func (portOpen *PortOpen) DeviceAddrString() string {
+	return util.EncodeToString(portOpen.RawDeviceID)
+}
+
+// Reject returns the encoded response that denies the inbound portopen request
+func (portOpen *PortOpen) Reject(reason string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	_, err := NewResponseMessage(buf, portOpen.RequestID, "response", "portopen", portOpen.Ref, "reject", reason)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 type PortSend struct {
 	Ref  string
 	Data []byte
-	Ok   bool
-	Err  error
+	// Seq is the sequence number of the frame if HasSeq is set
+	Seq    uint32
+	HasSeq bool
+	Ok     bool
+	Err    error
 }
 
+// SequencedPortSend is a portsend_seq frame, Seq increases by one for every frame of the Ref
+type SequencedPortSend struct {
+	Seq  uint32
+	Ref  string
+	Data []byte
+}
+
+// Reasons of a portclose
+const (
+	ReasonNormal  = "normal"
+	ReasonError   = "error"
+	ReasonTimeout = "timeout"
+)
+
 type PortClose struct {
 	Ref string
-	Ok  bool
-	Err error
+	// Reason is one of ReasonNormal, ReasonError or ReasonTimeout
+	Reason string
+	Ok     bool
+	Err    error
 }
 
 type Goodbye struct {
@@ -68,8 +148,38 @@ type ServerObj struct {
 	Sig          []byte
 	ServerPubKey []byte
 	Extra        map[string]big.Int
+	NetworkID    uint64
 }
 
+// CheckNetworkID returns ErrWrongNetwork if the server doesn't advertise the expected network,
+// an expected network id of zero accepts any server
+func (obj *ServerObj) CheckNetworkID(expected uint64) error {
+	if expected == 0 || obj.NetworkID == expected {
+		return nil
+	}
+	return fmt.Errorf("%w: server network %d, expected %d", ErrWrongNetwork, obj.NetworkID, expected)
+}
+
+// EdgeURL returns the dialable host:port of the edge port, it's empty if the host is invalid
+func (obj *ServerObj) EdgeURL() string {
+	return obj.hostPort(obj.EdgePort)
+}
+
+// ServerURL returns the dialable host:port of the server port, it's empty if the host is invalid
+func (obj *ServerObj) ServerURL() string {
+	return obj.hostPort(obj.ServerPort)
+}
+
+func (obj *ServerObj) hostPort(port uint64) string {
+	host := string(obj.Host)
+	if net.ParseIP(host) == nil && !hostnamePattern.MatchString(host) {
+		return ""
+	}
+	return net.JoinHostPort(host, strconv.FormatUint(port, 10))
+}
+
+// StateRoots are the 16 roots of the state tree buckets, the account state tree
+// with modulo i hashes to StateRoots[i], they are not indexed by block number
 type StateRoots struct {
 	StateRoots   [][]byte
 	rawStateRoot []byte
@@ -100,6 +210,81 @@ func (err Error) Error() string {
 
 type AccountValue struct {
 	accountTree MerkleTree
+	// StorageKey is the 32 bytes storage key of the getaccountvalue request
+	StorageKey []byte
+}
+
+// Block is a block returned by getblock, header and receipts are not decoded
+type Block struct {
+	Coinbase     []byte
+	Header       []interface{}
+	Receipts     []interface{}
+	Transactions []BlockTransaction
+}
+
+// BlockTransaction is a signed transaction included in a block
+type BlockTransaction struct {
+	Hash  [32]byte
+	From  [20]byte
+	To    [20]byte
+	Value *big.Int
+	Nonce uint64
+	Data  []byte
+	Sig   [65]byte
+}
+
+// ComputeHash returns the keccak256 hash of the rlp encoded transaction fields
+func (tx *BlockTransaction) ComputeHash() (hash [32]byte, err error) {
+	value := tx.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	encoded, err := rlp.EncodeToBytes(transactionHashItem{
+		Nonce: tx.Nonce,
+		From:  tx.From,
+		To:    tx.To,
+		Value: value,
+		Data:  tx.Data,
+		Sig:   tx.Sig,
+	})
+	if err != nil {
+		return
+	}
+	copy(hash[:], crypto.Sha3Hash(encoded))
+	return
+}
+
+// AccountValueAtBlock is the account value with merkle proof at the given block
+type AccountValueAtBlock struct {
+	BlockNumber uint64
+	Value       *AccountValue
+}
+
+// AccountRootsAtBlock is the account roots at the given block
+type AccountRootsAtBlock struct {
+	BlockNumber uint64
+	Roots       *AccountRoots
+}
+
+// SendTransactionResult is the sendtransaction response of servers that send the
+// transaction hash, Status is 1 on success and 0 on failure
+type SendTransactionResult struct {
+	TxHash [32]byte
+	Status uint8
+}
+
+// Ok returns true if the transaction was accepted
+func (result *SendTransactionResult) Ok() bool {
+	return result.Status == 1
+}
+
+// EventLog is an evm event log of a getlogs response
+type EventLog struct {
+	Address     [20]byte
+	Topics      [][32]byte
+	Data        []byte
+	BlockNumber uint64
+	TxHash      [32]byte
 }
 
 // StateRoot returns state root of given state roots
@@ -164,6 +349,18 @@ func (ar *AccountRoots) Find(accountRoot []byte) int {
 	return index
 }
 
+// VerifyAddress returns true if the proof contains the keccak256 of the address
+// and the root hash of the proof is the account root at the proof modulo
+func (ar *AccountRoots) VerifyAddress(addr [20]byte, proof *MerkleTree) bool {
+	if proof == nil || !proof.VerifyLeaf(crypto.Sha3Hash(addr[:])) {
+		return false
+	}
+	if proof.Modulo >= uint64(len(ar.AccountRoots)) {
+		return false
+	}
+	return bytes.Equal(ar.AccountRoots[proof.Modulo], proof.RootHash)
+}
+
 // IsValid check the account hash is valid
 // should we check state root?
 // func (ac *Account) IsValid() bool {
@@ -180,6 +377,26 @@ func (ac *Account) StateTree() MerkleTree {
 	return ac.stateTree
 }
 
+// CodeHash returns the keccak256 hash of the account code
+func (ac *Account) CodeHash() (hash [32]byte) {
+	copy(hash[:], crypto.Sha3Hash(ac.Code))
+	return
+}
+
+// verifyCodeHash returns ErrCodeHashMissing if the state tree has no code hash
+// leave and ErrCodeHashMismatch if the leave differs from the account code
+func (ac *Account) verifyCodeHash() error {
+	leaveHash, err := ac.stateTree.Get(codeHashKey)
+	if err != nil {
+		return ErrCodeHashMissing
+	}
+	codeHash := ac.CodeHash()
+	if !bytes.Equal(leaveHash, codeHash[:]) {
+		return ErrCodeHashMismatch
+	}
+	return nil
+}
+
 // AccountRoot returns account root of account value, you can compare with accountroots[mod]
 func (acv *AccountValue) AccountRoot() []byte {
 	return acv.accountTree.RootHash
@@ -189,3 +406,10 @@ func (acv *AccountValue) AccountRoot() []byte {
 func (acv *AccountValue) AccountTree() MerkleTree {
 	return acv.accountTree
 }
+
+// VerifyKey returns true if key is the requested storage key and a leave of
+// the merkle tree, keys shorter than 32 bytes are zero padded like the request key
+func (acv *AccountValue) VerifyKey(key []byte) bool {
+	key = util.PaddingBytesPrefix(key, 0, 32)
+	return bytes.Equal(key, acv.StorageKey) && acv.accountTree.VerifyLeaf(key)
+}
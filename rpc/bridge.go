@@ -4,6 +4,7 @@
 package rpc
 
 import (
+	"bytes"
 	"fmt"
 	"net"
 	"strconv"
@@ -15,6 +16,7 @@ import (
 
 var (
 	errPortNotPublished = fmt.Errorf("port was not published")
+	errPortLimitReached = fmt.Errorf("too many open ports")
 )
 
 // handleInboundRequest handle inbound request
@@ -70,6 +72,16 @@ func (client *Client) handleInboundRequest(inboundRequest interface{}) {
 				return
 			}
 
+			limiter := client.pool.PortLimiter()
+			deviceID := portOpen.DeviceID.HexString()
+			if !limiter.Allow(deviceID) {
+				client.ResponsePortOpen(portOpen, errPortLimitReached)
+				client.CastPortClose(portOpen.Ref)
+				client.Log().Warn("Port limit reached for device %s", deviceID)
+				return
+			}
+			defer limiter.Release(deviceID)
+
 			portOpen.SrcPortNumber = int(publishedPort.Src)
 			port := NewConnectedPort(0, portOpen.Ref, portOpen.DeviceID, client, portOpen.PortNumber)
 			defer port.Shutdown()
@@ -151,7 +163,7 @@ func (client *Client) handleInboundRequest(inboundRequest interface{}) {
 
 		client.Log().Warn("server disconnected, reason: %v", goodbye.Reason)
 		if !client.Closed() {
-			client.Close()
+			client.closeLost()
 		}
 	} else {
 		client.Log().Warn("doesn't support rpc request: %+v ", inboundRequest)
@@ -216,6 +228,13 @@ func (client *Client) handleInboundMessage(msg edge.Message) {
 
 		client.backoff.StepBack()
 		call := client.cm.CallByID(msg.ResponseID())
+		if client.msgLogger != nil {
+			method := ""
+			if call != nil {
+				method = call.method
+			}
+			client.msgLogger.LogInbound(method, msg.ResponseID(), msg.Buffer)
+		}
 		if call == nil {
 			// receive empty call, client might drop call because timeout, should drop message
 			return
@@ -244,6 +263,11 @@ func (client *Client) handleInboundMessage(msg edge.Message) {
 		defer client.timer.profile(time.Now(), fmt.Sprintf("handle:%s", call.method))
 
 		res, err := call.Parse(msg.Buffer)
+		if err != nil && res != nil {
+			// the result of a denied request carries its error, e.g. a denied portopen
+			call.enqueueResponse(res)
+			return
+		}
 		if err != nil {
 			rpcError := edge.Error{
 				Message: err.Error(),
@@ -254,6 +278,9 @@ func (client *Client) handleInboundMessage(msg edge.Message) {
 		call.enqueueResponse(res)
 		return
 	}
+	if client.msgLogger != nil {
+		client.msgLogger.LogInbound("", 0, msg.Buffer)
+	}
 	inboundRequest, err := msg.ReadAsInboundRequest()
 	if err != nil {
 		client.Log().Error("Not rpc request: %v", err)
@@ -283,7 +310,7 @@ func (client *Client) recvMessageLoop() {
 			if !client.isClosed {
 				// This was unexpected...
 				client.Log().Info("Client connection closed: %v", err)
-				client.Close()
+				client.closeLost()
 			}
 			return
 		}
@@ -299,9 +326,34 @@ func (client *Client) recvMessageLoop() {
 	}
 }
 
+// portSendLoop sends the queued portsend messages of TrySendRemote until the
+// client is closed
+func (client *Client) portSendLoop() {
+	for {
+		select {
+		case <-client.portSendQueue.Done():
+			return
+		case msg := <-client.portSendQueue.Messages():
+			// the response is not waited for, the buffered channel takes an error response
+			call := &Call{
+				id:       edge.ResponseID(msg),
+				method:   "portsend",
+				data:     bytes.NewBuffer(msg),
+				response: make(chan interface{}, 1),
+			}
+			if err := client.insertCall(call); err != nil {
+				client.Log().Debug("Failed to send queued portsend: %v", err)
+			}
+		}
+	}
+}
+
 // sendCall send the rpc call
 func (client *Client) sendCall(c *Call) (err error) {
 	ts := time.Now()
+	if client.msgLogger != nil {
+		client.msgLogger.LogOutbound(c.method, c.id, c.data.Bytes())
+	}
 	err = client.s.sendMessage(c.data.Bytes())
 	if err != nil {
 		client.Log().Error("Failed to write to node: %v", err)
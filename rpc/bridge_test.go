@@ -0,0 +1,68 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/diodechain/diode_client/config"
+	"github.com/diodechain/diode_client/edge"
+)
+
+func TestIsAllowlistedForeignFleet(t *testing.T) {
+	queries := 0
+	client := &Client{
+		config: &config.Config{FleetAddr: Address{1}},
+	}
+	client.portOpenGuard = edge.NewPortOpenGuard(func(ctx context.Context, blockNumber uint64, account edge.Address, key []byte) ([]byte, error) {
+		queries++
+		return make([]byte, 32), nil
+	})
+	device := Address{2}
+
+	public := &config.Port{Mode: config.PublicPublishedMode}
+	if !client.isAllowlisted(public, device) {
+		t.Fatalf("public port should open from a foreign fleet")
+	}
+	if queries != 0 {
+		t.Fatalf("public port should not query the fleet allowlist, got %d queries", queries)
+	}
+
+	protected := &config.Port{Mode: config.ProtectedPublishedMode}
+	if client.isAllowlisted(protected, device) {
+		t.Fatalf("protected port should not open from a foreign fleet")
+	}
+	if queries != 1 {
+		t.Fatalf("protected port should query the fleet allowlist once, got %d queries", queries)
+	}
+}
+
+func TestIsAllowlistedProtectedFleets(t *testing.T) {
+	allowedFleet := Address{3}
+	var queried []edge.Address
+	client := &Client{
+		config: &config.Config{FleetAddr: Address{1}},
+	}
+	client.portOpenGuard = edge.NewPortOpenGuard(func(ctx context.Context, blockNumber uint64, account edge.Address, key []byte) ([]byte, error) {
+		queried = append(queried, account)
+		value := make([]byte, 32)
+		if account == allowedFleet {
+			value[31] = 1
+		}
+		return value, nil
+	})
+	device := Address{2}
+
+	protected := &config.Port{
+		Mode:      config.ProtectedPublishedMode,
+		Allowlist: map[Address]bool{allowedFleet: true},
+	}
+	if !client.isAllowlisted(protected, device) {
+		t.Fatalf("protected port should open for a device of an allowlisted fleet")
+	}
+	if len(queried) != 1 || queried[0] != allowedFleet {
+		t.Fatalf("protected port should query the allowlisted fleet once, got %x", queried)
+	}
+}
@@ -4,7 +4,9 @@
 package rpc
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 // callManager represents call manager of rpc calls
@@ -70,6 +72,20 @@ func (cm *callManager) RemoveCallByID(id uint64) {
 	}
 }
 
+// WaitEmpty waits until all calls in queue received a response or the context is done
+func (cm *callManager) WaitEmpty(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for cm.TotalCallLength() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
 // RemoveCalls remove all calls in queue
 func (cm *callManager) RemoveCalls() {
 	cm.mx.Lock()
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -49,3 +50,41 @@ func TestCallmanager(t *testing.T) {
 		}
 	}
 }
+
+func TestCallmanagerWaitEmpty(t *testing.T) {
+	cm := NewCallManager(16)
+	calls := make([]*Call, 10)
+	for i := range calls {
+		calls[i] = makeCall(uint64(i+1), "getblockpeak")
+		calls[i].response = make(chan interface{}, 1)
+		if err := cm.Insert(calls[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the server responds to the first half of the calls only
+	go func() {
+		for i := 1; i <= 5; i++ {
+			if c := cm.CallByID(uint64(i)); c != nil {
+				c.enqueueResponse(uint64(i))
+			}
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := cm.WaitEmpty(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded but got %v", err)
+	}
+	cm.RemoveCalls()
+	for i, c := range calls {
+		res, ok := <-c.response
+		if i < 5 && (!ok || res != uint64(i+1)) {
+			t.Errorf("call %d should receive response %d but got %v", i+1, i+1, res)
+		}
+		if i >= 5 && (ok || c.state != CANCELLED) {
+			t.Errorf("call %d should be cancelled", i+1)
+		}
+	}
+	if err := cm.WaitEmpty(context.Background()); err != nil {
+		t.Errorf("empty call manager should not wait: %v", err)
+	}
+}
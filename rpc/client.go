@@ -10,8 +10,10 @@ package rpc
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -25,6 +27,7 @@ import (
 	"github.com/diodechain/diode_client/contract"
 	"github.com/diodechain/diode_client/db"
 	"github.com/diodechain/diode_client/edge"
+	"github.com/diodechain/diode_client/rlp"
 	"github.com/diodechain/diode_client/util"
 	"github.com/diodechain/openssl"
 	"github.com/diodechain/zap"
@@ -36,6 +39,11 @@ const (
 	packetLimit   = 65000
 	ticketBound   = 4194304
 	callQueueSize = 1024
+	// portSendQueueSize is the number of portsend messages of TrySendRemote that
+	// wait for the connection before payloads are dropped
+	portSendQueueSize = 256
+	// portOpenCheckTimeout is the timeout of the fleet allowlist check of protected ports
+	portOpenCheckTimeout = 10 * time.Second
 )
 
 var (
@@ -43,6 +51,7 @@ var (
 	errEmptyBNSresult               = fmt.Errorf("couldn't resolve name (null)")
 	errSendTransactionFailed        = fmt.Errorf("server returned false")
 	errClientClosed                 = fmt.Errorf("rpc client was closed")
+	errClientShutdown               = fmt.Errorf("rpc client is shutting down")
 	errPortOpenTimeout              = fmt.Errorf("portopen timeout")
 )
 
@@ -60,17 +69,29 @@ type Client struct {
 	pool          *DataPool
 	config        *config.Config
 	bq            *blockquick.Window
+	portOpenGuard *edge.PortOpenGuard
+	msgLogger     *edge.MessageLogger
+	blockCache    *edge.BlockCache
+	blockPeak     *edge.MonotonicBlockPeak
+	portSendQueue *edge.PortSendQueue
+	session       *edge.Session
 	lastTicket    *edge.DeviceTicket
 	latencySum    int64
 	latencyCount  int64
 	serverID      util.Address
 	onConnect     func(util.Address)
+	connected     chan struct{}
+	connectedOnce sync.Once
+	// portsMoved is closed after the ports of the closed client were moved
+	// to another server or closed
+	portsMoved chan struct{}
 	// close event
 	OnClose func()
 
-	isClosed bool
-	srv      *genserver.GenServer
-	timer    *Timer
+	isClosed   bool
+	isShutdown bool
+	srv        *genserver.GenServer
+	timer      *Timer
 }
 
 func getRequestID() uint64 {
@@ -97,12 +118,23 @@ func NewClient(host string, clientMan *ClientManager, cfg *config.Config, pool *
 		config:        cfg,
 		enableMetrics: cfg.EnableMetrics,
 		timer:         NewTimer(),
+		blockCache:    edge.NewBlockCache(edge.DefaultBlockCacheSize),
+		portSendQueue: edge.NewPortSendQueue(portSendQueueSize, getRequestID),
+		connected:     make(chan struct{}),
+		portsMoved:    make(chan struct{}),
 	}
+	client.session = newClientSession(client)
 
 	if client.enableMetrics {
 		client.metrics = NewMetrics()
 	}
 
+	client.blockPeak = edge.NewMonotonicBlockPeak(client.getBlockPeak)
+
+	client.portOpenGuard = edge.NewPortOpenGuard(func(ctx context.Context, blockNumber uint64, account edge.Address, key []byte) ([]byte, error) {
+		return client.GetAccountValueRawContext(ctx, blockNumber, account, key)
+	})
+
 	if !config.AppConfig.LogDateTime {
 		client.srv.DeadlockCallback = nil
 	}
@@ -110,6 +142,11 @@ func NewClient(host string, clientMan *ClientManager, cfg *config.Config, pool *
 	return client
 }
 
+// SetMessageLogger enables logging of all rpc messages, should be called before Start()
+func (client *Client) SetMessageLogger(logger *edge.MessageLogger) {
+	client.msgLogger = logger
+}
+
 func (client *Client) averageLatency() int64 {
 	return client.latencySum / client.latencyCount
 }
@@ -252,6 +289,10 @@ func (client *Client) insertCall(call *Call) (err error) {
 			err = errClientClosed
 			return
 		}
+		if client.isShutdown && call.method != "goodbye" {
+			err = errClientShutdown
+			return
+		}
 		err = client.cm.Insert(call)
 	})
 	if err == nil {
@@ -285,6 +326,34 @@ func (client *Client) CallContext(method string, args ...interface{}) (res inter
 	return
 }
 
+// callWithContext returns the response after calling the rpc, the call is
+// cancelled when ctx is done
+func (client *Client) callWithContext(ctx context.Context, method string, args ...interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	call, err := client.CastContext(nil, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	type result struct {
+		res interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		res, err := client.waitResponse(call)
+		done <- result{res: res, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.res, r.err
+	case <-ctx.Done():
+		client.srv.Cast(func() { client.cm.RemoveCallByID(call.id) })
+		return nil, ctx.Err()
+	}
+}
+
 // CheckTicket should client send traffic ticket to server
 func (client *Client) CheckTicket() {
 	defer client.timer.profile(time.Now(), "CheckTicket")
@@ -352,17 +421,12 @@ func (client *Client) validateNetwork() error {
 	}
 
 	// Checking chain of previous blocks
-	for i := windowSize - 2; i >= 0; i-- {
-		if blockHeaders[i].Hash() != blockHeaders[i+1].Parent() {
-			return fmt.Errorf("recevied blocks parent is not his parent: %+v %+v", blockHeaders[i+1], blockHeaders[i])
-		}
-		if !blockHeaders[i].ValidateSig() {
-			return fmt.Errorf("recevied blocks signature is not valid: %v", blockHeaders[i])
-		}
+	if err = blockquick.ValidateChain(blockHeaders); err != nil {
+		return err
 	}
 
 	// Starting to fetch new blocks
-	peak, err := client.GetBlockPeak()
+	peak, err := client.blockPeak.Poll(context.Background())
 	if err != nil {
 		return err
 	}
@@ -418,6 +482,30 @@ func (client *Client) GetBlockPeak() (uint64, error) {
 	return 0, nil
 }
 
+// getBlockPeak returns block peak, the rpc call is cancelled when ctx is done
+func (client *Client) getBlockPeak(ctx context.Context) (uint64, error) {
+	rawBlockPeak, err := client.callWithContext(ctx, "getblockpeak")
+	if err != nil {
+		return 0, err
+	}
+	if blockPeak, ok := rawBlockPeak.(uint64); ok {
+		return blockPeak, nil
+	}
+	return 0, nil
+}
+
+// HealthCheck returns the resource usage of the connected edge server
+func (client *Client) HealthCheck() (*edge.HealthStatus, error) {
+	rawStatus, err := client.CallContext("healthcheck")
+	if err != nil {
+		return nil, err
+	}
+	if status, ok := rawStatus.(*edge.HealthStatus); ok {
+		return status, nil
+	}
+	return nil, nil
+}
+
 // GetBlockquick returns block headers used for blockquick algorithm
 func (client *Client) GetBlockquick(lastValid uint64, windowSize uint64) ([]blockquick.BlockHeader, error) {
 	rawSequence, err := client.CallContext("getblockquick2", lastValid, windowSize)
@@ -430,6 +518,18 @@ func (client *Client) GetBlockquick(lastValid uint64, windowSize uint64) ([]bloc
 	return nil, nil
 }
 
+// GetBlockquickSince returns block headers used for blockquick algorithm that are newer than sinceHash
+func (client *Client) GetBlockquickSince(lastValid uint64, windowSize uint64, sinceHash [32]byte) ([]blockquick.BlockHeader, error) {
+	rawSequence, err := client.CallContext("getblockquick_since", lastValid, windowSize, sinceHash)
+	if err != nil {
+		return nil, err
+	}
+	if sequence, ok := rawSequence.([]uint64); ok {
+		return client.GetBlockHeadersUnsafe2(sequence)
+	}
+	return nil, nil
+}
+
 // GetBlockHeaderUnsafe returns an unchecked block header from the server
 func (client *Client) GetBlockHeaderUnsafe(blockNum uint64) (bh blockquick.BlockHeader, err error) {
 	var rawHeader interface{}
@@ -503,10 +603,19 @@ func (client *Client) GetBlockHeadersUnsafe(blockNumMin uint64, blockNumMax uint
 	return client.GetBlockHeadersUnsafe2(blockNumbers)
 }
 
-// GetBlock returns block
+// GetBlock returns block, blocks up to the last valid block are cached
 // TODO: make sure this rpc works (disconnect from server)
 func (client *Client) GetBlock(blockNum uint64) (interface{}, error) {
-	return client.CallContext("getblock", blockNum)
+	if block, ok := client.blockCache.Get(blockNum); ok {
+		return block, nil
+	}
+	res, err := client.CallContext("getblock", blockNum)
+	if block, ok := res.(*edge.Block); ok && err == nil {
+		if lvbn, _ := client.LastValid(); blockNum <= lvbn {
+			client.blockCache.Put(blockNum, block)
+		}
+	}
+	return res, err
 }
 
 // GetObject returns network object for device
@@ -538,6 +647,18 @@ func (client *Client) GetNode(nodeID [20]byte) (*edge.ServerObj, error) {
 	return nil, fmt.Errorf("GetNode(): parseerror")
 }
 
+// checkServerNetwork refuses servers that advertise a different network than the configured one
+func (client *Client) checkServerNetwork() error {
+	if client.config.NetworkID == 0 {
+		return nil
+	}
+	serverObj, err := client.GetNode(client.serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get server object: %v", err)
+	}
+	return serverObj.CheckNetworkID(client.config.NetworkID)
+}
+
 // Greet Initiates the connection
 // TODO: test compression flag
 func (client *Client) greet() error {
@@ -648,7 +769,7 @@ func (client *Client) submitTicket(ticket *edge.DeviceTicket) error {
 		}
 
 		if lastTicket, ok := resp.(edge.DeviceTicket); ok {
-			if lastTicket.Err == edge.ErrTicketTooLow {
+			if errors.Is(lastTicket.Err, edge.ErrTicketTooLow) {
 				sid, _ := client.s.GetServerID()
 				lastTicket.ServerID = sid
 				lastTicket.FleetAddr = client.config.FleetAddr
@@ -666,7 +787,7 @@ func (client *Client) submitTicket(ticket *edge.DeviceTicket) error {
 				} else {
 					client.Log().Warn("received fake ticket.. last_ticket=%v", lastTicket)
 				}
-			} else if lastTicket.Err == edge.ErrTicketTooOld {
+			} else if errors.Is(lastTicket.Err, edge.ErrTicketTooOld) {
 				client.Log().Info("received too old ticket")
 			}
 		}
@@ -678,7 +799,7 @@ func (client *Client) submitTicket(ticket *edge.DeviceTicket) error {
 func (client *Client) PortOpen(deviceID [20]byte, port int, portName string, mode string) (*edge.PortOpen, error) {
 	portOpen, err := client.doPortOpen(deviceID, portName, mode)
 	if portOpen != nil {
-		return portOpen, nil
+		return portOpen, err
 	}
 	if port < 65536 {
 		var b [2]byte
@@ -700,6 +821,10 @@ func (client *Client) doPortOpen(deviceID [20]byte, portName string, mode string
 		return nil, err
 	}
 	if portOpen, ok := rawPortOpen.(*edge.PortOpen); ok {
+		if portOpen.Err != nil {
+			return portOpen, portOpen.Err
+		}
+		client.session.TrackPort(portOpen.Ref, deviceID, portName, mode)
 		return portOpen, nil
 	}
 	return nil, nil
@@ -724,6 +849,16 @@ func (client *Client) CastPortClose(ref string) (err error) {
 	return err
 }
 
+// CastPortCloseReason cast portclose RPC with the close reason, the reason is
+// omitted for a normal close so that older servers understand the message
+func (client *Client) CastPortCloseReason(ref string, reason string) (err error) {
+	if reason == "" || reason == edge.ReasonNormal {
+		return client.CastPortClose(ref)
+	}
+	_, err = client.CastContext(nil, "portclose", ref, reason)
+	return err
+}
+
 // PortClose portclose RPC
 func (client *Client) PortClose(ref string) (interface{}, error) {
 	return client.CallContext("portclose", ref)
@@ -738,7 +873,6 @@ func (client *Client) Ping() (interface{}, error) {
 func (client *Client) SendTransaction(tx *edge.Transaction) (result bool, err error) {
 	var encodedRLPTx []byte
 	var res interface{}
-	var ok bool
 	err = client.SignTransaction(tx)
 	if err != nil {
 		return
@@ -748,13 +882,17 @@ func (client *Client) SendTransaction(tx *edge.Transaction) (result bool, err er
 		return
 	}
 	res, err = client.CallContext("sendtransaction", encodedRLPTx)
-	if res, ok = res.(string); ok {
+	switch res := res.(type) {
+	case string:
 		result = res == "ok"
-		if !result {
-			err = errSendTransactionFailed
-		}
+	case *edge.SendTransactionResult:
+		result = res.Ok()
+	default:
 		return
 	}
+	if !result {
+		err = errSendTransactionFailed
+	}
 	return
 }
 
@@ -813,13 +951,17 @@ func (client *Client) GetAccountNonce(blockNumber uint64, account [20]byte) uint
 
 // GetAccountValue returns account storage value
 func (client *Client) GetAccountValue(blockNumber uint64, account [20]byte, rawKey []byte) (*edge.AccountValue, error) {
+	return client.getAccountValue(context.Background(), blockNumber, account, rawKey)
+}
+
+func (client *Client) getAccountValue(ctx context.Context, blockNumber uint64, account [20]byte, rawKey []byte) (*edge.AccountValue, error) {
 	if blockNumber <= 0 {
 		bn, _ := client.LastValid()
 		blockNumber = uint64(bn)
 	}
 	// pad key to 32 bytes
 	key := util.PaddingBytesPrefix(rawKey, 0, 32)
-	rawAccountValue, err := client.CallContext("getaccountvalue", blockNumber, account[:], key)
+	rawAccountValue, err := client.callWithContext(ctx, "getaccountvalue", blockNumber, account[:], key)
 	if err != nil {
 		return nil, err
 	}
@@ -829,6 +971,23 @@ func (client *Client) GetAccountValue(blockNumber uint64, account [20]byte, rawK
 	return nil, nil
 }
 
+// GetAccountValueRange returns account values with merkle proofs for each block from fromBlock to toBlock
+func (client *Client) GetAccountValueRange(fromBlock uint64, toBlock uint64, account [20]byte, rawKey []byte) ([]edge.AccountValueAtBlock, error) {
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("invalid block range %d-%d", fromBlock, toBlock)
+	}
+	// pad key to 32 bytes
+	key := util.PaddingBytesPrefix(rawKey, 0, 32)
+	rawValues, err := client.CallContext("getaccountvaluerange", fromBlock, toBlock, account[:], key)
+	if err != nil {
+		return nil, err
+	}
+	if values, ok := rawValues.([]edge.AccountValueAtBlock); ok {
+		return values, nil
+	}
+	return nil, nil
+}
+
 // GetAccountValueInt returns account value as Integer
 func (client *Client) GetAccountValueInt(blockNumber uint64, addr [20]byte, key []byte) big.Int {
 	raw, err := client.GetAccountValueRaw(blockNumber, addr, key)
@@ -851,19 +1010,39 @@ func (client *Client) GetAccountValueAddress(blockNumber uint64, addr [20]byte,
 
 // GetAccountValueRaw returns account value
 func (client *Client) GetAccountValueRaw(blockNumber uint64, addr [20]byte, key []byte) ([]byte, error) {
+	return client.GetAccountValueRawContext(context.Background(), blockNumber, addr, key)
+}
+
+// GetAccountValueRawContext returns account value, the rpc calls are cancelled
+// when ctx is done
+func (client *Client) GetAccountValueRawContext(ctx context.Context, blockNumber uint64, addr [20]byte, key []byte) ([]byte, error) {
 	if blockNumber <= 0 {
 		bn, _ := client.LastValid()
 		blockNumber = uint64(bn)
 	}
-	acv, err := client.GetAccountValue(blockNumber, addr, key)
+	acvTree, err := client.getAccountProof(ctx, blockNumber, addr, key)
 	if err != nil {
 		return NullData, err
 	}
-	// get account roots
-	acr, err := client.GetAccountRoots(blockNumber, addr)
+	raw, err := acvTree.Get(key)
 	if err != nil {
 		return NullData, err
 	}
+	return raw, nil
+}
+
+// getAccountProof returns the merkle proof of the account value after checking
+// it against the account roots
+func (client *Client) getAccountProof(ctx context.Context, blockNumber uint64, addr [20]byte, key []byte) (edge.MerkleTree, error) {
+	acv, err := client.getAccountValue(ctx, blockNumber, addr, key)
+	if err != nil {
+		return edge.MerkleTree{}, err
+	}
+	// get account roots
+	acr, err := client.getAccountRoots(ctx, blockNumber, addr)
+	if err != nil {
+		return edge.MerkleTree{}, err
+	}
 	acvTree := acv.AccountTree()
 	// Verify the calculated proof value matches the specific known root
 	if acr.Find(acv.AccountRoot()) != int(acvTree.Modulo) {
@@ -871,22 +1050,65 @@ func (client *Client) GetAccountValueRaw(blockNumber uint64, addr [20]byte, key
 		// fmt.Printf("key := %#v\n", key)
 		// fmt.Printf("roots := %#v\n", acr)
 		// fmt.Printf("rawTestTree := %#v\n", acvTree.RawTree)
-		return NullData, fmt.Errorf("wrong merkle proof")
+		return edge.MerkleTree{}, fmt.Errorf("wrong merkle proof")
 	}
-	raw, err := acvTree.Get(key)
+	return acvTree, nil
+}
+
+// GetAccountValueProof returns the rlp encoded merkle proof of the account value
+func (client *Client) GetAccountValueProof(ctx context.Context, blockNumber uint64, addr [20]byte, key []byte) ([]byte, error) {
+	if blockNumber <= 0 {
+		bn, _ := client.LastValid()
+		blockNumber = uint64(bn)
+	}
+	acvTree, err := client.getAccountProof(ctx, blockNumber, addr, key)
 	if err != nil {
-		return NullData, err
+		return nil, err
 	}
-	return raw, nil
+	return rlp.EncodeToBytes(acvTree.RawTree)
+}
+
+// GetFleetDeviceList returns the devices of the device root of the fleet that
+// are revealed in the getaccountvalue proof, see edge.GetFleetDeviceList
+func (client *Client) GetFleetDeviceList(ctx context.Context, fleetAddr [20]byte, blockNumber uint64) ([][20]byte, error) {
+	if blockNumber <= 0 {
+		bn, _ := client.LastValid()
+		blockNumber = uint64(bn)
+	}
+	return edge.GetFleetDeviceList(ctx, fleetAddr, blockNumber, func(key []byte) ([]byte, error) {
+		return client.GetAccountValueProof(ctx, blockNumber, fleetAddr, key)
+	})
+}
+
+// FleetAccessList returns the iterator over the devices of the device root of
+// the fleet, see edge.NewFleetAccessListIterator
+func (client *Client) FleetAccessList(ctx context.Context, fleetAddr [20]byte, blockNumber uint64) (*edge.FleetAccessListIterator, error) {
+	if blockNumber <= 0 {
+		bn, _ := client.LastValid()
+		blockNumber = uint64(bn)
+	}
+	raw, err := client.GetAccountValueRawContext(ctx, blockNumber, fleetAddr, edge.DeviceRootKey())
+	if err != nil {
+		return nil, err
+	}
+	var deviceRoot [32]byte
+	copy(deviceRoot[:], util.PaddingBytesPrefix(raw, 0, 32))
+	return edge.NewFleetAccessListIterator(deviceRoot, func(key []byte) ([]byte, error) {
+		return client.GetAccountValueProof(ctx, blockNumber, fleetAddr, key)
+	}), nil
 }
 
 // GetAccountRoots returns account state roots
 func (client *Client) GetAccountRoots(blockNumber uint64, account [20]byte) (*edge.AccountRoots, error) {
+	return client.getAccountRoots(context.Background(), blockNumber, account)
+}
+
+func (client *Client) getAccountRoots(ctx context.Context, blockNumber uint64, account [20]byte) (*edge.AccountRoots, error) {
 	if blockNumber <= 0 {
 		bn, _ := client.LastValid()
 		blockNumber = uint64(bn)
 	}
-	rawAccountRoots, err := client.CallContext("getaccountroots", blockNumber, account[:])
+	rawAccountRoots, err := client.callWithContext(ctx, "getaccountroots", blockNumber, account[:])
 	if err != nil {
 		return nil, err
 	}
@@ -896,6 +1118,21 @@ func (client *Client) GetAccountRoots(blockNumber uint64, account [20]byte) (*ed
 	return nil, nil
 }
 
+// GetAccountRootsRange returns the account roots for each block from fromBlock to toBlock
+func (client *Client) GetAccountRootsRange(fromBlock uint64, toBlock uint64, account [20]byte) ([]edge.AccountRootsAtBlock, error) {
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("invalid block range %d-%d", fromBlock, toBlock)
+	}
+	rawRoots, err := client.CallContext("getaccountroots_range", fromBlock, toBlock, account[:])
+	if err != nil {
+		return nil, err
+	}
+	if roots, ok := rawRoots.([]edge.AccountRootsAtBlock); ok {
+		return roots, nil
+	}
+	return nil, nil
+}
+
 // ResolveReverseBNS resolves the (primary) destination of the BNS entry
 func (client *Client) ResolveReverseBNS(addr Address) (name string, err error) {
 	key := contract.BNSReverseEntryLocation(addr)
@@ -1065,10 +1302,14 @@ func (client *Client) IsDeviceAllowlisted(fleetAddr Address, clientAddr Address)
 	if fleetAddr == config.DefaultFleetAddr {
 		return true
 	}
-	key := contract.DeviceAllowlistKey(clientAddr)
-	num := client.GetAccountValueInt(0, fleetAddr, key)
-
-	return num.Int64() == 1
+	ctx, cancel := context.WithTimeout(context.Background(), portOpenCheckTimeout)
+	defer cancel()
+	allowed, err := client.portOpenGuard.Allow(ctx, clientAddr[:], fleetAddr)
+	if err != nil {
+		client.Log().Debug("Failed to check allowlist of device %x: %v", clientAddr, err)
+		return false
+	}
+	return allowed
 }
 
 // Closed returns whether client had closed
@@ -1076,8 +1317,37 @@ func (client *Client) Closed() bool {
 	return client.isClosed
 }
 
+// ShutdownGracefully stops accepting new calls, waits until the pending calls
+// received a response or ctx is done, sends goodbye and closes the client
+func (client *Client) ShutdownGracefully(ctx context.Context) error {
+	timeout := client.callTimeout(func() {
+		client.isShutdown = true
+	})
+	if timeout != nil {
+		return timeout
+	}
+	err := client.cm.WaitEmpty(ctx)
+	// calls without response are cancelled
+	client.cm.RemoveCalls()
+	if _, gerr := client.CastContext(nil, "goodbye", "shutdown", "client shutdown"); gerr != nil && err == nil {
+		err = gerr
+	}
+	client.Close()
+	return err
+}
+
 // Close rpc client
 func (client *Client) Close() {
+	client.close(false)
+}
+
+// closeLost closes the client after the connection to the server was lost,
+// the open ports are moved to another server
+func (client *Client) closeLost() {
+	client.close(true)
+}
+
+func (client *Client) close(moveSession bool) {
 	doCleanup := true
 	timeout := client.callTimeout(func() {
 		if client.isClosed {
@@ -1095,8 +1365,14 @@ func (client *Client) Close() {
 		}
 	})
 	if timeout == nil && doCleanup {
-		// remove open ports
+		// move the open ports to another server and remove the remaining ports
+		if moveSession && client.clientMan != nil {
+			client.clientMan.migrateSession(client)
+		}
 		client.pool.ClosePorts(client)
+		close(client.portsMoved)
+		client.blockCache.Close()
+		client.portSendQueue.Close()
 		client.srv.Shutdown(0)
 	}
 }
@@ -1128,6 +1404,7 @@ func (client *Client) doStart() (err error) {
 	}
 	go client.recvMessageLoop()
 	client.cm.SendCallPtr = client.sendCall
+	go client.portSendLoop()
 	return
 }
 
@@ -1149,7 +1426,7 @@ func (client *Client) doWatchLatestBlock() {
 	lastblock, _ := bq.Last()
 
 	start := time.Now()
-	blockPeak, err := client.GetBlockPeak()
+	blockPeak, err := client.blockPeak.Poll(context.Background())
 	elapsed := time.Since(start)
 	client.srv.Cast(func() { client.addLatencyMeasurement(elapsed) })
 
@@ -1196,6 +1473,10 @@ func (client *Client) initialize() (err error) {
 		err = fmt.Errorf("failed to get server id: %v", err)
 		return
 	}
+	err = client.checkServerNetwork()
+	if err != nil {
+		return
+	}
 	err = client.greet()
 	if err != nil {
 		return fmt.Errorf("failed to submitTicket to server: %v", err)
@@ -1204,5 +1485,16 @@ func (client *Client) initialize() (err error) {
 		client.onConnect(client.serverID)
 		go client.watchLatestBlock()
 	}
+	client.connectedOnce.Do(func() { close(client.connected) })
 	return
 }
+
+// waitConnected waits until the client is connected to the server or ctx is done
+func (client *Client) waitConnected(ctx context.Context) error {
+	select {
+	case <-client.connected:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -5,6 +5,7 @@
 package rpc
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"net"
@@ -12,10 +13,15 @@ import (
 	"time"
 
 	"github.com/diodechain/diode_client/config"
+	"github.com/diodechain/diode_client/edge"
 	"github.com/diodechain/diode_client/util"
 	"github.com/dominicletz/genserver"
 )
 
+// sessionMigrateTimeout is the timeout of moving the open ports of a closed
+// client to another server
+const sessionMigrateTimeout = 15 * time.Second
+
 // ClientManager struct for the client manager
 type ClientManager struct {
 	srv *genserver.GenServer
@@ -165,6 +171,63 @@ func (cm *ClientManager) startClient(host string) *Client {
 	return client
 }
 
+// dialSession starts a client that is connected to the given server
+func (cm *ClientManager) dialSession(ctx context.Context, server *edge.ServerObj) (edge.SessionTransport, error) {
+	var client *Client
+	cm.srv.Call(func() {
+		if cm.targetClients > 0 {
+			client = cm.startClient(string(server.Host))
+		}
+	})
+	if client == nil {
+		return nil, fmt.Errorf("couldn't connect to server: '%s'", server.Host)
+	}
+	if err := client.waitConnected(ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client.session.Transport, nil
+}
+
+// migrateSession moves the open ports of the closed client to another server,
+// the ports that are not moved are closed by the caller
+func (cm *ClientManager) migrateSession(client *Client) {
+	if len(client.session.Ports()) == 0 {
+		return
+	}
+	var host string
+	cm.srv.Call(func() {
+		if cm.targetClients > 0 {
+			host = cm.doSelectNextHost()
+		}
+	})
+	if host == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sessionMigrateTimeout)
+	defer cancel()
+	session, err := edge.MigrateSession(ctx, client.session, &edge.ServerObj{Host: []byte(host)})
+	if err != nil {
+		client.Log().Warn("Failed to move open ports to %s: %v", host, err)
+		return
+	}
+	newClient := session.Transport.(*clientTransport).client
+	for _, sessionPort := range session.Ports() {
+		oldKey := client.GetDeviceKey(sessionPort.PreviousRef)
+		port := cm.pool.GetPort(oldKey)
+		if port == nil {
+			newClient.CastPortClose(sessionPort.Ref)
+			continue
+		}
+		newClient.session.TrackPort(sessionPort.Ref, sessionPort.DeviceID, sessionPort.Port, sessionPort.Mode)
+		port.Migrate(newClient, sessionPort.Ref)
+		cm.pool.SetPort(oldKey, nil)
+		cm.pool.SetPort(newClient.GetDeviceKey(sessionPort.Ref), port)
+	}
+	client.Log().Info("Moved open ports to %s", host)
+}
+
 func (cm *ClientManager) GetPool() (datapool *DataPool) {
 	return cm.pool
 }
@@ -189,7 +252,7 @@ func (cm *ClientManager) GetClientorConnect(nodeID util.Address) (client *Client
 		fclient.Log().Error("GetServer(): failed to getnode %v", err)
 		return
 	}
-	if util.PubkeyToAddress(serverObj.ServerPubKey) != nodeID {
+	if addr, aerr := util.PubkeyToAddress(serverObj.ServerPubKey); aerr != nil || addr != nodeID {
 		err = fmt.Errorf("GetServer(): wrong signature in server object %+v", serverObj)
 		return
 	}
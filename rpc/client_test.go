@@ -0,0 +1,173 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/diodechain/diode_client/edge"
+	"github.com/diodechain/diode_client/rlp"
+	"github.com/dominicletz/genserver"
+)
+
+// newTestClient returns a client whose calls are answered by respond, calls
+// are not answered when respond returns nil
+func newTestClient(t *testing.T, respond func(c *Call) []interface{}) *Client {
+	client := &Client{
+		srv:           genserver.New("Client"),
+		cm:            NewCallManager(callQueueSize),
+		timer:         NewTimer(),
+		pool:          NewPool(),
+		blockCache:    edge.NewBlockCache(edge.DefaultBlockCacheSize),
+		portSendQueue: edge.NewPortSendQueue(portSendQueueSize, getRequestID),
+		connected:     make(chan struct{}),
+		portsMoved:    make(chan struct{}),
+	}
+	client.session = newClientSession(client)
+	client.cm.SendCallPtr = func(c *Call) error {
+		go func() {
+			payload := respond(c)
+			if payload == nil {
+				return
+			}
+			buffer, err := rlp.EncodeToBytes([]interface{}{c.id, payload})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			client.handleInboundMessage(edge.Message{Len: len(buffer), Buffer: buffer})
+		}()
+		return nil
+	}
+	return client
+}
+
+func TestPortOpenDenied(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(c *Call) []interface{} {
+		atomic.AddInt32(&calls, 1)
+		return []interface{}{"response", "not_allowed", "ref1"}
+	})
+	defer client.Close()
+	portOpen, err := client.PortOpen([20]byte{1}, 80, "tcp:80", "rw")
+	if !errors.Is(err, edge.ErrPortOpenDenied) {
+		t.Fatalf("expected ErrPortOpenDenied but got %v", err)
+	}
+	if portOpen == nil || portOpen.Ok || portOpen.Ref != "ref1" {
+		t.Fatalf("expected denied portopen but got %+v", portOpen)
+	}
+	if len(client.session.Ports()) != 0 {
+		t.Fatalf("denied port should not be part of the session")
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("denied portopen should be sent once, got %d calls", n)
+	}
+}
+
+func TestShutdownGracefully(t *testing.T) {
+	release := make(chan struct{})
+	client := newTestClient(t, func(c *Call) []interface{} {
+		if c.method == "goodbye" {
+			return nil
+		}
+		<-release
+		return []interface{}{"response", c.id}
+	})
+	var answered int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		call, err := client.CastContext(nil, "getblockpeak")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, ok := <-call.response
+			if ok && res == call.id {
+				atomic.AddInt32(&answered, 1)
+			}
+			client.cm.RemoveCallByID(call.id)
+		}()
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.ShutdownGracefully(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&answered); n != 10 {
+		t.Fatalf("all 10 calls should be answered before shutdown returns, got %d", n)
+	}
+	wg.Wait()
+	if !client.Closed() {
+		t.Fatalf("client should be closed after shutdown")
+	}
+}
+
+func TestPortSendLoop(t *testing.T) {
+	sent := make(chan *Call, 1)
+	client := newTestClient(t, func(c *Call) []interface{} {
+		sent <- c
+		return []interface{}{"response", "ok"}
+	})
+	go client.portSendLoop()
+	defer client.Close()
+	dropped, err := client.portSendQueue.TrySendPortPayload("ref1", []byte("data"))
+	if err != nil || dropped {
+		t.Fatalf("payload should be queued, dropped: %v err: %v", dropped, err)
+	}
+	select {
+	case call := <-sent:
+		if call.method != "portsend" || call.id != edge.ResponseID(call.data.Bytes()) {
+			t.Fatalf("unexpected call %s %d", call.method, call.id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("queued portsend was not sent")
+	}
+}
+
+func TestSendRemoteAck(t *testing.T) {
+	release := make(chan struct{})
+	client := newTestClient(t, func(c *Call) []interface{} {
+		if c.method == "portopen" {
+			return []interface{}{"response", "ok", "ref1"}
+		}
+		<-release
+		return []interface{}{"response", "ok"}
+	})
+	defer client.Close()
+	portOpen, err := client.PortOpen([20]byte{1}, 80, "tcp:80", "rw")
+	if err != nil || portOpen == nil || !portOpen.Ok {
+		t.Fatalf("portopen failed: %+v %v", portOpen, err)
+	}
+	ports := client.session.Ports()
+	if len(ports) != 1 || ports[0].Ref != "ref1" || ports[0].Port != "tcp:80" {
+		t.Fatalf("the opened port should be part of the session")
+	}
+
+	port := &ConnectedPort{Ref: "ref1", client: client, srv: genserver.New("Port")}
+	if err = port.SendRemote(make([]byte, edge.MaxPendingPortData/2)); err != nil {
+		t.Fatal(err)
+	}
+	full, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err = client.session.Track(full, "ref1", make([]byte, edge.MaxPendingPortData)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("data should be pending until the server received it, got %v", err)
+	}
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = client.session.Track(ctx, "ref1", make([]byte, edge.MaxPendingPortData)); err != nil {
+		t.Fatalf("received data should be acknowledged, got %v", err)
+	}
+}
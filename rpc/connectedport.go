@@ -9,6 +9,7 @@ package rpc
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net"
@@ -16,6 +17,7 @@ import (
 	"time"
 
 	"github.com/diodechain/diode_client/config"
+	"github.com/diodechain/diode_client/edge"
 	"github.com/diodechain/zap"
 	"github.com/dominicletz/genserver"
 )
@@ -138,18 +140,116 @@ func (port *ConnectedPort) SendRemote(data []byte) (err error) {
 		return
 	}
 
+	waitedForMove := false
+	for {
+		var client *Client
+		var ref string
+		port.srv.Call(func() {
+			err = port.remoteErr
+			client = port.client
+			ref = port.Ref
+		})
+		if err == nil && client == nil {
+			err = io.EOF
+		}
+		if err != nil {
+			return
+		}
+
+		// the session keeps the data until the server received it, so that it
+		// can be sent again when the port is moved to another server, this waits
+		// while too much data of the port is unacknowledged
+		tracked := true
+		err = client.session.Track(context.Background(), ref, data)
+		if err == edge.ErrSessionPortNotOpen {
+			if client.Closed() && !waitedForMove {
+				// the port is being moved to another server
+				<-client.portsMoved
+				waitedForMove = true
+				continue
+			}
+			// inbound ports are not part of the session
+			tracked = false
+			err = nil
+		} else if err != nil {
+			return
+		}
+
+		moved := false
+		port.srv.Call(func() {
+			if port.client != client || port.Ref != ref {
+				moved = true
+				return
+			}
+			if port.remoteErr != nil {
+				err = port.remoteErr
+				return
+			}
+			var call *Call
+			call, err = client.CastContext(nil, "portsend", ref, data)
+			if err == nil {
+				go port.waitPortSend(client, call, ref, len(data))
+			}
+		})
+		// tracked data of a moved port was sent again by the migration
+		if !moved || tracked {
+			return
+		}
+	}
+}
+
+// waitPortSend acknowledges the portsend data once the server received it,
+// the port is closed when the portsend failed and the port was not moved
+// to another server
+func (port *ConnectedPort) waitPortSend(client *Client, call *Call, ref string, n int) {
+	res, err := client.waitResponse(call)
+	if portSend, ok := res.(*edge.PortSend); ok && portSend.Ok {
+		client.session.Ack(ref, n)
+		return
+	}
+	if err == nil {
+		return
+	}
+	if _, ok := err.(CancelledError); ok {
+		if client.Closed() {
+			<-client.portsMoved
+			moved := true
+			port.srv.Call(func() { moved = port.client != client })
+			if moved {
+				return
+			}
+		}
+		err = io.EOF
+	}
+	port.srv.Cast(func() { port.remoteErr = err })
+	port.Close()
+}
+
+// Migrate moves the port to the given client and port reference
+func (port *ConnectedPort) Migrate(client *Client, ref string) {
+	port.srv.Call(func() {
+		port.client = client
+		port.Ref = ref
+	})
+}
+
+// TrySendRemote sends the data north-bound without blocking on a congested
+// connection, dropped is true if the data or a part of it was discarded
+func (port *ConnectedPort) TrySendRemote(data []byte) (dropped bool, err error) {
 	port.srv.Call(func() {
 		if port.remoteErr != nil {
 			err = port.remoteErr
 			return
 		}
-
-		var call *Call
-		call, err = port.client.CastContext(port, "portsend", port.Ref, data)
-		if err == nil {
-			go func() {
-				port.client.waitResponse(call)
-			}()
+		for len(data) > 0 && err == nil {
+			chunk := data
+			if len(chunk) > packetLimit {
+				chunk = chunk[:packetLimit]
+			}
+			var chunkDropped bool
+			chunkDropped, err = port.client.portSendQueue.TrySendPortPayload(port.Ref, chunk)
+			dropped = dropped || chunkDropped
+			data = data[len(chunk):]
 		}
 	})
 	return
@@ -184,6 +284,7 @@ func (port *ConnectedPort) close() {
 	if port.client != nil {
 		deviceKey := port.client.GetDeviceKey(port.Ref)
 		port.client.pool.SetPort(deviceKey, nil)
+		port.client.session.ClosePort(port.Ref)
 	}
 	// send portclose request and channel
 	port.client.CastPortClose(port.Ref)
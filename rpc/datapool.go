@@ -19,6 +19,7 @@ type DataPool struct {
 	locks          map[string]bool
 	devices        map[string]*ConnectedPort
 	publishedPorts map[int]*config.Port
+	portLimiter    *edge.PortLimiter
 
 	memoryCache *cache.Cache
 	bnsCache    *cache.Cache
@@ -39,6 +40,7 @@ func NewPool() *DataPool {
 		bnsCache:       cache.New(config.AppConfig.BnsCacheTime, config.AppConfig.BnsCacheTime*2),
 		devices:        make(map[string]*ConnectedPort),
 		publishedPorts: make(map[int]*config.Port),
+		portLimiter:    edge.NewPortLimiter(edge.DefaultMaxPortsPerDevice, edge.DefaultMaxTotalPorts),
 	}
 	if !config.AppConfig.LogDateTime {
 		pool.srv.DeadlockCallback = nil
@@ -281,6 +283,11 @@ func (p *DataPool) SetPort(key string, dev *ConnectedPort) {
 	})
 }
 
+// PortLimiter returns the limiter of concurrently open inbound ports
+func (p *DataPool) PortLimiter() *edge.PortLimiter {
+	return p.portLimiter
+}
+
 func (p *DataPool) GetPublishedPort(portnum int) (port *config.Port) {
 	p.srv.Call(func() { port = p.publishedPorts[portnum] })
 	return
@@ -84,7 +84,10 @@ func TestE2ETunnels(t *testing.T) {
 	defer cb.Close()
 
 	pubKey := LoadClientPubKey()
-	ID := util.PubkeyToAddress(pubKey)
+	ID, err := util.PubkeyToAddress(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	errCh := make(chan error)
 	// client
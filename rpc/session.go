@@ -0,0 +1,61 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/diodechain/diode_client/edge"
+)
+
+// clientTransport is the session transport of the edge server connection of a client
+type clientTransport struct {
+	client *Client
+}
+
+// PortOpen opens the port of the device
+func (transport *clientTransport) PortOpen(ctx context.Context, deviceID edge.Address, port string, mode string) (*edge.PortOpen, error) {
+	res, err := transport.client.callWithContext(ctx, "portopen", deviceID[:], port, mode)
+	if err != nil {
+		return nil, err
+	}
+	if portOpen, ok := res.(*edge.PortOpen); ok {
+		return portOpen, portOpen.Err
+	}
+	return nil, fmt.Errorf("unexpected portopen response %T", res)
+}
+
+// PortSend sends the data to the port and returns once the server received it
+func (transport *clientTransport) PortSend(ctx context.Context, ref string, data []byte) error {
+	res, err := transport.client.callWithContext(ctx, "portsend", ref, data)
+	if err != nil {
+		return err
+	}
+	if portSend, ok := res.(*edge.PortSend); !ok || !portSend.Ok {
+		return fmt.Errorf("portsend to %s failed", ref)
+	}
+	return nil
+}
+
+// PortClose closes the port
+func (transport *clientTransport) PortClose(ref string) error {
+	return transport.client.CastPortClose(ref)
+}
+
+// Close closes the client
+func (transport *clientTransport) Close() error {
+	transport.client.Close()
+	return nil
+}
+
+// newClientSession returns the session of the client, the ports of the
+// session are moved to another edge server when the client is closed
+func newClientSession(client *Client) *edge.Session {
+	var dial edge.SessionDialer
+	if client.clientMan != nil {
+		dial = client.clientMan.dialSession
+	}
+	return edge.AttachSession(&edge.ServerObj{Host: []byte(client.host)}, &clientTransport{client: client}, dial)
+}
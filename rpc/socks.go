@@ -368,11 +368,7 @@ func (socksServer *Server) doConnectDevice(requestId int64, deviceName string, p
 
 			var portOpen *edge.PortOpen
 			portOpen, err = client.PortOpen(deviceID, port, portName, mode)
-			if err != nil {
-				return
-			}
-			if portOpen != nil && portOpen.Err != nil {
-				err = portOpen.Err
+			if err != nil || portOpen == nil {
 				return
 			}
 			portOpen.PortNumber = port
@@ -408,7 +404,7 @@ func (socksServer *Server) doConnectDevice(requestId int64, deviceName string, p
 
 	msg := fmt.Sprintf("doConnectDevice() for '%v' failed: %v with %v candidates", deviceName, err, len(candidates))
 	socksServer.logger.Error(msg)
-	if _, ok := err.(RPCError); ok {
+	if _, ok := err.(RPCError); ok || errors.Is(err, edge.ErrPortOpenDenied) {
 		return nil, HttpError{404, DeviceError{err}}
 	}
 	return nil, HttpError{500, fmt.Errorf(msg)}
@@ -604,7 +600,7 @@ func parseHost(host string) (isWS bool, mode string, deviceID string, port int,
 	}
 	if len(modeHostPort[1]) > 0 {
 		mode = modeHostPort[1]
-		mode = mode[:len(mode)-1]
+		mode = edge.NormalizePortMode(mode[:len(mode)-1])
 	}
 	if domain == "diode.link" || domain == "diode" || domain == "diode.ws" {
 		deviceID = modeHostPort[2]
@@ -792,9 +788,12 @@ func (socksServer *Server) forwardUDP(pconn net.PacketConn, raddr net.Addr, devi
 	if connPort != nil {
 		bs := make([]byte, 4)
 		binary.LittleEndian.PutUint32(bs, uint32(len(data)))
-		err := connPort.SendRemote(append(bs, data...))
+		// datagrams are dropped instead of blocking on a congested connection
+		dropped, err := connPort.TrySendRemote(append(bs, data...))
 		if err != nil {
 			socksServer.logger.Error("forwardUDP error: PortSend(): %v", err)
+		} else if dropped {
+			socksServer.logger.Debug("forwardUDP: dropped %d bytes on congested connection", len(data))
 		}
 		return
 	}
@@ -147,8 +147,7 @@ func GetConnectionID(conn *openssl.Conn) ([20]byte, error) {
 	if err != nil {
 		return [20]byte{}, err
 	}
-	hashPubKey := util.PubkeyToAddress(pubKey)
-	return hashPubKey, nil
+	return util.PubkeyToAddress(pubKey)
 }
 
 // GetCertificatePubKey returns server uncompressed public key
@@ -113,8 +113,11 @@ func restoreLastValid() (uint64, crypto.Sha3) {
 	lvbn, err := db.DB.Get(lvbnKey)
 	var lvbh []byte
 	if err == nil {
-		lvbnNum := util.DecodeBytesToUint(lvbn)
-		lvbh, err = db.DB.Get(lvbhKey)
+		var lvbnNum uint64
+		lvbnNum, err = util.DecodeBytesToUint(lvbn)
+		if err == nil {
+			lvbh, err = db.DB.Get(lvbhKey)
+		}
 		if err == nil {
 			var hash [32]byte
 			copy(hash[:], lvbh)
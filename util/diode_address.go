@@ -0,0 +1,44 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package util
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiodeAddress is a resolved diode address
+type DiodeAddress struct {
+	Raw [20]byte
+}
+
+// Address returns the diode address as Address
+func (da DiodeAddress) Address() Address {
+	return Address(da.Raw)
+}
+
+// String returns the hex encoded address
+func (da DiodeAddress) String() string {
+	return EncodeToString(da.Raw[:])
+}
+
+// ResolveDiodeAddress returns the address of a 0x prefixed hex name directly,
+// other names are looked up with resolver
+func ResolveDiodeAddress(ctx context.Context, name string, resolver func(name string) ([20]byte, error)) (DiodeAddress, error) {
+	if IsAddress([]byte(name)) {
+		addr, err := DecodeAddress(name)
+		return DiodeAddress{Raw: addr}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return DiodeAddress{}, err
+	}
+	if resolver == nil {
+		return DiodeAddress{}, fmt.Errorf("cannot resolve %s without resolver", name)
+	}
+	raw, err := resolver(name)
+	if err != nil {
+		return DiodeAddress{}, fmt.Errorf("cannot resolve %s: %w", name, err)
+	}
+	return DiodeAddress{Raw: raw}, nil
+}
@@ -0,0 +1,46 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package util
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestResolveDiodeAddress(t *testing.T) {
+	sensor := [20]byte{1, 2, 3}
+	mock := func(name string) ([20]byte, error) {
+		if name == "sensor1" {
+			return sensor, nil
+		}
+		return [20]byte{}, fmt.Errorf("%s not found", name)
+	}
+	ctx := context.Background()
+	addr, err := ResolveDiodeAddress(ctx, "sensor1", mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.Raw != sensor {
+		t.Errorf("expected %x but got %s", sensor, addr)
+	}
+
+	hexAddr := "0x937c492a77ae90de971986d003ffbc5f8bb2232c"
+	addr, err = ResolveDiodeAddress(ctx, hexAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.String() != hexAddr {
+		t.Errorf("expected %s but got %s", hexAddr, addr)
+	}
+
+	if _, err = ResolveDiodeAddress(ctx, "sensor2", mock); err == nil {
+		t.Errorf("expected resolve error for sensor2")
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = ResolveDiodeAddress(canceled, "sensor1", mock); err != context.Canceled {
+		t.Errorf("expected context.Canceled but got %v", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"math/big"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/diodechain/diode_client/crypto"
 
@@ -28,6 +29,8 @@ var (
 	hexStringBase     = []byte("0123456789abcdefABCDEF")
 	addressLength     = 40
 	subDomainpattern  = regexp.MustCompile(`^(0x[A-Fa-f0-9]{40}|[A-Za-z0-9]{1,20}-[A-Za-z0-9]{1,20}|[A-Za-z0-9]{1,30})$`)
+
+	ErrValueOverflow = fmt.Errorf("value doesn't fit into uint64")
 )
 
 func isHexBytes(src []byte) bool {
@@ -113,6 +116,11 @@ func EncodeToString(src []byte) string {
 	return out
 }
 
+// EncodeToStringUpper encode bytes to upper case hex string (0X prefixed)
+func EncodeToStringUpper(src []byte) string {
+	return upperPrefix + strings.ToUpper(hex.EncodeToString(src))
+}
+
 // DecodeString decode string to bytes
 func DecodeString(src string) (dst []byte, err error) {
 	srcByt := []byte(strings.ToLower(src))
@@ -128,6 +136,53 @@ func DecodeString(src string) (dst []byte, err error) {
 	return
 }
 
+// EncodeToStringOmitEmpty encode bytes to string, empty bytes are encoded to
+// an empty string instead of 0x
+func EncodeToStringOmitEmpty(src []byte) string {
+	if len(src) == 0 {
+		return ""
+	}
+	return EncodeToString(src)
+}
+
+// DecodeStringOmitEmpty decode string to bytes, an empty string is decoded to nil
+func DecodeStringOmitEmpty(src string) ([]byte, error) {
+	if src == "" {
+		return nil, nil
+	}
+	return DecodeString(src)
+}
+
+// DecodeStringBatch decodes the hex strings with up to concurrency goroutines,
+// the results and errors are in the same order as srcs
+func DecodeStringBatch(srcs []string, concurrency int) ([][]byte, []error) {
+	dsts := make([][]byte, len(srcs))
+	errs := make([]error, len(srcs))
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > len(srcs) {
+		concurrency = len(srcs)
+	}
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range indexes {
+				dsts[j], errs[j] = DecodeString(srcs[j])
+			}
+		}()
+	}
+	for i := range srcs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return dsts, errs
+}
+
 // DecodeStringToIntForce decode string to int
 func DecodeStringToIntForce(src string) uint64 {
 	ret, _ := DecodeStringToInt(src)
@@ -144,6 +199,21 @@ func DecodeStringToInt(src string) (uint64, error) {
 	return out.Uint64(), nil
 }
 
+// DecodeStringToUint256 decodes the hex string to an unsigned 256 bit integer
+func DecodeStringToUint256(src string) (*big.Int, error) {
+	if strings.HasPrefix(src, "-") {
+		return nil, fmt.Errorf("DecodeStringToUint256(): Cannot decode negative value '%v'", src)
+	}
+	outByt, err := DecodeString(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(outByt) > 32 {
+		return nil, ErrValueOverflow
+	}
+	return new(big.Int).SetBytes(outByt), nil
+}
+
 // EncodeForce encode bytes
 func EncodeForce(src []byte) (dst []byte) {
 	dst = make([]byte, len(src)*2)
@@ -202,9 +272,13 @@ func DecodeBytesToBigInt(src []byte) *big.Int {
 	return outBig
 }
 
-// DecodeBytesToUint returns int of given bytes
-func DecodeBytesToUint(src []byte) uint64 {
-	return DecodeBytesToBigInt(src).Uint64()
+// DecodeBytesToUint returns uint64 of given bytes, it returns ErrValueOverflow
+// if the given bytes are longer than 8 bytes
+func DecodeBytesToUint(src []byte) (uint64, error) {
+	if len(src) > 8 {
+		return 0, ErrValueOverflow
+	}
+	return DecodeBytesToBigInt(src).Uint64(), nil
 }
 
 // DecodeIntToBytes returns bytes of the given int
@@ -6,6 +6,9 @@ package util
 import (
 	"bytes"
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
 	"testing"
 )
 
@@ -265,6 +268,27 @@ func TestDecodeString(t *testing.T) {
 	}
 }
 
+func TestDecodeStringBatch(t *testing.T) {
+	srcs := make([]string, 1000)
+	for i := range srcs {
+		srcs[i] = fmt.Sprintf("0x%040x", i*7919)
+	}
+	srcs[500] = "0xzz"
+	dsts, errs := DecodeStringBatch(srcs, 8)
+	if len(dsts) != len(srcs) || len(errs) != len(srcs) {
+		t.Fatalf("expected %d results but got %d and %d errors", len(srcs), len(dsts), len(errs))
+	}
+	for i, src := range srcs {
+		dst, err := DecodeString(src)
+		if (err == nil) != (errs[i] == nil) {
+			t.Fatalf("error of %s doesn't match: %v %v", src, err, errs[i])
+		}
+		if !bytes.Equal(dst, dsts[i]) {
+			t.Fatalf("result of %s doesn't match: %x %x", src, dst, dsts[i])
+		}
+	}
+}
+
 func TestDecodeBytesToInt(t *testing.T) {
 	for _, v := range decodeBytesIntTest {
 		res := DecodeBytesToInt(v.Src)
@@ -276,13 +300,26 @@ func TestDecodeBytesToInt(t *testing.T) {
 
 func TestDecodeBytesToUint(t *testing.T) {
 	for _, v := range decodeBytesUintTest {
-		res := DecodeBytesToUint(v.Src)
-		if v.Res != res {
+		res, err := DecodeBytesToUint(v.Src)
+		if err != nil || v.Res != res {
 			t.Errorf("Wrong result when call DecodeBytesToUint")
 		}
 	}
 }
 
+func TestDecodeBytesToUintOverflow(t *testing.T) {
+	res, err := DecodeBytesToUint(bytes.Repeat([]byte{0xff}, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != math.MaxUint64 {
+		t.Errorf("expected %d but got %d", uint64(math.MaxUint64), res)
+	}
+	if _, err = DecodeBytesToUint(make([]byte, 9)); err != ErrValueOverflow {
+		t.Errorf("expected ErrValueOverflow but got %v", err)
+	}
+}
+
 func TestDecodeIntToBytes(t *testing.T) {
 	for _, v := range decodeBytesIntTest {
 		res := DecodeIntToBytes(v.Res)
@@ -310,6 +347,36 @@ func TestEncodeToString(t *testing.T) {
 	}
 }
 
+func TestEncodeToStringUpper(t *testing.T) {
+	res := EncodeToStringUpper([]byte{1, 0xab})
+	if res != "0X01AB" {
+		t.Errorf("Wrong result when call EncodeToStringUpper: %s", res)
+	}
+	if !IsHexNumber([]byte(EncodeToStringUpper([]byte{1}))) {
+		t.Errorf("Upper case hex should be a hex number")
+	}
+	if IsHex([]byte(EncodeToStringUpper([]byte{1}))) {
+		t.Errorf("Upper case hex should not be hex")
+	}
+}
+
+func TestEncodeToStringOmitEmpty(t *testing.T) {
+	if res := EncodeToStringOmitEmpty(nil); res != "" {
+		t.Errorf("Wrong result when call EncodeToStringOmitEmpty: %s", res)
+	}
+	if res := EncodeToStringOmitEmpty([]byte{1, 0xab}); res != "0x01ab" {
+		t.Errorf("Wrong result when call EncodeToStringOmitEmpty: %s", res)
+	}
+	res, err := DecodeStringOmitEmpty("")
+	if err != nil || res != nil {
+		t.Errorf("Wrong result when call DecodeStringOmitEmpty: %v %v", res, err)
+	}
+	res, err = DecodeStringOmitEmpty("0x01ab")
+	if err != nil || !bytes.Equal(res, []byte{1, 0xab}) {
+		t.Errorf("Wrong result when call DecodeStringOmitEmpty: %v %v", res, err)
+	}
+}
+
 func TestEncodeForce(t *testing.T) {
 	for _, v := range decodeStringTest {
 		res := fmt.Sprintf("0x%s", string(EncodeForce(v.Res)))
@@ -318,3 +385,31 @@ func TestEncodeForce(t *testing.T) {
 		}
 	}
 }
+
+func TestDecodeStringToUint256(t *testing.T) {
+	res, err := DecodeStringToUint256("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	if res.Cmp(expected) != 0 {
+		t.Errorf("expected %s but got %s", expected, res)
+	}
+	if _, err = DecodeStringToUint256("0x01" + strings.Repeat("00", 32)); err != ErrValueOverflow {
+		t.Errorf("expected ErrValueOverflow but got %v", err)
+	}
+	if _, err = DecodeStringToUint256("-0x01"); err == nil {
+		t.Errorf("expected error for negative value")
+	}
+}
+
+func TestRLPHashKeccak(t *testing.T) {
+	// keccak256(rlp([])) is the ethereum empty uncles hash
+	hash, err := RLPHash([]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if EncodeToString(hash) != "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347" {
+		t.Errorf("Wrong result when call RLPHash: %s", EncodeToString(hash))
+	}
+}
@@ -70,10 +70,10 @@ func CreateAddress(b Address, nonce uint64) (addr Address) {
 }
 
 // PubkeyToAddress returns diode address
-func PubkeyToAddress(pubkey []byte) (addr Address) {
-	dpubkey := crypto.PubkeyFromCompressed(pubkey)
-	hashPubkey := crypto.Sha3Hash(dpubkey)
-	copy(addr[:], hashPubkey[12:])
+func PubkeyToAddress(pubkey []byte) (addr Address, err error) {
+	var raw []byte
+	raw, err = crypto.PubkeyToAddress(pubkey)
+	copy(addr[:], raw)
 	return
 }
 
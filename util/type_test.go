@@ -57,10 +57,20 @@ func TestPubkeyToAddress(t *testing.T) {
 	if len(pubkey) != 65 {
 		t.Fatalf("Couldn't marshal public key")
 	}
-	pubAddr := PubkeyToAddress(pubkey)
+	pubAddr, err := PubkeyToAddress(pubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if pubAddr.HexString() != pubAddrHex {
 		t.Errorf("Failed to convert public key to address")
 	}
+	compressedAddr, err := PubkeyToAddress(secp256k1.CompressPubkeyBytes(pubkey))
+	if err != nil || compressedAddr != pubAddr {
+		t.Errorf("Failed to convert compressed public key to address: %v", err)
+	}
+	if _, err = PubkeyToAddress(pubkey[:64]); err == nil {
+		t.Errorf("Public key of invalid length should fail")
+	}
 }
 
 func TestCreateAddress(t *testing.T) {
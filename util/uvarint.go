@@ -0,0 +1,38 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package util
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// WriteUvarint writes n as variable-length integer following the encoding/binary convention
+func WriteUvarint(w io.Writer, n uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	size := binary.PutUvarint(buf[:], n)
+	_, err := w.Write(buf[:size])
+	return err
+}
+
+// ReadUvarint reads a variable-length integer written by WriteUvarint
+func ReadUvarint(r io.Reader) (uint64, error) {
+	if br, ok := r.(io.ByteReader); ok {
+		return binary.ReadUvarint(br)
+	}
+	return binary.ReadUvarint(&byteReader{r: r})
+}
+
+// byteReader reads single bytes so ReadUvarint doesn't consume more than the uvarint
+type byteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (br *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(br.r, br.buf[:]); err != nil {
+		return 0, err
+	}
+	return br.buf[0], nil
+}
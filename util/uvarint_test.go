@@ -0,0 +1,60 @@
+// Diode Network Client
+// Copyright 2021 Diode
+// Licensed under the Diode License, Version 1.1
+package util
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+type onlyReader struct {
+	r *bytes.Reader
+}
+
+func (or onlyReader) Read(p []byte) (int, error) {
+	return or.r.Read(p)
+}
+
+func TestUvarintRoundTrip(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		size int
+	}{
+		{0, 1},
+		{127, 1},
+		{128, 2},
+		{math.MaxUint64, 10},
+	}
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		if err := WriteUvarint(buf, test.n); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != test.size {
+			t.Errorf("expected %d to be encoded in %d bytes but got %d", test.n, test.size, buf.Len())
+		}
+		raw := buf.Bytes()
+		n, err := ReadUvarint(bytes.NewReader(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != test.n {
+			t.Errorf("expected %d but got %d", test.n, n)
+		}
+		n, err = ReadUvarint(onlyReader{bytes.NewReader(raw)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != test.n {
+			t.Errorf("expected %d but got %d", test.n, n)
+		}
+	}
+}
+
+func TestReadUvarintEOF(t *testing.T) {
+	if _, err := ReadUvarint(bytes.NewReader([]byte{0x80})); err == nil {
+		t.Errorf("expected error for truncated uvarint")
+	}
+}